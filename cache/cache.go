@@ -0,0 +1,13 @@
+// Package cache provides key/value stores used to cache service responses.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores string values with a time to live. Get reports whether the key was found.
+type Cache interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+}
@@ -0,0 +1,194 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := NewLRU(2)
+	c.now = func() time.Time { return now }
+
+	c.Set(ctx, "a", "1", 0)
+	c.Set(ctx, "b", "2", time.Minute)
+	_, ok, _ := c.Get(ctx, "a") // a is now the most recently used
+	assert.True(t, ok)
+
+	c.Set(ctx, "c", "3", 0)
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok, "b should have been evicted")
+	v, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", v)
+	assert.Equal(t, 2, c.Len())
+
+	c.Set(ctx, "c", "4", time.Minute)
+	v, _, _ = c.Get(ctx, "c")
+	assert.Equal(t, "4", v)
+
+	now = now.Add(time.Minute)
+	_, ok, _ = c.Get(ctx, "c")
+	assert.False(t, ok, "c should have expired")
+	assert.Equal(t, 1, c.Len())
+}
+
+// fakeRedis is a minimal Redis server supporting AUTH, GET and SET with PX. Other
+// commands are refused until the client authenticated with the password.
+type fakeRedis struct {
+	lis      net.Listener
+	password string
+	mu       sync.Mutex
+	values   map[string]string
+	expires  map[string]time.Time
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{lis: lis, password: password, values: map[string]string{}, expires: map[string]time.Time{}}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+// readCommand reads a command sent as an array of bulk strings.
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = rd.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(line), "$"))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	authenticated := f.password == ""
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "AUTH":
+			if authenticated = args[len(args)-1] == f.password; authenticated {
+				fmt.Fprint(conn, "+OK\r\n")
+			} else {
+				fmt.Fprint(conn, "-WRONGPASS invalid username-password pair\r\n")
+			}
+		case !authenticated:
+			fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+		case cmd == "GET":
+			v, ok := f.values[args[1]]
+			if exp, set := f.expires[args[1]]; set && time.Now().After(exp) {
+				ok = false
+			}
+			if !ok {
+				fmt.Fprint(conn, "$-1\r\n")
+			} else {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(v), v)
+			}
+		case cmd == "SET":
+			f.values[args[1]] = args[2]
+			delete(f.expires, args[1])
+			if len(args) == 5 && strings.ToUpper(args[3]) == "PX" {
+				ms, _ := strconv.Atoi(args[4])
+				f.expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			}
+			fmt.Fprint(conn, "+OK\r\n")
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+		}
+		f.mu.Unlock()
+	}
+}
+
+func Test_Redis(t *testing.T) {
+	f := newFakeRedis(t, "secret")
+	defer f.lis.Close()
+
+	ctx := context.Background()
+	c := NewRedis(redis.Options{Addr: f.lis.Addr().String(), Password: "secret"})
+	defer c.Close()
+
+	_, ok, err := c.Get(ctx, "greet:hello")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.Nil(t, c.Set(ctx, "greet:hello", "hello\r\nworld", 0))
+	v, ok, err := c.Get(ctx, "greet:hello")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "hello\r\nworld", v)
+
+	assert.Nil(t, c.Set(ctx, "short", "lived", 20*time.Millisecond))
+	time.Sleep(30 * time.Millisecond)
+	_, ok, _ = c.Get(ctx, "short")
+	assert.False(t, ok)
+
+	err = c.client.Do(ctx, "FLUSHALL").Err()
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "ERR unknown command 'FLUSHALL'", err.Error())
+	}
+	// error replies keep the connection usable
+	_, ok, err = c.Get(ctx, "greet:hello")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// a wrong password fails every command
+	wrong := NewRedis(redis.Options{Addr: f.lis.Addr().String(), Password: "guess"})
+	defer wrong.Close()
+	_, _, err = wrong.Get(ctx, "greet:hello")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "WRONGPASS invalid username-password pair", err.Error())
+	}
+}
+
+func Test_RedisUnavailable(t *testing.T) {
+	c := NewRedis(redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond})
+	defer c.Close()
+	_, _, err := c.Get(context.Background(), "key")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process cache holding at most size entries, evicting the least recently
+// used entry when full. Expired entries are dropped when they are read.
+type LRU struct {
+	mu      sync.Mutex
+	size    int
+	ll      *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+type entry struct {
+	key     string
+	value   string
+	expires time.Time
+}
+
+func NewLRU(size int) *LRU {
+	return &LRU{
+		size:    size,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+func (c *LRU) Get(_ context.Context, key string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return "", false, nil
+	}
+	e := el.Value.(*entry)
+	if !e.expires.IsZero() && !c.now().Before(e.expires) {
+		c.ll.Remove(el)
+		delete(c.entries, key)
+		return "", false, nil
+	}
+	c.ll.MoveToFront(el)
+	return e.value, true, nil
+}
+
+// Set stores value under key. A ttl of zero never expires.
+func (c *LRU) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.now().Add(ttl)
+	}
+	if el, ok := c.entries[key]; ok {
+		el.Value = &entry{key, value, expires}
+		c.ll.MoveToFront(el)
+		return nil
+	}
+	c.entries[key] = c.ll.PushFront(&entry{key, value, expires})
+	for c.size > 0 && c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+	}
+	return nil
+}
+
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
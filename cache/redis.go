@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis is a cache backed by a Redis server through go-redis.
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis returns a cache for the server described by options, which carry the
+// address, the credentials (Username and Password) and the TLS configuration. The
+// dial, read and write timeouts bound each command. Failed commands are not retried:
+// a cache that is slow to answer only makes requests slower.
+func NewRedis(options redis.Options) *Redis {
+	options.MaxRetries = -1
+	return &Redis{client: redis.NewClient(&options)}
+}
+
+func (c *Redis) Get(ctx context.Context, key string) (string, bool, error) {
+	v, err := c.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return v, true, nil
+}
+
+func (c *Redis) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (c *Redis) Close() error {
+	return c.client.Close()
+}
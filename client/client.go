@@ -0,0 +1,136 @@
+// Package client provides remote implementations of svc.Greeter over HTTP and gRPC,
+// so callers can swap a local GreetingService for a remote one.
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/tkeech1/gowebsvc/retry"
+	service "github.com/tkeech1/gowebsvc/svc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// DefaultPolicies retries Greet, which is idempotent, and never retries Expensive.
+var DefaultPolicies = map[string]retry.Policy{
+	"Greet": retry.DefaultPolicy,
+}
+
+// Config configures a remote client.
+type Config struct {
+	// Timeout bounds each call, including retries. Defaults to 10 seconds.
+	Timeout time.Duration
+	// Policies holds the retry policy per method ("Greet", "Expensive"). Only transport
+	// failures are retried, never service errors. Defaults to DefaultPolicies.
+	Policies map[string]retry.Policy
+	// Budget, when set, limits retries and hedged requests across all calls.
+	Budget *retry.Budget
+	// HTTPClient is used by the HTTP client. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// TransportError is returned when the server could not be reached or returned an
+// unreadable response, as opposed to a service error such as svc.ErrEmptyGreeting.
+type TransportError struct {
+	Err error
+}
+
+func (e TransportError) Error() string {
+	return e.Err.Error()
+}
+
+// Client implements svc.Greeter on top of go-kit client endpoints. The endpoints take
+// svc.GreetRequest / svc.ExpensiveRequest and return svc.GreetResponse /
+// svc.ExpensiveResponse.
+type Client struct {
+	GreetEndpoint     endpoint.Endpoint
+	ExpensiveEndpoint endpoint.Endpoint
+}
+
+func (c Client) Greet(ctx context.Context, greeting string) (string, error) {
+	response, err := c.GreetEndpoint(ctx, service.GreetRequest{S: greeting})
+	if err != nil {
+		return "", decodeError(err)
+	}
+	r := response.(service.GreetResponse)
+	if r.Err != "" {
+		return "", service.DecodeError(r.Err)
+	}
+	return r.V, nil
+}
+
+func (c Client) Expensive(ctx context.Context, connectionString, username, password string) (string, error) {
+	request := service.ExpensiveRequest{C: connectionString, U: username, P: password}
+	response, err := c.ExpensiveEndpoint(ctx, request)
+	if err != nil {
+		return "", decodeError(err)
+	}
+	r := response.(service.ExpensiveResponse)
+	if r.Err != "" {
+		return "", service.DecodeError(r.Err)
+	}
+	return r.V, nil
+}
+
+// endpoint wraps e with the retry policy for method and bounds each call by cfg.Timeout.
+func (cfg Config) endpoint(method string, e endpoint.Endpoint) endpoint.Endpoint {
+	policies := cfg.Policies
+	if policies == nil {
+		policies = DefaultPolicies
+	}
+	policy := policies[method]
+	if policy.Retryable == nil {
+		policy.Retryable = retryable
+	}
+	e = retry.Middleware(policy, cfg.Budget)(e)
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return e(ctx, request)
+	}
+}
+
+// retryable reports whether err is a transient failure: the server could not be
+// reached (a network error), answered with a 5xx or 429 status, or failed the call
+// with an unavailable/exhausted gRPC status. Other gRPC status errors carry a service
+// error and, like unreadable responses, are final.
+func retryable(err error) bool {
+	switch err.(type) {
+	case net.Error:
+		return true
+	case StatusError:
+		return true
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch s.Code() {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+func decodeError(err error) error {
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.PermissionDenied:
+			return service.ErrPermissionDenied
+		case codes.Unknown, codes.InvalidArgument, codes.FailedPrecondition:
+			return service.DecodeError(s.Message())
+		}
+	}
+	return TransportError{err}
+}
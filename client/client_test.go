@@ -0,0 +1,207 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tkeech1/gowebsvc/middleware"
+	"github.com/tkeech1/gowebsvc/retry"
+	service "github.com/tkeech1/gowebsvc/svc"
+	"google.golang.org/grpc"
+)
+
+// newHTTPServer serves the v2 greeting API under prefix the way the HTTP servers do,
+// failing the first `failures` requests to either path with a non-JSON 502.
+func newHTTPServer(prefix string, failures int32) (*httptest.Server, *int32) {
+	var calls int32
+	svc := service.GreetingService{}
+	mux := http.NewServeMux()
+	mux.HandleFunc(prefix+"/v2/greeting", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		var request service.GreetRequestV2
+		json.NewDecoder(r.Body).Decode(&request)
+		v, err := svc.Greet(r.Context(), request.Name)
+		response := service.GreetResponse{V: v}
+		if err != nil {
+			response.Err = err.Error()
+			w.WriteHeader(service.HTTPStatus(err))
+		}
+		json.NewEncoder(w).Encode(response)
+	})
+	mux.HandleFunc(prefix+"/v2/expensive", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		var request service.ExpensiveRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		v, err := svc.Expensive(r.Context(), request.C, request.U, request.P)
+		response := service.ExpensiveResponse{V: v}
+		if err != nil {
+			response.Err = err.Error()
+			w.WriteHeader(service.HTTPStatus(err))
+		}
+		json.NewEncoder(w).Encode(response)
+	})
+	return httptest.NewServer(mux), &calls
+}
+
+func Test_HTTPClient(t *testing.T) {
+	ts, _ := newHTTPServer("/greeter", 0)
+	defer ts.Close()
+
+	// the v2 paths are joined to the path of the base URL
+	svc, err := NewHTTPClient(ts.URL+"/greeter", Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		call             func() (string, error)
+		expectedResponse string
+		errorResponse    error
+	}{
+		"greet": {
+			call:             func() (string, error) { return svc.Greet(context.Background(), "hello") },
+			expectedResponse: "hello",
+		},
+		"greet_empty": {
+			call:          func() (string, error) { return svc.Greet(context.Background(), "") },
+			errorResponse: service.ErrEmptyGreeting,
+		},
+		"expensive": {
+			call:             func() (string, error) { return svc.Expensive(context.Background(), "c", "u", "p") },
+			expectedResponse: "cup",
+		},
+		"expensive_nousername": {
+			call:          func() (string, error) { return svc.Expensive(context.Background(), "c", "", "p") },
+			errorResponse: service.ErrMissingUsername,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		response, err := test.call()
+		assert.Equal(t, test.expectedResponse, response)
+		assert.True(t, test.errorResponse == err, "expected %v, got %v", test.errorResponse, err)
+	}
+}
+
+func Test_HTTPClientRetries(t *testing.T) {
+	ts, calls := newHTTPServer("", 2)
+	defer ts.Close()
+
+	policy := retry.Policy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+	svc, _ := NewHTTPClient(ts.URL, Config{Policies: map[string]retry.Policy{"Greet": policy}})
+	_, err := svc.Greet(context.Background(), "hello")
+	_, ok := err.(TransportError)
+	assert.True(t, ok)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+
+	response, err := svc.Greet(context.Background(), "hello")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", response)
+
+	// service errors are not retried
+	_, err = svc.Greet(context.Background(), "")
+	assert.True(t, err == service.ErrEmptyGreeting)
+	assert.Equal(t, int32(4), atomic.LoadInt32(calls))
+}
+
+func Test_HTTPClientDefaultPolicies(t *testing.T) {
+	ts, calls := newHTTPServer("", 100)
+	defer ts.Close()
+
+	svc, _ := NewHTTPClient(ts.URL, Config{})
+	_, err := svc.Greet(context.Background(), "hello")
+	_, ok := err.(TransportError)
+	assert.True(t, ok)
+	assert.Equal(t, int32(retry.DefaultPolicy.MaxAttempts), atomic.LoadInt32(calls))
+
+	// Expensive is not idempotent and is not retried by default
+	_, err = svc.Expensive(context.Background(), "c", "u", "p")
+	_, ok = err.(TransportError)
+	assert.True(t, ok)
+	assert.Equal(t, int32(retry.DefaultPolicy.MaxAttempts+1), atomic.LoadInt32(calls))
+}
+
+func Test_HTTPClientRetryable(t *testing.T) {
+	tests := map[string]struct {
+		status    int
+		body      string
+		retryable bool
+	}{
+		"internal_error":      {status: http.StatusInternalServerError, body: `{"err":"internal"}`, retryable: true},
+		"service_unavailable": {status: http.StatusServiceUnavailable, body: "unavailable", retryable: true},
+		"too_many_requests":   {status: http.StatusTooManyRequests, body: "slow down", retryable: true},
+		"not_found":           {status: http.StatusNotFound, body: "404 page not found"},
+		"unreadable":          {status: http.StatusOK, body: "<html>"},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		var calls int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(test.status)
+			w.Write([]byte(test.body))
+		}))
+		policy := retry.Policy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+		svc, _ := NewHTTPClient(ts.URL, Config{Policies: map[string]retry.Policy{"Greet": policy}})
+		_, err := svc.Greet(context.Background(), "hello")
+		_, ok := err.(TransportError)
+		assert.True(t, ok, "got %v", err)
+		expected := int32(1)
+		if test.retryable {
+			expected = 2
+		}
+		assert.Equal(t, expected, atomic.LoadInt32(&calls))
+		ts.Close()
+	}
+}
+
+func Test_HTTPClientUnavailable(t *testing.T) {
+	svc, _ := NewHTTPClient("127.0.0.1:1", Config{Timeout: time.Second})
+	_, err := svc.Greet(context.Background(), "hello")
+	_, ok := err.(TransportError)
+	assert.True(t, ok)
+}
+
+func Test_GRPCClient(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	service.RegisterGreetingServiceServer(s, middleware.AuthorizationMiddlewareGRPC{
+		Policy: middleware.Policy{Methods: map[string]middleware.Rule{"Greet": {Roles: []string{"*"}}}},
+		Next:   &service.GreetingServiceGRPC{Next: service.GreetingService{}},
+	})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	svc := NewGRPCClient(conn, Config{Timeout: time.Second})
+
+	response, err := svc.Greet(context.Background(), "hello")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", response)
+
+	// Expensive is not in the policy
+	_, err = svc.Expensive(context.Background(), "c", "u", "p")
+	assert.True(t, err == service.ErrPermissionDenied, "got %v", err)
+}
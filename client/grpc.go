@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	service "github.com/tkeech1/gowebsvc/svc"
+	"google.golang.org/grpc"
+)
+
+// NewGRPCClient returns a svc.Greeter that calls the gRPC GreetingService over conn.
+func NewGRPCClient(conn *grpc.ClientConn, cfg Config) service.Greeter {
+	return Client{
+		GreetEndpoint: cfg.endpoint("Greet", grpctransport.NewClient(
+			conn,
+			"svc.GreetingService",
+			"GreetGRPC",
+			encodeGRPCGreetRequest,
+			decodeGRPCGreetResponse,
+			service.GRPCGreetResponse{},
+		).Endpoint()),
+		ExpensiveEndpoint: cfg.endpoint("Expensive", grpctransport.NewClient(
+			conn,
+			"svc.GreetingService",
+			"ExpensiveGRPC",
+			encodeGRPCExpensiveRequest,
+			decodeGRPCExpensiveResponse,
+			service.GRPCExpensiveResponse{},
+		).Endpoint()),
+	}
+}
+
+func encodeGRPCGreetRequest(_ context.Context, request interface{}) (interface{}, error) {
+	r := request.(service.GreetRequest)
+	return &service.GRPCGreetRequest{S: r.S}, nil
+}
+
+func decodeGRPCGreetResponse(_ context.Context, response interface{}) (interface{}, error) {
+	r := response.(*service.GRPCGreetResponse)
+	return service.GreetResponse{V: r.Greeting, Err: r.Err}, nil
+}
+
+func encodeGRPCExpensiveRequest(_ context.Context, request interface{}) (interface{}, error) {
+	r := request.(service.ExpensiveRequest)
+	return &service.GRPCExpensiveRequest{ConnectionString: r.C, Username: r.U, Password: r.P}, nil
+}
+
+func decodeGRPCExpensiveResponse(_ context.Context, response interface{}) (interface{}, error) {
+	r := response.(*service.GRPCExpensiveResponse)
+	return service.ExpensiveResponse{V: r.Status, Err: r.Err}, nil
+}
@@ -1,39 +0,0 @@
-package main
-
-import (
-	"log"
-	"os"
-	"time"
-
-	service "github.com/tkeech1/gowebsvc/svc"
-	"golang.org/x/net/context"
-	"google.golang.org/grpc"
-)
-
-const (
-	address     = "localhost:50051"
-	defaultName = "world"
-)
-
-func main() {
-	// Set up a connection to the server.
-	conn, err := grpc.Dial(address, grpc.WithInsecure())
-	if err != nil {
-		log.Fatalf("did not connect: %v", err)
-	}
-	defer conn.Close()
-	c := service.NewGreetingServiceClient(conn)
-
-	// Contact the server and print out its response.
-	name := defaultName
-	if len(os.Args) > 1 {
-		name = os.Args[1]
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-	r, err := c.GreetGRPC(ctx, &service.GRPCGreetRequest{S: name})
-	if err != nil {
-		log.Fatalf("could not greet: %v", err)
-	}
-	log.Printf("Greeting: %s", r.Greeting)
-}
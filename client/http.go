@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+// NewHTTPClient returns a svc.Greeter that calls the v2 API of the HTTP service at
+// baseURL, e.g. "http://localhost:8080" or "https://example.com/greeter" when the
+// service is served under a path prefix.
+func NewHTTPClient(baseURL string, cfg Config) (service.Greeter, error) {
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	// the paths below are resolved relative to the base path, which must be a directory
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+
+	var options []httptransport.ClientOption
+	if cfg.HTTPClient != nil {
+		options = append(options, httptransport.SetClient(cfg.HTTPClient))
+	}
+
+	return Client{
+		GreetEndpoint: cfg.endpoint("Greet", httptransport.NewClient(
+			http.MethodPost,
+			u.ResolveReference(&url.URL{Path: "v2/greeting"}),
+			encodeGreetRequest,
+			decodeGreetResponse,
+			options...,
+		).Endpoint()),
+		ExpensiveEndpoint: cfg.endpoint("Expensive", httptransport.NewClient(
+			http.MethodPost,
+			u.ResolveReference(&url.URL{Path: "v2/expensive"}),
+			httptransport.EncodeJSONRequest,
+			decodeExpensiveResponse,
+			options...,
+		).Endpoint()),
+	}, nil
+}
+
+// StatusError is a response with a 5xx or 429 status: the server could not serve the
+// request right now, so it may be retried. Err is the reason reported by the server,
+// if any.
+type StatusError struct {
+	StatusCode int
+	Err        string
+}
+
+func (e StatusError) Error() string {
+	if e.Err == "" {
+		return fmt.Sprintf("server answered %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("server answered %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Err)
+}
+
+// unavailable reports whether r asks the client to try again later.
+func unavailable(r *http.Response) bool {
+	return r.StatusCode >= 500 || r.StatusCode == http.StatusTooManyRequests
+}
+
+func encodeGreetRequest(ctx context.Context, r *http.Request, request interface{}) error {
+	return httptransport.EncodeJSONRequest(ctx, r, service.GreetRequestV2{Name: request.(service.GreetRequest).S})
+}
+
+// Other responses report service errors in the body, so any body that decodes is a
+// valid response regardless of the status code.
+func decodeGreetResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response service.GreetResponse
+	err := json.NewDecoder(r.Body).Decode(&response)
+	if unavailable(r) {
+		return nil, StatusError{StatusCode: r.StatusCode, Err: response.Err}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unexpected response (%s): %v", r.Status, err)
+	}
+	return response, nil
+}
+
+func decodeExpensiveResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response service.ExpensiveResponse
+	err := json.NewDecoder(r.Body).Decode(&response)
+	if unavailable(r) {
+		return nil, StatusError{StatusCode: r.StatusCode, Err: response.Err}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unexpected response (%s): %v", r.Status, err)
+	}
+	return response, nil
+}
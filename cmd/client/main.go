@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tkeech1/gowebsvc/client"
+	"github.com/tkeech1/gowebsvc/discovery"
+	"github.com/tkeech1/gowebsvc/retry"
+	service "github.com/tkeech1/gowebsvc/svc"
+	"github.com/tkeech1/gowebsvc/tlsconfig"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// exit codes
+const (
+	exitOK          = 0
+	exitFailed      = 1 // at least one request returned a service error
+	exitUsage       = 2
+	exitUnavailable = 3 // the server could not be reached
+)
+
+const usage = `usage: client [flags] <command> [command flags] [args]
+
+commands:
+  greet [name...]          send a greeting for each name
+  expensive                run the expensive operation
+
+Requests can also be read from a file (or "-" for stdin) with -input, one JSON
+request per line, e.g. {"s":"hello"} for greet.
+
+flags:
+`
+
+type options struct {
+	transport string
+	addr      string
+	timeout   time.Duration
+	retries   int
+	hedge     time.Duration
+	lb        string
+	output    string
+	input     string
+	tls       tlsconfig.Options
+	useTLS    bool
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var o options
+	fs := flag.NewFlagSet("client", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&o.transport, "transport", "http", "transport to use: http or grpc")
+	fs.StringVar(&o.addr, "addr", "", "server address (default localhost:8080 for http, localhost:50051 for grpc); "+
+		"grpc also accepts static:///host:port,..., file:///path and dnssrv:///name targets")
+	fs.StringVar(&o.lb, "lb", discovery.RoundRobin, "grpc load balancing policy: round_robin or least_outstanding")
+	fs.DurationVar(&o.timeout, "timeout", time.Second, "timeout for each request")
+	fs.IntVar(&o.retries, "retries", 2, "number of times to retry greet after a transport failure (expensive is never retried)")
+	fs.DurationVar(&o.hedge, "hedge", 0, "send a hedged greet request when no response arrived within this delay (0 disables); requires -retries of at least 1")
+	fs.StringVar(&o.output, "output", "text", "output format: text or json")
+	fs.StringVar(&o.input, "input", "", `read requests from a file, one JSON request per line ("-" for stdin)`)
+	fs.BoolVar(&o.useTLS, "tls", false, "connect using TLS")
+	fs.StringVar(&o.tls.CAFile, "tls-ca", "", "CA file used to verify the server certificate (system roots when empty)")
+	fs.StringVar(&o.tls.CertFile, "tls-cert", "", "client certificate file for mutual TLS")
+	fs.StringVar(&o.tls.KeyFile, "tls-key", "", "client private key file for mutual TLS")
+	fs.StringVar(&o.tls.ServerName, "tls-server-name", "", "override the server name used to verify the certificate")
+	fs.StringVar(&o.tls.MinVersion, "tls-min-version", "1.2", "minimum TLS version (1.0, 1.1, 1.2 or 1.3)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	if o.hedge > 0 && o.retries < 1 {
+		// a hedged request is an extra attempt, which -retries 0 does not allow
+		fmt.Fprintln(stderr, "-hedge requires -retries of at least 1")
+		return exitUsage
+	}
+	if o.output != "text" && o.output != "json" {
+		fmt.Fprintf(stderr, "unknown output format %q\n", o.output)
+		return exitUsage
+	}
+
+	var tlsConfig *tls.Config
+	if o.useTLS {
+		cfg, reloader, err := tlsconfig.Client(o.tls)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to configure TLS: %v\n", err)
+			return exitUsage
+		}
+		if reloader != nil {
+			// requests read from -input may outlive the client certificate
+			stop := make(chan struct{})
+			defer close(stop)
+			go reloader.Watch(10*time.Second, log.New(stderr, "", 0), stop)
+		}
+		tlsConfig = cfg
+	}
+
+	policy := retry.DefaultPolicy
+	policy.MaxAttempts = o.retries + 1
+	policy.HedgeDelay = o.hedge
+	cfg := client.Config{
+		Timeout:  o.timeout,
+		Policies: map[string]retry.Policy{"Greet": policy},
+	}
+	var svc service.Greeter
+	switch o.transport {
+	case "http":
+		addr, scheme := o.addr, "http"
+		if addr == "" {
+			addr = "localhost:8080"
+		}
+		transport := &http.Transport{}
+		if tlsConfig != nil {
+			scheme = "https"
+			transport.TLSClientConfig = tlsConfig
+		}
+		defer transport.CloseIdleConnections()
+		cfg.HTTPClient = &http.Client{Transport: transport}
+		c, err := client.NewHTTPClient(scheme+"://"+addr, cfg)
+		if err != nil {
+			fmt.Fprintf(stderr, "invalid address: %v\n", err)
+			return exitUsage
+		}
+		svc = c
+	case "grpc":
+		addr := o.addr
+		if addr == "" {
+			addr = "localhost:50051"
+		}
+		dialOption := grpc.WithInsecure()
+		if tlsConfig != nil {
+			dialOption = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+		}
+		conn, err := discovery.Dial(addr, o.lb, dialOption)
+		if err != nil {
+			fmt.Fprintf(stderr, "could not connect: %v\n", err)
+			return exitUnavailable
+		}
+		defer conn.Close()
+		svc = client.NewGRPCClient(conn, cfg)
+	default:
+		fmt.Fprintf(stderr, "unknown transport %q\n", o.transport)
+		return exitUsage
+	}
+
+	command, cmdArgs := fs.Arg(0), fs.Args()[1:]
+	var requests []interface{}
+	switch command {
+	case "greet":
+		for _, name := range cmdArgs {
+			requests = append(requests, service.GreetRequest{S: name})
+		}
+	case "expensive":
+		cmd := flag.NewFlagSet("expensive", flag.ContinueOnError)
+		cmd.SetOutput(stderr)
+		var r service.ExpensiveRequest
+		cmd.StringVar(&r.C, "connection-string", "", "connection string")
+		cmd.StringVar(&r.U, "username", "", "username")
+		cmd.StringVar(&r.P, "password", "", "password")
+		if err := cmd.Parse(cmdArgs); err != nil {
+			return exitUsage
+		}
+		if o.input == "" {
+			requests = append(requests, r)
+		}
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n", command)
+		fs.Usage()
+		return exitUsage
+	}
+
+	if o.input != "" {
+		batch, err := readRequests(o.input, command, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to read requests: %v\n", err)
+			return exitUsage
+		}
+		requests = append(requests, batch...)
+	}
+	if len(requests) == 0 {
+		fmt.Fprintln(stderr, "no requests to send")
+		return exitUsage
+	}
+
+	code := exitOK
+	for _, req := range requests {
+		ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
+		result, err := call(ctx, svc, req)
+		cancel()
+		if err != nil {
+			if _, ok := err.(client.TransportError); ok {
+				code = exitUnavailable
+			} else if code == exitOK {
+				code = exitFailed
+			}
+		}
+		printResult(stdout, o.output, req, result, err)
+	}
+	return code
+}
+
+func call(ctx context.Context, svc service.Greeter, req interface{}) (string, error) {
+	switch r := req.(type) {
+	case service.GreetRequest:
+		return svc.Greet(ctx, r.S)
+	case service.ExpensiveRequest:
+		return svc.Expensive(ctx, r.C, r.U, r.P)
+	}
+	return "", fmt.Errorf("unknown request %T", req)
+}
+
+func printResult(w io.Writer, format string, req interface{}, result string, err error) {
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+	if format == "json" {
+		var response interface{}
+		switch req.(type) {
+		case service.GreetRequest:
+			response = service.GreetResponse{V: result, Err: errMsg}
+		case service.ExpensiveRequest:
+			response = service.ExpensiveResponse{V: result, Err: errMsg}
+		}
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(w, "error: %s\n", errMsg)
+		return
+	}
+	fmt.Fprintln(w, result)
+}
+
+// readRequests reads one JSON request per line from path, or from stdin when path is "-".
+func readRequests(path, command string, stdin io.Reader) ([]interface{}, error) {
+	in := stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var requests []interface{}
+	scanner := bufio.NewScanner(in)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var err error
+		switch command {
+		case "greet":
+			var r service.GreetRequest
+			err = json.Unmarshal([]byte(text), &r)
+			requests = append(requests, r)
+		case "expensive":
+			var r service.ExpensiveRequest
+			err = json.Unmarshal([]byte(text), &r)
+			requests = append(requests, r)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+	return requests, scanner.Err()
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+func newTestServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/greeting", func(w http.ResponseWriter, r *http.Request) {
+		var request service.GreetRequestV2
+		json.NewDecoder(r.Body).Decode(&request)
+		response := service.GreetResponse{V: request.Name}
+		if request.Name == "" {
+			response.Err = "empty greeting"
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		json.NewEncoder(w).Encode(response)
+	})
+	mux.HandleFunc("/v2/expensive", func(w http.ResponseWriter, r *http.Request) {
+		var request service.ExpensiveRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		json.NewEncoder(w).Encode(service.ExpensiveResponse{V: request.C + request.U + request.P})
+	})
+	return httptest.NewServer(mux)
+}
+
+func Test_Run(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+	addr := strings.TrimPrefix(ts.URL, "http://")
+
+	tests := map[string]struct {
+		args           []string
+		stdin          string
+		expectedOutput string
+		expectedCode   int
+	}{
+		"greet_text": {
+			args:           []string{"-addr", addr, "greet", "hello", "world"},
+			expectedOutput: "hello\nworld\n",
+			expectedCode:   exitOK,
+		},
+		"greet_json": {
+			args:           []string{"-addr", addr, "-output", "json", "greet", "hello"},
+			expectedOutput: `{"greeting":"hello"}` + "\n",
+			expectedCode:   exitOK,
+		},
+		"greet_batch_stdin": {
+			args:           []string{"-addr", addr, "-input", "-", "greet"},
+			stdin:          `{"s":"one"}` + "\n\n" + `{"s":""}` + "\n",
+			expectedOutput: "one\nerror: empty greeting\n",
+			expectedCode:   exitFailed,
+		},
+		"expensive": {
+			args:           []string{"-addr", addr, "expensive", "-connection-string", "c1", "-username", "u1", "-password", "p1"},
+			expectedOutput: "c1u1p1\n",
+			expectedCode:   exitOK,
+		},
+		"unavailable": {
+			args:           []string{"-addr", "127.0.0.1:1", "greet", "hello"},
+			expectedOutput: "error: ",
+			expectedCode:   exitUnavailable,
+		},
+		"hedge_without_retries": {
+			args:         []string{"-addr", addr, "-retries", "0", "-hedge", "10ms", "greet", "hello"},
+			expectedCode: exitUsage,
+		},
+		"unknown_command": {
+			args:         []string{"-addr", addr, "wave"},
+			expectedCode: exitUsage,
+		},
+		"unknown_transport": {
+			args:         []string{"-transport", "smtp", "greet", "hello"},
+			expectedCode: exitUsage,
+		},
+		"bad_batch": {
+			args:         []string{"-addr", addr, "-input", "-", "greet"},
+			stdin:        "not json\n",
+			expectedCode: exitUsage,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		var stdout, stderr bytes.Buffer
+		code := run(test.args, strings.NewReader(test.stdin), &stdout, &stderr)
+		assert.Equal(t, test.expectedCode, code)
+		assert.True(t, strings.HasPrefix(stdout.String(), test.expectedOutput), stdout.String())
+	}
+}
@@ -0,0 +1,61 @@
+package discovery
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+const leastOutstandingName = "least_outstanding"
+
+func init() {
+	balancer.Register(base.NewBalancerBuilderWithConfig(leastOutstandingName, leastOutstandingBuilder{}, base.Config{HealthCheck: true}))
+}
+
+type leastOutstandingBuilder struct{}
+
+func (leastOutstandingBuilder) Build(readySCs map[resolver.Address]balancer.SubConn) balancer.Picker {
+	if len(readySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	p := &leastOutstandingPicker{outstanding: make(map[balancer.SubConn]int)}
+	for _, sc := range readySCs {
+		p.subConns = append(p.subConns, sc)
+		p.outstanding[sc] = 0
+	}
+	return p
+}
+
+// leastOutstandingPicker sends each call to the backend with the fewest calls in
+// flight, breaking ties in a round-robin fashion.
+type leastOutstandingPicker struct {
+	mu          sync.Mutex
+	subConns    []balancer.SubConn
+	outstanding map[balancer.SubConn]int
+	next        int
+}
+
+func (p *leastOutstandingPicker) Pick(ctx context.Context, opts balancer.PickOptions) (balancer.SubConn, func(balancer.DoneInfo), error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var picked balancer.SubConn
+	for i := range p.subConns {
+		sc := p.subConns[(p.next+i)%len(p.subConns)]
+		if picked == nil || p.outstanding[sc] < p.outstanding[picked] {
+			picked = sc
+		}
+	}
+	p.next = (p.next + 1) % len(p.subConns)
+	p.outstanding[picked]++
+
+	done := func(balancer.DoneInfo) {
+		p.mu.Lock()
+		p.outstanding[picked]--
+		p.mu.Unlock()
+	}
+	return picked, done, nil
+}
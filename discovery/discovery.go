@@ -0,0 +1,42 @@
+// Package discovery lets gRPC clients spread calls over a set of GreetingService
+// backends. Backends are resolved from a target URL:
+//
+//	static:///host1:50051,host2:50051   a fixed list
+//	file:///etc/greeting/backends        one address per line, reloaded when the file changes
+//	dnssrv:///_grpc._tcp.greeting.local  DNS SRV records, re-resolved periodically
+//
+// Calls are balanced with round-robin or least-outstanding-requests, and backends
+// that report NOT_SERVING through the gRPC health service are ejected until they
+// recover.
+package discovery
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer/roundrobin"
+
+	// registers the client side of the gRPC health checking protocol
+	_ "google.golang.org/grpc/health"
+)
+
+// Load balancing policies accepted by Dial.
+const (
+	RoundRobin        = roundrobin.Name
+	LeastOutstanding  = leastOutstandingName
+	defaultPolicy     = RoundRobin
+	serviceConfigJSON = `{"loadBalancingPolicy":%q,"healthCheckConfig":{"serviceName":""}}`
+)
+
+// Dial connects to all backends resolved from target and balances calls between the
+// healthy ones using policy (RoundRobin when empty).
+func Dial(target, policy string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if policy == "" {
+		policy = defaultPolicy
+	}
+	if policy != RoundRobin && policy != LeastOutstanding {
+		return nil, fmt.Errorf("unknown load balancing policy %q", policy)
+	}
+	opts = append(opts, grpc.WithDefaultServiceConfig(fmt.Sprintf(serviceConfigJSON, policy)))
+	return grpc.Dial(target, opts...)
+}
@@ -0,0 +1,209 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	service "github.com/tkeech1/gowebsvc/svc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+)
+
+// backend answers greetings with its own name so tests can tell backends apart.
+type backend struct {
+	name   string
+	addr   string
+	server *grpc.Server
+	health *health.Server
+}
+
+func (b *backend) GreetGRPC(ctx context.Context, in *service.GRPCGreetRequest) (*service.GRPCGreetResponse, error) {
+	return &service.GRPCGreetResponse{Greeting: b.name}, nil
+}
+
+func (b *backend) ExpensiveGRPC(ctx context.Context, in *service.GRPCExpensiveRequest) (*service.GRPCExpensiveResponse, error) {
+	return &service.GRPCExpensiveResponse{Status: b.name}, nil
+}
+
+func startBackends(t *testing.T, n int) []*backend {
+	var backends []*backend
+	for i := 0; i < n; i++ {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := &backend{
+			name:   fmt.Sprintf("backend-%d", i),
+			addr:   lis.Addr().String(),
+			server: grpc.NewServer(),
+			health: health.NewServer(),
+		}
+		service.RegisterGreetingServiceServer(b.server, b)
+		healthpb.RegisterHealthServer(b.server, b.health)
+		go b.server.Serve(lis)
+		backends = append(backends, b)
+	}
+	return backends
+}
+
+func stopBackends(backends []*backend) {
+	for _, b := range backends {
+		b.server.Stop()
+	}
+}
+
+// greet calls the service n times and counts the answers per backend.
+func greet(t *testing.T, conn *grpc.ClientConn, n int) map[string]int {
+	c := service.NewGreetingServiceClient(conn)
+	seen := map[string]int{}
+	for i := 0; i < n; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		r, err := c.GreetGRPC(ctx, &service.GRPCGreetRequest{S: "hello"}, grpc.WaitForReady(true))
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[r.Greeting]++
+	}
+	return seen
+}
+
+func Test_StaticRoundRobin(t *testing.T) {
+	backends := startBackends(t, 3)
+	defer stopBackends(backends)
+
+	target := fmt.Sprintf("static:///%s,%s,%s", backends[0].addr, backends[1].addr, backends[2].addr)
+	conn, err := Dial(target, RoundRobin, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// wait until every backend is connected and take a fresh sample
+	assert.Eventually(t, func() bool { return len(greet(t, conn, 6)) == 3 }, 5*time.Second, 10*time.Millisecond)
+	seen := greet(t, conn, 30)
+	for _, b := range backends {
+		assert.Equal(t, 10, seen[b.name], b.name)
+	}
+
+	// an unhealthy backend is ejected and receives no more calls
+	backends[1].health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	assert.Eventually(t, func() bool { return greet(t, conn, 6)[backends[1].name] == 0 }, 5*time.Second, 10*time.Millisecond)
+	seen = greet(t, conn, 20)
+	assert.Equal(t, 0, seen[backends[1].name])
+	assert.Equal(t, 10, seen[backends[0].name])
+
+	// and comes back once it reports serving again
+	backends[1].health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	assert.Eventually(t, func() bool { return greet(t, conn, 6)[backends[1].name] > 0 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func Test_FileResolver(t *testing.T) {
+	backends := startBackends(t, 2)
+	defer stopBackends(backends)
+
+	f, err := ioutil.TempFile("", "backends")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	ioutil.WriteFile(f.Name(), []byte("# backends\n"+backends[0].addr+"\n"), 0644)
+
+	interval := FilePollInterval
+	FilePollInterval = 10 * time.Millisecond
+	defer func() { FilePollInterval = interval }()
+
+	conn, err := Dial("file://"+f.Name(), LeastOutstanding, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	assert.Equal(t, map[string]int{backends[0].name: 5}, greet(t, conn, 5))
+
+	ioutil.WriteFile(f.Name(), []byte(backends[1].addr+"\n"), 0644)
+	assert.Eventually(t, func() bool {
+		return greet(t, conn, 1)[backends[1].name] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func Test_DNSSRVResolver(t *testing.T) {
+	backends := startBackends(t, 2)
+	defer stopBackends(backends)
+
+	var records []*net.SRV
+	for _, b := range backends {
+		_, port, _ := net.SplitHostPort(b.addr)
+		var p uint16
+		fmt.Sscan(port, &p)
+		records = append(records, &net.SRV{Target: "127.0.0.1.", Port: p})
+	}
+	lookup := lookupSRV
+	lookupSRV = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		assert.Equal(t, "_grpc._tcp.greeting.local", name)
+		return name, records, nil
+	}
+	defer func() { lookupSRV = lookup }()
+
+	conn, err := Dial("dnssrv:///_grpc._tcp.greeting.local", RoundRobin, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	assert.Eventually(t, func() bool { return len(greet(t, conn, 4)) == 2 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func Test_Dial(t *testing.T) {
+	_, err := Dial("static:///127.0.0.1:1", "random", grpc.WithInsecure())
+	assert.NotNil(t, err)
+}
+
+type fakeSubConn struct {
+	balancer.SubConn
+	name string
+}
+
+func Test_LeastOutstandingPicker(t *testing.T) {
+	a, b := &fakeSubConn{name: "a"}, &fakeSubConn{name: "b"}
+	picker := leastOutstandingBuilder{}.Build(map[resolver.Address]balancer.SubConn{
+		{Addr: "a"}: a,
+		{Addr: "b"}: b,
+	})
+
+	// two calls in flight go to different backends
+	first, doneFirst, _ := picker.Pick(context.Background(), balancer.PickOptions{})
+	second, doneSecond, _ := picker.Pick(context.Background(), balancer.PickOptions{})
+	assert.NotEqual(t, first, second)
+
+	// once the first completes, its backend is the least loaded
+	doneFirst(balancer.DoneInfo{})
+	third, doneThird, _ := picker.Pick(context.Background(), balancer.PickOptions{})
+	assert.Equal(t, first, third)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, done, _ := picker.Pick(context.Background(), balancer.PickOptions{})
+			done(balancer.DoneInfo{})
+		}()
+	}
+	wg.Wait()
+	doneSecond(balancer.DoneInfo{})
+	doneThird(balancer.DoneInfo{})
+
+	_, _, err := leastOutstandingBuilder{}.Build(nil).Pick(context.Background(), balancer.PickOptions{})
+	assert.Equal(t, balancer.ErrNoSubConnAvailable, err)
+}
@@ -0,0 +1,180 @@
+package discovery
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/resolver"
+)
+
+// Intervals at which watched files and DNS SRV records are re-read.
+var (
+	FilePollInterval   = 5 * time.Second
+	DNSRefreshInterval = 30 * time.Second
+)
+
+// lookupSRV is replaced in tests.
+var lookupSRV = net.DefaultResolver.LookupSRV
+
+func init() {
+	resolver.Register(builder{scheme: "static"})
+	resolver.Register(builder{scheme: "file"})
+	resolver.Register(builder{scheme: "dnssrv"})
+}
+
+type builder struct {
+	scheme string
+}
+
+func (b builder) Scheme() string {
+	return b.scheme
+}
+
+func (b builder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOption) (resolver.Resolver, error) {
+	r := &pollingResolver{
+		cc:         cc,
+		resolveNow: make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	switch b.scheme {
+	case "static":
+		addrs := splitAddresses(target.Endpoint)
+		r.lookup = func() ([]string, error) { return addrs, nil }
+	case "file":
+		// file:///etc/backends has an empty authority, file://./backends a relative one
+		path := target.Authority + "/" + target.Endpoint
+		r.lookup = func() ([]string, error) { return readAddresses(path) }
+		r.interval = FilePollInterval
+	case "dnssrv":
+		name := target.Endpoint
+		r.lookup = func() ([]string, error) { return resolveSRV(name) }
+		r.interval = DNSRefreshInterval
+	}
+
+	if err := r.update(); err != nil {
+		return nil, err
+	}
+	r.wg.Add(1)
+	go r.watch()
+	return r, nil
+}
+
+// pollingResolver pushes the addresses returned by lookup to gRPC, re-running it every
+// interval (if set) and whenever gRPC asks for it.
+type pollingResolver struct {
+	cc         resolver.ClientConn
+	lookup     func() ([]string, error)
+	interval   time.Duration
+	resolveNow chan struct{}
+	done       chan struct{}
+	wg         sync.WaitGroup
+
+	last []string
+}
+
+func (r *pollingResolver) update() error {
+	addrs, err := r.lookup()
+	if err != nil {
+		return err
+	}
+	sort.Strings(addrs)
+	if r.last != nil && reflect.DeepEqual(addrs, r.last) {
+		return nil
+	}
+	r.last = addrs
+
+	state := resolver.State{}
+	for _, a := range addrs {
+		state.Addresses = append(state.Addresses, resolver.Address{Addr: a})
+	}
+	r.cc.UpdateState(state)
+	return nil
+}
+
+func (r *pollingResolver) watch() {
+	defer r.wg.Done()
+	var tick <-chan time.Time
+	if r.interval > 0 {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-tick:
+		case <-r.resolveNow:
+		case <-r.done:
+			return
+		}
+		// keep the last known backends when the lookup fails
+		if err := r.update(); err != nil {
+			grpclog.Warningf("discovery: failed to resolve backends: %v", err)
+		}
+	}
+}
+
+func (r *pollingResolver) ResolveNow(resolver.ResolveNowOption) {
+	select {
+	case r.resolveNow <- struct{}{}:
+	default:
+	}
+}
+
+func (r *pollingResolver) Close() {
+	close(r.done)
+	r.wg.Wait()
+}
+
+func splitAddresses(s string) []string {
+	var addrs []string
+	for _, a := range strings.Split(s, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs
+}
+
+// readAddresses reads one address per line, ignoring blank lines and # comments.
+func readAddresses(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var addrs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		addrs = append(addrs, line)
+	}
+	return addrs, scanner.Err()
+}
+
+func resolveSRV(name string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, records, err := lookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []string
+	for _, srv := range records {
+		host := strings.TrimSuffix(srv.Target, ".")
+		addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(int(srv.Port))))
+	}
+	return addrs, nil
+}
@@ -0,0 +1,30 @@
+// Package events publishes service events to a message bus.
+package events
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrClosed       = errors.New("events: publisher is closed")
+	ErrInvalidTopic = errors.New("events: invalid topic")
+)
+
+// Publisher sends messages to the topics of a message bus. Publish returns once the
+// bus accepted the message.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, data []byte) error
+	Close() error
+}
+
+// Message is a message published to a topic.
+type Message struct {
+	Topic string
+	Data  []byte
+}
+
+func validTopic(topic string) bool {
+	return topic != "" && !strings.ContainsAny(topic, " \t\r\n")
+}
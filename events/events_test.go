@@ -0,0 +1,286 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	kafka "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Memory(t *testing.T) {
+	ctx := context.Background()
+	b := NewMemory()
+
+	greetings, stop := b.Subscribe("greetings", 2)
+	other, _ := b.Subscribe("other", 2)
+
+	data := []byte("hello")
+	assert.Nil(t, b.Publish(ctx, "greetings", data))
+	data[0] = 'j' // the bus keeps its own copy
+	assert.Equal(t, Message{Topic: "greetings", Data: []byte("hello")}, <-greetings)
+	assert.Len(t, other, 0)
+
+	assert.Equal(t, ErrInvalidTopic, b.Publish(ctx, "two words", nil))
+
+	// a full subscriber misses the messages instead of holding up publishing
+	assert.Nil(t, b.Publish(ctx, "greetings", []byte("1")))
+	assert.Nil(t, b.Publish(ctx, "greetings", []byte("2")))
+	assert.Nil(t, b.Publish(ctx, "greetings", []byte("3")))
+
+	stop()
+	stop()
+	assert.Equal(t, []byte("1"), (<-greetings).Data)
+	assert.Equal(t, []byte("2"), (<-greetings).Data)
+	_, ok := <-greetings
+	assert.False(t, ok)
+	assert.Nil(t, b.Publish(ctx, "greetings", nil))
+
+	assert.Nil(t, b.Close())
+	_, ok = <-other
+	assert.False(t, ok)
+	assert.Equal(t, ErrClosed, b.Publish(ctx, "other", nil))
+}
+
+// fakeNATS is a minimal NATS server recording the messages published to it. Like a
+// real server, it answers PUB on the subject "forbidden" with a permissions error and
+// keeps the connection; PUB on "disconnect" closes the connection.
+type fakeNATS struct {
+	lis      net.Listener
+	mu       sync.Mutex
+	messages []Message
+	connects []string
+}
+
+func newFakeNATS(t *testing.T) *fakeNATS {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeNATS{lis: lis}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeNATS) serve(conn net.Conn) {
+	defer conn.Close()
+	fmt.Fprint(conn, "INFO {\"server_id\":\"fake\",\"max_payload\":1048576,\"auth_required\":true}\r\n")
+	rd := bufio.NewReader(conn)
+	connected := false
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return
+		}
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+		switch args[0] {
+		case "CONNECT":
+			f.mu.Lock()
+			f.connects = append(f.connects, strings.TrimSpace(strings.TrimPrefix(line, "CONNECT")))
+			f.mu.Unlock()
+		case "PING":
+			if connected {
+				// a server PING before answering checks the client answers it
+				fmt.Fprint(conn, "PING\r\n")
+				if l, err := rd.ReadString('\n'); err != nil || l != "PONG\r\n" {
+					return
+				}
+			}
+			connected = true
+			fmt.Fprint(conn, "PONG\r\n")
+		case "PUB":
+			n, _ := strconv.Atoi(args[2])
+			payload := make([]byte, n+2)
+			if _, err := io.ReadFull(rd, payload); err != nil {
+				return
+			}
+			switch args[1] {
+			case "forbidden":
+				fmt.Fprint(conn, "-ERR 'Permissions Violation for Publish to \"forbidden\"'\r\n")
+				continue
+			case "disconnect":
+				return
+			}
+			f.mu.Lock()
+			f.messages = append(f.messages, Message{Topic: args[1], Data: payload[:n]})
+			f.mu.Unlock()
+		default:
+			fmt.Fprint(conn, "-ERR 'Unknown Protocol Operation'\r\n")
+			return
+		}
+	}
+}
+
+func Test_NATS(t *testing.T) {
+	f := newFakeNATS(t)
+	defer f.lis.Close()
+	ctx := context.Background()
+	n := NewNATS(f.lis.Addr().String(), time.Second, nats.UserInfo("svc", "secret"))
+	defer n.Close()
+
+	assert.Nil(t, n.Publish(ctx, "greetings", []byte("hello\r\nworld")))
+	assert.Nil(t, n.Publish(ctx, "greetings.v2", nil))
+	err := n.Publish(ctx, "forbidden", []byte("x"))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, `nats: Permissions Violation for Publish to "forbidden"`, err.Error())
+	}
+	assert.Nil(t, n.Publish(ctx, "greetings", []byte("same connection")))
+	assert.NotNil(t, n.Publish(ctx, "disconnect", nil))
+	// the connection is re-established after it was lost
+	assert.Nil(t, n.Publish(ctx, "greetings", []byte("again")))
+	assert.Equal(t, ErrInvalidTopic, n.Publish(ctx, "", nil))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	assert.Equal(t, []Message{
+		{Topic: "greetings", Data: []byte("hello\r\nworld")},
+		{Topic: "greetings.v2", Data: []byte{}},
+		{Topic: "greetings", Data: []byte("same connection")},
+		{Topic: "greetings", Data: []byte("again")},
+	}, f.messages)
+	if assert.Len(t, f.connects, 2) {
+		assert.Contains(t, f.connects[0], `"user":"svc","pass":"secret"`)
+	}
+
+	// a server that cannot be reached fails the publish within the timeout
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis.Close()
+	unavailable := NewNATS(lis.Addr().String(), 100*time.Millisecond)
+	defer unavailable.Close()
+	start := time.Now()
+	assert.NotNil(t, unavailable.Publish(ctx, "greetings", nil))
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+// fakeKafkaWriter records the messages written to it. It fails writes to the topic
+// "unknown" with UNKNOWN_TOPIC_OR_PARTITION.
+type fakeKafkaWriter struct {
+	messages []kafka.Message
+	deadline bool
+	closed   bool
+}
+
+func (w *fakeKafkaWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	_, w.deadline = ctx.Deadline()
+	for _, m := range msgs {
+		if m.Topic == "unknown" {
+			return kafka.UnknownTopicOrPartition
+		}
+	}
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *fakeKafkaWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func Test_Kafka(t *testing.T) {
+	ctx := context.Background()
+	w := &fakeKafkaWriter{}
+	k := &Kafka{timeout: time.Second, writer: w}
+
+	assert.Nil(t, k.Publish(ctx, "greetings", []byte(`{"method":"Greet"}`)))
+	assert.True(t, w.deadline)
+	assert.Equal(t, kafka.UnknownTopicOrPartition, k.Publish(ctx, "unknown", []byte("x")))
+	assert.Equal(t, ErrInvalidTopic, k.Publish(ctx, "two words", nil))
+	assert.Equal(t, []kafka.Message{
+		{Topic: "greetings", Value: []byte(`{"method":"Greet"}`)},
+	}, w.messages)
+	assert.Nil(t, k.Close())
+	assert.True(t, w.closed)
+
+	// a broker that cannot be reached fails the publish within the timeout
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis.Close()
+	unavailable := NewKafka(lis.Addr().String(), 100*time.Millisecond)
+	defer unavailable.Close()
+	start := time.Now()
+	assert.NotNil(t, unavailable.Publish(ctx, "greetings", nil))
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+// flakyPublisher fails until it is fixed.
+type flakyPublisher struct {
+	mu        sync.Mutex
+	broken    bool
+	published []string
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, topic string, data []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.broken {
+		return errors.New("bus unavailable")
+	}
+	p.published = append(p.published, string(data))
+	return nil
+}
+
+func (p *flakyPublisher) Close() error { return nil }
+
+func (p *flakyPublisher) set(broken bool) {
+	p.mu.Lock()
+	p.broken = broken
+	p.mu.Unlock()
+}
+
+func Test_Outbox(t *testing.T) {
+	p := &flakyPublisher{broken: true}
+	o := NewOutbox(p, 2, time.Millisecond, log.New(ioutil.Discard, "", 0))
+
+	// while the bus is down messages wait in order, and the buffer bounds them
+	assert.Nil(t, o.Add("greetings", []byte("1")))
+	deadline := time.Now().Add(time.Second)
+	for o.Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Nil(t, o.Add("greetings", []byte("2")))
+	assert.Nil(t, o.Add("greetings", []byte("3")))
+	assert.Equal(t, ErrOutboxFull, o.Add("greetings", []byte("4")))
+
+	p.set(false)
+	assert.Nil(t, o.Close(context.Background()))
+	assert.Equal(t, []string{"1", "2", "3"}, p.published)
+	assert.Equal(t, ErrClosed, o.Add("greetings", []byte("5")))
+
+	// closing gives up on a bus that stays down
+	p.set(true)
+	o = NewOutbox(p, 2, time.Millisecond, log.New(ioutil.Discard, "", 0))
+	assert.Nil(t, o.Add("greetings", []byte("6")))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, o.Close(ctx))
+	assert.Len(t, p.published, 3)
+	assert.NotPanics(t, func() { o.Close(ctx) })
+}
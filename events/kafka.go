@@ -0,0 +1,53 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// kafkaWriter is the part of kafka.Writer used by Kafka.
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Kafka publishes to a Kafka cluster through a kafka-go Writer, which looks up the
+// partition leaders from the brokers' metadata and writes record batches. Each message
+// is written on its own and acknowledged by the partition leader; the Writer does not
+// retry, as the outbox does.
+type Kafka struct {
+	timeout time.Duration
+	writer  kafkaWriter
+}
+
+// NewKafka returns a publisher for the cluster the broker at addr belongs to. Each
+// publish is bounded by timeout unless the context has an earlier deadline.
+func NewKafka(addr string, timeout time.Duration) *Kafka {
+	return &Kafka{
+		timeout: timeout,
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(addr),
+			Balancer:     &kafka.LeastBytes{},
+			MaxAttempts:  1,
+			BatchSize:    1,
+			RequiredAcks: kafka.RequireOne,
+			ReadTimeout:  timeout,
+			WriteTimeout: timeout,
+		},
+	}
+}
+
+func (k *Kafka) Publish(ctx context.Context, topic string, data []byte) error {
+	if !validTopic(topic) {
+		return ErrInvalidTopic
+	}
+	ctx, cancel := context.WithTimeout(ctx, k.timeout)
+	defer cancel()
+	return k.writer.WriteMessages(ctx, kafka.Message{Topic: topic, Value: data})
+}
+
+func (k *Kafka) Close() error {
+	return k.writer.Close()
+}
@@ -0,0 +1,85 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory is an in-process bus. Every subscriber of a topic receives each message
+// published to it. Publish never waits for a subscriber: a message is dropped for
+// those whose buffer is full.
+type Memory struct {
+	mu     sync.Mutex
+	subs   map[string][]chan Message
+	closed bool
+}
+
+func NewMemory() *Memory {
+	return &Memory{subs: map[string][]chan Message{}}
+}
+
+func (b *Memory) Publish(ctx context.Context, topic string, data []byte) error {
+	if !validTopic(topic) {
+		return ErrInvalidTopic
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	m := Message{Topic: topic, Data: append([]byte(nil), data...)}
+	for _, ch := range b.subs[topic] {
+		select {
+		case ch <- m:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel receiving the messages published to topic from now on,
+// buffering up to buffer of them, and a function ending the subscription.
+func (b *Memory) Subscribe(topic string, buffer int) (<-chan Message, func()) {
+	ch := make(chan Message, buffer)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subs[topic] = append(b.subs[topic], ch)
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() { b.unsubscribe(topic, ch) })
+	}
+}
+
+func (b *Memory) unsubscribe(topic string, ch chan Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	subs := b.subs[topic]
+	for i, c := range subs {
+		if c == ch {
+			b.subs[topic] = append(subs[:i:i], subs[i+1:]...)
+			close(ch)
+			return
+		}
+	}
+}
+
+// Close ends all subscriptions.
+func (b *Memory) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	for _, subs := range b.subs {
+		for _, ch := range subs {
+			close(ch)
+		}
+	}
+	b.subs = nil
+	return nil
+}
@@ -0,0 +1,114 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+var ErrOutboxFull = errors.New("events: outbox is full")
+
+// Outbox buffers messages and publishes them in order in the background, so callers
+// are neither held up nor failed by an unavailable bus. A failed publish is retried
+// every retryInterval until it succeeds; while it fails, new messages wait in the
+// buffer and are rejected with ErrOutboxFull once it holds size of them.
+type Outbox struct {
+	publisher     Publisher
+	retryInterval time.Duration
+	logger        *log.Logger
+
+	queue    chan Message
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewOutbox(p Publisher, size int, retryInterval time.Duration, logger *log.Logger) *Outbox {
+	o := &Outbox{
+		publisher:     p,
+		retryInterval: retryInterval,
+		logger:        logger,
+		queue:         make(chan Message, size),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go o.run()
+	return o
+}
+
+// Add queues data for topic without waiting for it to be published.
+func (o *Outbox) Add(topic string, data []byte) error {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if o.closed {
+		return ErrClosed
+	}
+	select {
+	case o.queue <- Message{Topic: topic, Data: data}:
+		return nil
+	default:
+		return ErrOutboxFull
+	}
+}
+
+// Len returns the number of messages waiting to be published.
+func (o *Outbox) Len() int {
+	return len(o.queue)
+}
+
+// Close stops accepting messages and waits until the queued ones are published or ctx
+// is done, in which case the remaining messages are dropped. It does not close the
+// publisher.
+func (o *Outbox) Close(ctx context.Context) error {
+	o.mu.Lock()
+	if !o.closed {
+		o.closed = true
+		close(o.queue)
+	}
+	o.mu.Unlock()
+
+	select {
+	case <-o.done:
+		return nil
+	case <-ctx.Done():
+		o.stopOnce.Do(func() { close(o.stop) })
+		<-o.done
+		if n := len(o.queue); n > 0 {
+			o.logger.Printf("dropped %d unpublished events", n)
+		}
+		return ctx.Err()
+	}
+}
+
+func (o *Outbox) run() {
+	defer close(o.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-o.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for m := range o.queue {
+		for {
+			err := o.publisher.Publish(ctx, m.Topic, m.Data)
+			if err == nil {
+				break
+			}
+			o.logger.Printf("failed to publish event to %s, retrying: %v", m.Topic, err)
+			select {
+			case <-time.After(o.retryInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
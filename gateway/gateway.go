@@ -0,0 +1,78 @@
+// Package gateway serves the REST API declared by the google.api.http annotations in
+// svc/greeting.proto and svc/v2/greeting.proto. Requests are translated into calls on
+// the GreetingServiceServer of their version, so the HTTP routes, their JSON bodies and
+// their status codes follow the proto contract rather than a hand-written handler.
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/tkeech1/gowebsvc/middleware"
+	service "github.com/tkeech1/gowebsvc/svc"
+	svcv2 "github.com/tkeech1/gowebsvc/svc/v2"
+)
+
+// callerHeaders are forwarded to the server as gRPC metadata so the caller identity set
+// by the gateway in front of us reaches the authorization middleware, and the
+// Accept-Language of the client reaches locale negotiation.
+var callerHeaders = map[string]bool{
+	"x-caller-id":     true,
+	"x-caller-roles":  true,
+	"x-caller-scopes": true,
+	"accept-language": true,
+}
+
+// NewHandler returns a handler serving the annotated routes of both GreetingService
+// versions by calling v1 and v2 in-process. The caller from the request headers is
+// stored in the context, as on the hand-written routes, for services authorizing with
+// AuthorizationMiddleware. Responses of the v1 routes announce deprecation, with the
+// matching v2 route as successor.
+func NewHandler(ctx context.Context, v1 service.GreetingServiceServer, v2 svcv2.GreetingServiceServer, deprecation middleware.Deprecation) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{OrigName: true}),
+		runtime.WithIncomingHeaderMatcher(headerMatcher),
+		runtime.WithForwardResponseOption(contentLanguage),
+	)
+	if err := service.RegisterGreetingServiceHandlerServer(ctx, mux, v1); err != nil {
+		return nil, err
+	}
+	if err := svcv2.RegisterGreetingServiceHandlerServer(ctx, mux, v2); err != nil {
+		return nil, err
+	}
+	return middleware.CallerHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v2/") {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		d := deprecation
+		d.Successor = "/v2" + strings.TrimPrefix(r.URL.Path, "/v1")
+		d.Handler(mux).ServeHTTP(w, r)
+	})), nil
+}
+
+func headerMatcher(key string) (string, bool) {
+	if k := strings.ToLower(key); callerHeaders[k] {
+		return k, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// contentLanguage announces the locale of a greeting in the Content-Language header, as
+// the hand-written routes do.
+func contentLanguage(_ context.Context, w http.ResponseWriter, m proto.Message) error {
+	var locale string
+	switch m := m.(type) {
+	case *service.GRPCGreetResponse:
+		locale = m.GetLocale()
+	case *svcv2.GreetResponse:
+		locale = m.GetLocale()
+	}
+	if locale != "" {
+		w.Header().Set("Content-Language", locale)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/tkeech1/gowebsvc/middleware"
+	service "github.com/tkeech1/gowebsvc/svc"
+	svcv2 "github.com/tkeech1/gowebsvc/svc/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newServer returns the service behind both the gateway and a gRPC connection, so each
+// test case can compare the bridged HTTP response with the direct gRPC one.
+func newServer(t *testing.T) (http.Handler, service.GreetingServiceClient, svcv2.GreetingServiceClient, func()) {
+	policy := middleware.Policy{Methods: map[string]middleware.Rule{
+		"Greet":     {Roles: []string{"*"}},
+		"Expensive": {Roles: []string{"admin"}},
+	}}
+	srv := middleware.AuthorizationMiddlewareGRPC{
+		Policy: policy,
+		Logger: log.New(os.Stdout, "LOG: ", log.Ldate|log.Ltime|log.Lshortfile),
+		Next:   &service.GreetingServiceGRPC{Next: service.GreetingService{}},
+	}
+	srv2 := svcv2.Server{Next: middleware.AuthorizationMiddleware{
+		Policy: policy,
+		Logger: log.New(os.Stdout, "LOG: ", log.Ldate|log.Ltime|log.Lshortfile),
+		Next:   service.GreetingService{},
+	}}
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(grpc.UnaryInterceptor(middleware.CallerUnaryServerInterceptor))
+	service.RegisterGreetingServiceServer(s, srv)
+	svcv2.RegisterGreetingServiceServer(s, srv2)
+	go s.Serve(lis)
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler, err := NewHandler(context.Background(), srv, srv2, middleware.Deprecation{Date: time.Unix(1000, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return handler, service.NewGreetingServiceClient(conn), svcv2.NewGreetingServiceClient(conn), func() {
+		conn.Close()
+		s.Stop()
+	}
+}
+
+func Test_Gateway(t *testing.T) {
+	handler, client, client2, stop := newServer(t)
+	defer stop()
+
+	tests := map[string]struct {
+		method   string
+		url      string
+		body     string
+		roles    string
+		response proto.Message
+		call     func(ctx context.Context) (proto.Message, error)
+	}{
+		"greet_post": {
+			method:   "POST",
+			url:      "/greeting",
+			body:     `{"s":"hello"}`,
+			response: &service.GRPCGreetResponse{},
+			call: func(ctx context.Context) (proto.Message, error) {
+				return client.GreetGRPC(ctx, &service.GRPCGreetRequest{S: "hello"})
+			},
+		},
+		"greet_get": {
+			method:   "GET",
+			url:      "/greeting?s=hello",
+			response: &service.GRPCGreetResponse{},
+			call: func(ctx context.Context) (proto.Message, error) {
+				return client.GreetGRPC(ctx, &service.GRPCGreetRequest{S: "hello"})
+			},
+		},
+		"greet_v1": {
+			method:   "POST",
+			url:      "/v1/greeting",
+			body:     `{"s":"hello"}`,
+			response: &service.GRPCGreetResponse{},
+			call: func(ctx context.Context) (proto.Message, error) {
+				return client.GreetGRPC(ctx, &service.GRPCGreetRequest{S: "hello"})
+			},
+		},
+		"greet_v2": {
+			method:   "POST",
+			url:      "/v2/greeting",
+			body:     `{"name":"hello","locale":"en","formality":"FORMAL"}`,
+			response: &svcv2.GreetResponse{},
+			call: func(ctx context.Context) (proto.Message, error) {
+				return client2.Greet(ctx, &svcv2.GreetRequest{Name: "hello", Locale: "en", Formality: svcv2.Formality_FORMAL})
+			},
+		},
+		"greet_v2_empty": {
+			method:   "POST",
+			url:      "/v2/greeting",
+			body:     `{"name":""}`,
+			response: &svcv2.GreetResponse{},
+			call: func(ctx context.Context) (proto.Message, error) {
+				return client2.Greet(ctx, &svcv2.GreetRequest{})
+			},
+		},
+		"expensive_v2_denied": {
+			method:   "POST",
+			url:      "/v2/expensive",
+			body:     `{"connection_string":"c","username":"u","password":"p"}`,
+			roles:    "guest",
+			response: &svcv2.ExpensiveResponse{},
+			call: func(ctx context.Context) (proto.Message, error) {
+				return client2.Expensive(ctx, &svcv2.ExpensiveRequest{ConnectionString: "c", Username: "u", Password: "p"})
+			},
+		},
+		"expensive": {
+			method:   "POST",
+			url:      "/expensive",
+			body:     `{"connection_string":"c","username":"u","password":"p"}`,
+			roles:    "admin",
+			response: &service.GRPCExpensiveResponse{},
+			call: func(ctx context.Context) (proto.Message, error) {
+				return client.ExpensiveGRPC(ctx, &service.GRPCExpensiveRequest{ConnectionString: "c", Username: "u", Password: "p"})
+			},
+		},
+		"expensive_missing_password": {
+			method:   "POST",
+			url:      "/expensive",
+			body:     `{"connection_string":"c","username":"u"}`,
+			roles:    "admin",
+			response: &service.GRPCExpensiveResponse{},
+			call: func(ctx context.Context) (proto.Message, error) {
+				return client.ExpensiveGRPC(ctx, &service.GRPCExpensiveRequest{ConnectionString: "c", Username: "u"})
+			},
+		},
+		"expensive_denied": {
+			method:   "POST",
+			url:      "/expensive",
+			body:     `{"connection_string":"c","username":"u","password":"p"}`,
+			roles:    "guest",
+			response: &service.GRPCExpensiveResponse{},
+			call: func(ctx context.Context) (proto.Message, error) {
+				return client.ExpensiveGRPC(ctx, &service.GRPCExpensiveRequest{ConnectionString: "c", Username: "u", Password: "p"})
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-caller-roles", test.roles)
+		expected, grpcErr := test.call(ctx)
+
+		req, err := http.NewRequest(test.method, test.url, bytes.NewBufferString(test.body))
+		assert.Nil(t, err)
+		req.Header.Set("X-Caller-Roles", test.roles)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if strings.HasPrefix(test.url, "/v2/") {
+			assert.Empty(t, w.Header().Get("Deprecation"))
+		} else {
+			assert.Equal(t, "@1000", w.Header().Get("Deprecation"))
+		}
+
+		if grpcErr != nil {
+			st := status.Convert(grpcErr)
+			assert.Equal(t, runtime.HTTPStatusFromCode(st.Code()), w.Code)
+			assert.Contains(t, w.Body.String(), st.Message())
+			continue
+		}
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, jsonpb.Unmarshal(w.Body, test.response))
+		assert.True(t, proto.Equal(expected, test.response), "bridged %v, direct %v", test.response, expected)
+	}
+}
+
+func Test_GatewayBadRequest(t *testing.T) {
+	handler, _, _, stop := newServer(t)
+	defer stop()
+
+	req, err := http.NewRequest("POST", "/greeting", bytes.NewBufferString(`{"s":`))
+	assert.Nil(t, err)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req, err = http.NewRequest("GET", "/unknown", nil)
+	assert.Nil(t, err)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -1,17 +1,25 @@
 module github.com/tkeech1/gowebsvc
 
-go 1.12
+go 1.16
 
 require (
 	github.com/VividCortex/gohistogram v1.0.0 // indirect
 	github.com/go-kit/kit v0.9.0
-	github.com/golang/protobuf v1.3.2
+	github.com/go-redis/redis/v8 v8.11.4
+	github.com/golang/protobuf v1.5.2
+	github.com/gorilla/websocket v1.4.1
+	github.com/graph-gophers/graphql-go v1.5.0
+	github.com/grpc-ecosystem/grpc-gateway v1.11.3
+	github.com/nats-io/nats.go v1.16.0
 	github.com/prometheus/client_golang v1.1.0
 	github.com/prometheus/common v0.6.0
-	github.com/stretchr/testify v1.4.0
-	golang.org/x/net v0.0.0-20190909003024-a7b16738d86b
-	golang.org/x/sys v0.0.0-20190911201528-7ad0cfa0b7b5 // indirect
-	golang.org/x/text v0.3.2 // indirect
-	google.golang.org/genproto v0.0.0-20190911173649-1774047e7e51 // indirect
+	github.com/rabbitmq/amqp091-go v1.8.1
+	github.com/segmentio/kafka-go v0.4.25
+	github.com/soheilhy/cmux v0.1.4
+	github.com/stretchr/testify v1.8.0
+	go.etcd.io/bbolt v1.3.5
+	golang.org/x/net v0.0.0-20210428140749-89ef3d95e781
+	google.golang.org/genproto v0.0.0-20190911173649-1774047e7e51
 	google.golang.org/grpc v1.23.1
+	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
 )
@@ -1,17 +1,23 @@
 package main
 
 import (
+	"crypto/tls"
+	"flag"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"net/http"
 
+	"github.com/go-kit/kit/endpoint"
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	httptransport "github.com/go-kit/kit/transport/http"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	middleware "github.com/tkeech1/gowebsvc/middleware"
 	service "github.com/tkeech1/gowebsvc/svc"
+	"github.com/tkeech1/gowebsvc/tlsconfig"
 )
 
 func getGreetingHandler(svc service.Greeter) *httptransport.Server {
@@ -19,22 +25,87 @@ func getGreetingHandler(svc service.Greeter) *httptransport.Server {
 		makeGreetingEndpoint(svc),
 		decodeGreetRequest,
 		encodeResponse,
+		httptransport.ServerBefore(caller, acceptLanguage),
 	)
 }
 
-func getExpensiveHandler(svc service.Greeter) *httptransport.Server {
+// getExpensiveHandler serves the v1 expensive operation. The endpoint is passed in
+// because the operation runs once per endpoint, which the other transports share.
+func getExpensiveHandler(expensive endpoint.Endpoint) *httptransport.Server {
 	return httptransport.NewServer(
-		makeExpensiveEndpoint(svc),
+		expensive,
 		decodeExpensiveRequest,
 		encodeResponse,
+		httptransport.ServerBefore(caller),
 	)
 }
 
+// v1Deprecated is when the v1 API was deprecated in favour of v2.
+var v1Deprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// routes returns the v1 handlers under /v1 and at their unversioned paths, and the v2
+// handlers under /v2. The unversioned paths and the v1 paths announce deprecation.
+// /rpc serves the v2 operations over JSON-RPC 2.0.
+func routes(svc service.Greeter, deprecation middleware.Deprecation) map[string]http.Handler {
+	expensive := makeExpensiveEndpoint(svc)
+
+	v1 := map[string]http.Handler{
+		"/greeting":  getGreetingHandler(svc),
+		"/expensive": getExpensiveHandler(expensive),
+	}
+	v2 := map[string]http.Handler{
+		"/greeting": httptransport.NewServer(makeGreetingV2Endpoint(svc), decodeGreetV2Request, encodeV2Response,
+			httptransport.ServerBefore(caller, acceptLanguage), httptransport.ServerErrorEncoder(encodeV2Error)),
+		"/expensive": httptransport.NewServer(makeExpensiveV2Endpoint(expensive), decodeExpensiveRequest, encodeV2Response,
+			httptransport.ServerBefore(caller), httptransport.ServerErrorEncoder(encodeV2Error)),
+	}
+
+	handlers := map[string]http.Handler{}
+	for path, h := range v1 {
+		d := deprecation
+		d.Successor = "/v2" + path
+		handlers[path] = d.Handler(h)
+		handlers["/v1"+path] = d.Handler(h)
+	}
+	for path, h := range v2 {
+		handlers["/v2"+path] = h
+	}
+	handlers["/rpc"] = newRPCServer(rpcCodecs(svc, expensive), caller, acceptLanguage)
+	return handlers
+}
+
 // main
 func main() {
+	policyFile := flag.String("policy", "", "authorization policy file (JSON); authorization is disabled when empty")
+	tlsCert := flag.String("tls-cert", "", "TLS certificate file; TLS is disabled when empty")
+	tlsKey := flag.String("tls-key", "", "TLS private key file")
+	tlsClientCA := flag.String("tls-client-ca", "", "CA file used to verify client certificates (enables mutual TLS)")
+	tlsMinVersion := flag.String("tls-min-version", "1.2", "minimum TLS version (1.0, 1.1, 1.2 or 1.3)")
+	tlsCiphers := flag.String("tls-ciphers", "", "comma-separated list of allowed cipher suites")
+	flag.Parse()
+
 	//logger := kitlog.NewLogfmtLogger(os.Stdout)
 	logger := log.New(os.Stdout, "LOG: ", log.Ldate|log.Ltime|log.Lshortfile)
 
+	var tlsConfig *tls.Config
+	if *tlsCert != "" {
+		options := tlsconfig.Options{
+			CertFile:   *tlsCert,
+			KeyFile:    *tlsKey,
+			CAFile:     *tlsClientCA,
+			MinVersion: *tlsMinVersion,
+		}
+		if *tlsCiphers != "" {
+			options.CipherSuites = strings.Split(*tlsCiphers, ",")
+		}
+		cfg, reloader, err := tlsconfig.Server(options)
+		if err != nil {
+			log.Fatalf("failed to configure TLS: %v", err)
+		}
+		go reloader.Watch(10*time.Second, logger, nil)
+		tlsConfig = cfg
+	}
+
 	fieldKeys := []string{"method", "error"}
 	requestCount := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
 		Namespace: "my_group",
@@ -51,14 +122,29 @@ func main() {
 
 	var svc service.Greeter
 	svc = service.GreetingService{}
+	svc = middleware.NewCoalescingMiddleware(kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: "my_group",
+		Subsystem: "greeting_service",
+		Name:      "coalesced_requests",
+		Help:      "Number of requests that joined an identical in-flight request.",
+	}, []string{"method"}), svc)
+	if *policyFile != "" {
+		policy, err := middleware.LoadPolicy(*policyFile)
+		if err != nil {
+			log.Fatalf("failed to load policy: %v", err)
+		}
+		svc = middleware.AuthorizationMiddleware{Policy: policy, Logger: logger, Next: svc}
+	}
 	svc = middleware.LoggingMiddleware{logger, svc}
 	svc = middleware.InstrumentingMiddleware{requestCount, requestLatency, svc}
 
-	greetingHandler := getGreetingHandler(svc)
-	expensiveHandler := getExpensiveHandler(svc)
-
-	http.Handle("/greeting", greetingHandler)
-	http.Handle("/expensive", expensiveHandler)
+	for path, handler := range routes(svc, middleware.Deprecation{Date: v1Deprecated}) {
+		http.Handle(path, handler)
+	}
 	http.Handle("/metrics", promhttp.Handler())
-	log.Fatal(http.ListenAndServe("127.0.0.1:8080", nil))
+	server := &http.Server{Addr: "127.0.0.1:8080", TLSConfig: tlsConfig}
+	if tlsConfig != nil {
+		log.Fatal(server.ListenAndServeTLS("", ""))
+	}
+	log.Fatal(server.ListenAndServe())
 }
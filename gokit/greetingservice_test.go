@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	service "github.com/tkeech1/gowebsvc/svc"
 
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/go-kit/kit/transport/http/jsonrpc"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/expfmt"
@@ -312,7 +314,7 @@ func Test_ExpensiveService(t *testing.T) {
 		}
 		w := httptest.NewRecorder()
 
-		handler := getExpensiveHandler(test.svc)
+		handler := getExpensiveHandler(makeExpensiveEndpoint(test.svc))
 		handler.ServeHTTP(w, req)
 		assert.Equal(t, test.expectedResponse, w.Body.String())
 		assert.Equal(t, test.httpStatusResponse, w.Code)
@@ -369,7 +371,7 @@ func Test_ExpensiveServiceMultipleTries(t *testing.T) {
 	svc = service.GreetingService{}
 	logger := log.New(os.Stdout, "LOG: ", log.Ldate|log.Ltime|log.Lshortfile)
 	svc = middleware.LoggingMiddleware{logger, svc}
-	handler := getExpensiveHandler(svc)
+	handler := getExpensiveHandler(makeExpensiveEndpoint(svc))
 
 	t.Logf("Running test case: %s", "success")
 	req, err := http.NewRequest("POST", "/expensive", bytes.NewBuffer(tests["success"].expensive))
@@ -392,3 +394,311 @@ func Test_ExpensiveServiceMultipleTries(t *testing.T) {
 	assert.Equal(t, tests["2nd_try"].httpStatusResponse, w.Code)
 
 }
+
+func Test_Versions(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		path               string
+		body               []byte
+		expectedResponse   string
+		httpStatusResponse int
+		deprecated         bool
+	}{
+		{
+			name:               "v1",
+			path:               "/v1/greeting",
+			body:               []byte(`{"s":"hello"}`),
+			expectedResponse:   `{"greeting":"hello"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+			deprecated:         true,
+		},
+		{
+			name:               "v1_localized",
+			path:               "/v1/greeting",
+			body:               []byte(`{"s":"hello","locale":"de-AT","count":2}`),
+			expectedResponse:   `{"greeting":"Hallo, hello (2 Personen)!","locale":"de"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+			deprecated:         true,
+		},
+		{
+			name:               "unversioned",
+			path:               "/greeting",
+			body:               []byte(`{"s":""}`),
+			expectedResponse:   `{"greeting":"","err":"empty greeting"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+			deprecated:         true,
+		},
+		{
+			name:               "v2",
+			path:               "/v2/greeting",
+			body:               []byte(`{"name":"hello","locale":"en","formality":"formal"}`),
+			expectedResponse:   `{"greeting":"Good day, hello.","locale":"en"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "v2_empty",
+			path:               "/v2/greeting",
+			body:               []byte(`{"name":""}`),
+			expectedResponse:   `{"greeting":"","err":"empty greeting"}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		{
+			name:               "v2_unknown_formality",
+			path:               "/v2/greeting",
+			body:               []byte(`{"name":"hello","formality":"casual"}`),
+			expectedResponse:   `{"err":"unknown formality \"casual\""}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		{
+			name:               "v2_expensive",
+			path:               "/v2/expensive",
+			body:               []byte(`{"connection_string":"c1","username":"u1"}`),
+			expectedResponse:   `{"status":"","err":"missing password"}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		{
+			name:               "v1_expensive_after_v2",
+			path:               "/v1/expensive",
+			body:               []byte(`{"connection_string":"c1","username":"u1","password":"p1"}`),
+			expectedResponse:   `{"status":"already initialized"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+			deprecated:         true,
+		},
+	}
+
+	mux := http.NewServeMux()
+	for path, handler := range routes(service.GreetingService{}, middleware.Deprecation{Date: time.Unix(1000, 0), Sunset: time.Unix(2000, 0)}) {
+		mux.Handle(path, handler)
+	}
+
+	for _, test := range tests {
+		t.Logf("Running test case: %s", test.name)
+		req, err := http.NewRequest("POST", test.path, bytes.NewBuffer(test.body))
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		assert.Equal(t, test.expectedResponse, w.Body.String())
+		assert.Equal(t, test.httpStatusResponse, w.Code)
+		if test.deprecated {
+			assert.Equal(t, "@1000", w.Header().Get("Deprecation"))
+			assert.Equal(t, "Thu, 01 Jan 1970 00:33:20 GMT", w.Header().Get("Sunset"))
+			assert.Contains(t, w.Header().Get("Link"), "/v2/")
+		} else {
+			assert.Empty(t, w.Header().Get("Deprecation"))
+		}
+	}
+}
+
+func Test_JSONRPC(t *testing.T) {
+	tests := map[string]struct {
+		method             string
+		body               string
+		expectedResponse   string
+		httpStatusResponse int
+	}{
+		"greet": {
+			method:             "POST",
+			body:               `{"jsonrpc":"2.0","method":"Greeter.Greet","params":{"name":"hello","locale":"fr","formality":"formal"},"id":1}`,
+			expectedResponse:   `{"jsonrpc":"2.0","result":{"greeting":"Bonjour, hello.","locale":"fr"},"id":1}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"expensive": {
+			method:             "POST",
+			body:               `{"jsonrpc":"2.0","method":"Greeter.Expensive","params":{"connection_string":"c1","username":"u1","password":"p1"},"id":"a"}`,
+			expectedResponse:   `{"jsonrpc":"2.0","result":{"status":"c1u1p1"},"id":"a"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"null_id": {
+			method:             "POST",
+			body:               `{"jsonrpc":"2.0","method":"Greeter.Greet","params":{"name":"hello"},"id":null}`,
+			expectedResponse:   `{"jsonrpc":"2.0","result":{"greeting":"hello"},"id":null}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"notification": {
+			method:             "POST",
+			body:               `{"jsonrpc":"2.0","method":"Greeter.Greet","params":{"name":"hello"}}`,
+			httpStatusResponse: http.StatusNoContent,
+		},
+		"batch": {
+			method: "POST",
+			body: `[{"jsonrpc":"2.0","method":"Greeter.Greet","params":{"name":"a"},"id":1},` +
+				`{"jsonrpc":"2.0","method":"Greeter.Greet","params":{"name":"b"}},` +
+				`{"jsonrpc":"2.0","method":"Greeter.Greet","params":{"name":""},"id":2},` +
+				`{"jsonrpc":"2.0","method":"Greeter.Hello","id":3},` +
+				`1]`,
+			expectedResponse: `[{"jsonrpc":"2.0","result":{"greeting":"a"},"id":1},` +
+				`{"jsonrpc":"2.0","error":{"code":-32602,"message":"empty greeting","data":{"code":"InvalidArgument"}},"id":2},` +
+				`{"jsonrpc":"2.0","error":{"code":-32601,"message":"method Greeter.Hello was not found"},"id":3},` +
+				`{"jsonrpc":"2.0","error":{"code":-32600,"message":"JSON could not be decoded: json: cannot unmarshal number into Go value of type main.rpcRequest"},"id":null}]` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"batch_notifications": {
+			method:             "POST",
+			body:               `[{"jsonrpc":"2.0","method":"Greeter.Greet","params":{"name":"a"}},{"jsonrpc":"2.0","method":"Greeter.Greet","params":{"name":"b"}}]`,
+			httpStatusResponse: http.StatusNoContent,
+		},
+		"error_empty_batch": {
+			method:             "POST",
+			body:               `[]`,
+			expectedResponse:   `{"jsonrpc":"2.0","error":{"code":-32600,"message":"empty batch"},"id":null}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"error_batch_too_large": {
+			method:             "POST",
+			body:               "[" + strings.Repeat(`{"jsonrpc":"2.0","method":"Greeter.Greet","params":{"name":"a"}},`, maxRPCBatch) + `{"jsonrpc":"2.0","method":"Greeter.Greet","params":{"name":"a"}}]`,
+			expectedResponse:   `{"jsonrpc":"2.0","error":{"code":-32600,"message":"batch of 101 calls exceeds the maximum of 100"},"id":null}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"error_body_too_large": {
+			method:             "POST",
+			body:               `{"jsonrpc":"2.0","method":"Greeter.Greet","params":{"name":"` + strings.Repeat("a", maxRPCBody) + `"},"id":1}`,
+			expectedResponse:   `{"jsonrpc":"2.0","error":{"code":-32600,"message":"http: request body too large"},"id":null}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"error_parse": {
+			method:             "POST",
+			body:               `{"jsonrpc":"2.0","method"`,
+			expectedResponse:   `{"jsonrpc":"2.0","error":{"code":-32700,"message":"JSON could not be decoded: unexpected end of JSON input"},"id":null}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"error_version": {
+			method:             "POST",
+			body:               `{"jsonrpc":"1.0","method":"Greeter.Greet","id":1}`,
+			expectedResponse:   `{"jsonrpc":"2.0","error":{"code":-32600,"message":"jsonrpc must be \"2.0\" and method must be set"},"id":1}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"error_params": {
+			method:             "POST",
+			body:               `{"jsonrpc":"2.0","method":"Greeter.Greet","params":["hello"],"id":1}`,
+			expectedResponse:   `{"jsonrpc":"2.0","error":{"code":-32602,"message":"params must be an object: json: cannot unmarshal array into Go value of type svc.GreetRequestV2"},"id":1}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"error_formality": {
+			method:             "POST",
+			body:               `{"jsonrpc":"2.0","method":"Greeter.Greet","params":{"name":"hello","formality":"rude"},"id":1}`,
+			expectedResponse:   `{"jsonrpc":"2.0","error":{"code":-32602,"message":"unknown formality \"rude\""},"id":1}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"error_method": {
+			method:             "GET",
+			expectedResponse:   "Method Not Allowed\n",
+			httpStatusResponse: http.StatusMethodNotAllowed,
+		},
+	}
+
+	mux := http.NewServeMux()
+	for path, handler := range routes(service.GreetingService{}, middleware.Deprecation{}) {
+		mux.Handle(path, handler)
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		req := httptest.NewRequest(test.method, "/rpc", bytes.NewBufferString(test.body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		assert.Equal(t, test.expectedResponse, w.Body.String())
+		assert.Equal(t, test.httpStatusResponse, w.Code)
+	}
+}
+
+func Test_RPCError(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected *jsonrpc.Error
+	}{
+		"invalid_argument": {
+			err:      service.ErrMissingUsername,
+			expected: &jsonrpc.Error{Code: jsonrpc.InvalidParamsError, Message: "missing username", Data: map[string]string{"code": "InvalidArgument"}},
+		},
+		"deadline_exceeded": {
+			err:      service.ErrRequestTimedOut,
+			expected: &jsonrpc.Error{Code: -32004, Message: "request timed out", Data: map[string]string{"code": "DeadlineExceeded"}},
+		},
+		"permission_denied": {
+			err:      service.ErrPermissionDenied,
+			expected: &jsonrpc.Error{Code: -32007, Message: "permission denied", Data: map[string]string{"code": "PermissionDenied"}},
+		},
+		"unknown": {
+			err:      errors.New("boom"),
+			expected: &jsonrpc.Error{Code: jsonrpc.InternalError, Message: "boom", Data: map[string]string{"code": "Unknown"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		assert.Equal(t, test.expected, rpcError(test.err))
+	}
+}
+
+func Test_Authorization(t *testing.T) {
+	policy := middleware.Policy{Methods: map[string]middleware.Rule{
+		"Greet":     {Roles: []string{"*"}},
+		"Expensive": {Roles: []string{"admin"}},
+	}}
+	svc := middleware.AuthorizationMiddleware{Policy: policy, Next: service.GreetingService{}}
+
+	tests := []struct {
+		name               string
+		path               string
+		roles              string
+		body               []byte
+		expectedResponse   string
+		httpStatusResponse int
+	}{
+		{
+			name:               "greet_anonymous",
+			path:               "/v1/greeting",
+			body:               []byte(`{"s":"hello"}`),
+			expectedResponse:   `{"greeting":"hello"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "v1_expensive_denied",
+			path:               "/v1/expensive",
+			roles:              "user",
+			body:               []byte(`{"connection_string":"c1","username":"u1","password":"p1"}`),
+			expectedResponse:   `{"status":"","err":"permission denied"}` + "\n",
+			httpStatusResponse: http.StatusForbidden,
+		},
+		{
+			name:               "v2_expensive_denied",
+			path:               "/v2/expensive",
+			body:               []byte(`{"connection_string":"c1","username":"u1","password":"p1"}`),
+			expectedResponse:   `{"status":"","err":"permission denied"}` + "\n",
+			httpStatusResponse: http.StatusForbidden,
+		},
+		{
+			name:               "rpc_expensive_denied",
+			path:               "/rpc",
+			body:               []byte(`{"jsonrpc":"2.0","method":"Greeter.Expensive","params":{"connection_string":"c1","username":"u1","password":"p1"},"id":1}`),
+			expectedResponse:   `{"jsonrpc":"2.0","error":{"code":-32007,"message":"permission denied","data":{"code":"PermissionDenied"}},"id":1}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "v2_expensive_allowed",
+			path:               "/v2/expensive",
+			roles:              "admin",
+			body:               []byte(`{"connection_string":"c1","username":"u1","password":"p1"}`),
+			expectedResponse:   `{"status":"c1u1p1"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+	}
+
+	mux := http.NewServeMux()
+	for path, handler := range routes(svc, middleware.Deprecation{}) {
+		mux.Handle(path, handler)
+	}
+
+	for _, test := range tests {
+		t.Logf("Running test case: %s", test.name)
+		req := httptest.NewRequest("POST", test.path, bytes.NewBuffer(test.body))
+		req.Header.Set("X-Caller-Roles", test.roles)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		assert.Equal(t, test.expectedResponse, w.Body.String())
+		assert.Equal(t, test.httpStatusResponse, w.Code)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	service "github.com/tkeech1/gowebsvc/svc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/go-kit/kit/transport/http/jsonrpc"
+)
+
+// JSON-RPC 2.0
+
+// rpcCodecs returns the JSON-RPC methods of the service. Their params are the v2
+// request bodies, passed by name, and their results the v2 response bodies.
+func rpcCodecs(svc service.Greeter, expensive endpoint.Endpoint) jsonrpc.EndpointCodecMap {
+	return jsonrpc.EndpointCodecMap{
+		"Greeter.Greet": {
+			Endpoint: makeGreetingV2Endpoint(svc),
+			Decode:   decodeGreetRPCParams,
+			Encode:   encodeRPCResult,
+		},
+		"Greeter.Expensive": {
+			Endpoint: makeExpensiveV2Endpoint(expensive),
+			Decode:   decodeExpensiveRPCParams,
+			Encode:   encodeRPCResult,
+		},
+	}
+}
+
+func decodeGreetRPCParams(_ context.Context, params json.RawMessage) (interface{}, error) {
+	var request service.GreetRequestV2
+	if err := decodeRPCParams(params, &request); err != nil {
+		return nil, err
+	}
+	if _, err := service.ParseFormality(request.Formality); err != nil {
+		return nil, jsonrpc.Error{Code: jsonrpc.InvalidParamsError, Message: err.Error()}
+	}
+	return greetRequestV2{name: request.Name, locale: request.Locale, formality: request.Formality, count: request.Count, template: request.Template}, nil
+}
+
+func decodeExpensiveRPCParams(_ context.Context, params json.RawMessage) (interface{}, error) {
+	var request service.ExpensiveRequest
+	if err := decodeRPCParams(params, &request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// decodeRPCParams decodes params given by name into v. Omitted params decode as an
+// empty object.
+func decodeRPCParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return jsonrpc.Error{Code: jsonrpc.InvalidParamsError, Message: "params must be an object: " + err.Error()}
+	}
+	return nil
+}
+
+// encodeRPCResult returns the body of a v2 response, or its service error.
+func encodeRPCResult(_ context.Context, response interface{}) (json.RawMessage, error) {
+	r := response.(responseV2)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return json.Marshal(r.body)
+}
+
+// rpcError converts err to a JSON-RPC error object. Invalid arguments are reported as
+// invalid params and unknown errors as internal errors; the other service errors get
+// a server error code, -32000 minus their gRPC code, with the code name as data.
+func rpcError(err error) *jsonrpc.Error {
+	if e, ok := err.(jsonrpc.Error); ok {
+		return &e
+	}
+	code := service.Code(err)
+	e := &jsonrpc.Error{Message: err.Error(), Data: map[string]string{"code": code.String()}}
+	switch code {
+	case codes.InvalidArgument:
+		e.Code = jsonrpc.InvalidParamsError
+	case codes.Unknown:
+		e.Code = jsonrpc.InternalError
+	default:
+		e.Code = -32000 - int(code)
+	}
+	return e
+}
+
+// rpcRequest is a JSON-RPC request. ID is nil in notifications, which are not answered,
+// and "null" when the client sent a null id.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonrpc.Error  `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+var nullID = json.RawMessage("null")
+
+// Limits on JSON-RPC requests: the size of the body and the number of calls in a
+// batch.
+const (
+	maxRPCBody  = 1 << 20
+	maxRPCBatch = 100
+)
+
+// rpcServer serves JSON-RPC 2.0 over HTTP POST with the codecs of a go-kit
+// jsonrpc.EndpointCodecMap. Unlike jsonrpc.Server, it accepts batches, whose calls run
+// in order and are answered in an array, and notifications, which run without being
+// answered; a request made only of notifications gets 204 No Content. Bodies larger
+// than maxRPCBody and batches of more than maxRPCBatch calls are invalid requests.
+type rpcServer struct {
+	ecm    jsonrpc.EndpointCodecMap
+	before []httptransport.RequestFunc
+}
+
+func newRPCServer(ecm jsonrpc.EndpointCodecMap, before ...httptransport.RequestFunc) *rpcServer {
+	return &rpcServer{ecm: ecm, before: before}
+}
+
+func (s *rpcServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	for _, f := range s.before {
+		ctx = f(ctx, r)
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRPCBody))
+	if err != nil {
+		writeRPC(w, rpcResponse{JSONRPC: jsonrpc.Version, Error: &jsonrpc.Error{Code: jsonrpc.InvalidRequestError, Message: err.Error()}, ID: nullID})
+		return
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '[' {
+		if response, ok := s.call(ctx, body); ok {
+			writeRPC(w, response)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		writeRPC(w, rpcResponse{JSONRPC: jsonrpc.Version, Error: &jsonrpc.Error{Code: jsonrpc.ParseError, Message: "JSON could not be decoded: " + err.Error()}, ID: nullID})
+		return
+	}
+	if len(batch) == 0 {
+		writeRPC(w, rpcResponse{JSONRPC: jsonrpc.Version, Error: &jsonrpc.Error{Code: jsonrpc.InvalidRequestError, Message: "empty batch"}, ID: nullID})
+		return
+	}
+	if len(batch) > maxRPCBatch {
+		message := fmt.Sprintf("batch of %d calls exceeds the maximum of %d", len(batch), maxRPCBatch)
+		writeRPC(w, rpcResponse{JSONRPC: jsonrpc.Version, Error: &jsonrpc.Error{Code: jsonrpc.InvalidRequestError, Message: message}, ID: nullID})
+		return
+	}
+	responses := []rpcResponse{}
+	for _, message := range batch {
+		if response, ok := s.call(ctx, message); ok {
+			responses = append(responses, response)
+		}
+	}
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeRPC(w, responses)
+}
+
+// call runs a single request. It reports false for notifications, which get no
+// response.
+func (s *rpcServer) call(ctx context.Context, message json.RawMessage) (rpcResponse, bool) {
+	response := rpcResponse{JSONRPC: jsonrpc.Version, ID: nullID}
+	var req rpcRequest
+	if err := json.Unmarshal(message, &req); err != nil {
+		code := jsonrpc.InvalidRequestError
+		if _, ok := err.(*json.SyntaxError); ok {
+			code = jsonrpc.ParseError
+		}
+		response.Error = &jsonrpc.Error{Code: code, Message: "JSON could not be decoded: " + err.Error()}
+		return response, true
+	}
+	if req.ID != nil {
+		response.ID = req.ID
+	}
+	if req.JSONRPC != jsonrpc.Version || req.Method == "" {
+		response.Error = &jsonrpc.Error{Code: jsonrpc.InvalidRequestError, Message: `jsonrpc must be "2.0" and method must be set`}
+		return response, true
+	}
+
+	result, err := s.invoke(ctx, req)
+	if req.ID == nil {
+		return rpcResponse{}, false
+	}
+	if err != nil {
+		response.Error = rpcError(err)
+		return response, true
+	}
+	response.Result = result
+	return response, true
+}
+
+func (s *rpcServer) invoke(ctx context.Context, req rpcRequest) (json.RawMessage, error) {
+	ecm, ok := s.ecm[req.Method]
+	if !ok {
+		return nil, jsonrpc.Error{Code: jsonrpc.MethodNotFoundError, Message: "method " + req.Method + " was not found"}
+	}
+	request, err := ecm.Decode(ctx, req.Params)
+	if err != nil {
+		return nil, err
+	}
+	response, err := ecm.Endpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return ecm.Encode(ctx, response)
+}
+
+func writeRPC(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", jsonrpc.ContentType)
+	json.NewEncoder(w).Encode(v)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"sync"
 
+	middleware "github.com/tkeech1/gowebsvc/middleware"
 	service "github.com/tkeech1/gowebsvc/svc"
 
 	"github.com/go-kit/kit/endpoint"
@@ -17,27 +18,33 @@ import (
 func makeGreetingEndpoint(svc service.Greeter) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(service.GreetRequest)
+		ctx, err := service.NewRequestOptionsContext(ctx, req.Locale, req.Formality, req.Count, req.Template)
+		if err != nil {
+			return service.GreetResponse{V: "", Err: err.Error()}, nil
+		}
 		v, err := svc.Greet(ctx, req.S)
 		if err != nil {
-			return service.GreetResponse{"", err.Error()}, nil
+			return service.GreetResponse{V: "", Err: err.Error()}, nil
 		}
-		return service.GreetResponse{v, ""}, nil
+		return service.GreetResponse{V: v, Err: "", Locale: service.ResolveLocale(ctx)}, nil
 	}
 }
 
 func makeExpensiveEndpoint(svc service.Greeter) endpoint.Endpoint {
 	var (
-		init sync.Once
+		mu   sync.Mutex
+		done bool
 	)
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		var err error
-		v := "already initialized"
-		init.Do(func() {
-			// do an expensive operation here - it will only occur on the first invocation of the handler
-			req := request.(service.ExpensiveRequest)
-			v, err = svc.Expensive(ctx, req.C, req.U, req.P)
-		})
-
+		mu.Lock()
+		defer mu.Unlock()
+		if done {
+			return service.ExpensiveResponse{V: "already initialized"}, nil
+		}
+		// do an expensive operation here - it will only occur on the first permitted invocation of the handler
+		req := request.(service.ExpensiveRequest)
+		v, err := svc.Expensive(ctx, req.C, req.U, req.P)
+		done = err != service.ErrPermissionDenied
 		if err != nil {
 			return service.ExpensiveResponse{"", err.Error()}, nil
 		}
@@ -63,5 +70,108 @@ func decodeExpensiveRequest(_ context.Context, r *http.Request) (interface{}, er
 }
 
 func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	var errMsg string
+	switch r := response.(type) {
+	case service.GreetResponse:
+		if r.Locale != "" {
+			w.Header().Set("Content-Language", r.Locale)
+		}
+		errMsg = r.Err
+	case service.ExpensiveResponse:
+		errMsg = r.Err
+	}
+	if errMsg == service.ErrPermissionDenied.Error() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+	}
 	return json.NewEncoder(w).Encode(response)
 }
+
+// caller stores the caller from the X-Caller-* headers in the context, where the
+// authorization middleware looks for it.
+func caller(ctx context.Context, r *http.Request) context.Context {
+	return middleware.NewCallerContext(ctx, middleware.CallerFromRequest(r))
+}
+
+// acceptLanguage stores the locales of the Accept-Language header in the GreetOptions
+// of the request context.
+func acceptLanguage(ctx context.Context, r *http.Request) context.Context {
+	if header := r.Header["Accept-Language"]; len(header) > 0 {
+		return service.NewAcceptLanguageContext(ctx, header...)
+	}
+	return ctx
+}
+
+// v2
+
+// greetRequestV2 is a decoded v2 greeting request.
+type greetRequestV2 struct {
+	name      string
+	locale    string
+	formality string
+	count     int
+	template  string
+}
+
+// responseV2 carries the service error of a v2 endpoint next to the response body, so
+// encodeV2Response can report it with a matching status code.
+type responseV2 struct {
+	body interface{}
+	err  error
+}
+
+func makeGreetingV2Endpoint(svc service.Greeter) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(greetRequestV2)
+		ctx, err := service.NewRequestOptionsContext(ctx, req.locale, req.formality, req.count, req.template)
+		if err != nil {
+			return nil, err
+		}
+		v, err := svc.Greet(ctx, req.name)
+		if err != nil {
+			return responseV2{service.GreetResponse{V: "", Err: err.Error()}, err}, nil
+		}
+		return responseV2{service.GreetResponse{V: v, Err: "", Locale: service.ResolveLocale(ctx)}, nil}, nil
+	}
+}
+
+// makeExpensiveV2Endpoint serves v2 from the v1 endpoint, so the expensive operation
+// still runs only once across both versions.
+func makeExpensiveV2Endpoint(v1 endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		response, err := v1(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		r := response.(service.ExpensiveResponse)
+		return responseV2{r, service.DecodeError(r.Err)}, nil
+	}
+}
+
+func decodeGreetV2Request(_ context.Context, r *http.Request) (interface{}, error) {
+	var request service.GreetRequestV2
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	if _, err := service.ParseFormality(request.Formality); err != nil {
+		return nil, err
+	}
+	return greetRequestV2{name: request.Name, locale: request.Locale, formality: request.Formality, count: request.Count, template: request.Template}, nil
+}
+
+func encodeV2Response(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	r := response.(responseV2)
+	w.Header().Set("Content-Type", "application/json")
+	if g, ok := r.body.(service.GreetResponse); ok && g.Locale != "" {
+		w.Header().Set("Content-Language", g.Locale)
+	}
+	w.WriteHeader(service.HTTPStatus(r.err))
+	return json.NewEncoder(w).Encode(r.body)
+}
+
+// encodeV2Error reports requests that could not be decoded.
+func encodeV2Error(_ context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"err": err.Error()})
+}
@@ -0,0 +1,21 @@
+// Package graphql defines the GraphQL requests and responses the service exchanges,
+// run by graph-gophers/graphql-go.
+package graphql
+
+import (
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// Request is a GraphQL request, as sent in the JSON body of a POST request.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is a GraphQL response. Data holds the json.RawMessage produced by
+// graphql-go, and is left out when the request did not run.
+type Response struct {
+	Data   interface{}          `json:"data,omitempty"`
+	Errors []*errors.QueryError `json:"errors,omitempty"`
+}
@@ -0,0 +1,163 @@
+// Package i18n generates localized greetings: a message catalog with formal and
+// informal variants and plural forms, and locale negotiation with fallback chains.
+package i18n
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Messages are the greetings of one locale. Informal and Formal contain {name}; when
+// several people are greeted, {name} becomes Group with {people} replaced by the
+// People form for their number, which contains {count}.
+type Messages struct {
+	Informal string
+	Formal   string
+	People   map[Category]string
+	// Group defaults to "{name} ({people})".
+	Group string
+}
+
+// Catalog holds the messages of every supported locale.
+type Catalog struct {
+	Messages map[string]Messages
+	// Default is used when none of the preferred locales is supported.
+	Default string
+	// Parents maps locales to the locale they fall back to when the fallback is not
+	// found by removing subtags, e.g. Swiss German to German.
+	Parents map[string]string
+}
+
+// Default is the built-in catalog.
+var Default = &Catalog{
+	Default: "en",
+	Parents: map[string]string{
+		"gsw": "de",
+		"lb":  "de",
+	},
+	Messages: map[string]Messages{
+		"ar": {
+			Informal: "مرحبا يا {name}!",
+			Formal:   "السلام عليكم يا {name}.",
+			People: map[Category]string{
+				Zero:  "{count} شخص",
+				One:   "شخص واحد",
+				Two:   "شخصان",
+				Few:   "{count} أشخاص",
+				Many:  "{count} شخصًا",
+				Other: "{count} شخص",
+			},
+		},
+		"cs": {
+			Informal: "Ahoj, {name}!",
+			Formal:   "Dobrý den, {name}.",
+			People:   map[Category]string{One: "{count} osoba", Few: "{count} osoby", Other: "{count} osob"},
+		},
+		"de": {
+			Informal: "Hallo, {name}!",
+			Formal:   "Guten Tag, {name}.",
+			People:   map[Category]string{One: "{count} Person", Other: "{count} Personen"},
+		},
+		"en": {
+			Informal: "Hi, {name}!",
+			Formal:   "Good day, {name}.",
+			People:   map[Category]string{One: "{count} person", Other: "{count} people"},
+		},
+		"es": {
+			Informal: "¡Hola, {name}!",
+			Formal:   "Buenos días, {name}.",
+			People:   map[Category]string{One: "{count} persona", Other: "{count} personas"},
+		},
+		"fr": {
+			Informal: "Salut, {name} !",
+			Formal:   "Bonjour, {name}.",
+			People:   map[Category]string{One: "{count} personne", Other: "{count} personnes"},
+		},
+		"it": {
+			Informal: "Ciao, {name}!",
+			Formal:   "Buongiorno, {name}.",
+			People:   map[Category]string{One: "{count} persona", Other: "{count} persone"},
+		},
+		"ja": {
+			Informal: "やあ、{name}！",
+			Formal:   "こんにちは、{name}さん。",
+			People:   map[Category]string{Other: "{count}人"},
+			Group:    "{name}（{people}）",
+		},
+		"ko": {
+			Informal: "안녕, {name}!",
+			Formal:   "안녕하세요, {name}님.",
+			People:   map[Category]string{Other: "{count}명"},
+		},
+		"nl": {
+			Informal: "Hoi, {name}!",
+			Formal:   "Goedendag, {name}.",
+			People:   map[Category]string{One: "{count} persoon", Other: "{count} personen"},
+		},
+		"pl": {
+			Informal: "Cześć, {name}!",
+			Formal:   "Dzień dobry, {name}.",
+			People:   map[Category]string{One: "{count} osoba", Few: "{count} osoby", Many: "{count} osób", Other: "{count} osoby"},
+		},
+		"pt": {
+			Informal: "Olá, {name}!",
+			Formal:   "Bom dia, {name}.",
+			People:   map[Category]string{One: "{count} pessoa", Other: "{count} pessoas"},
+		},
+		"pt-BR": {
+			Informal: "Oi, {name}!",
+			Formal:   "Bom dia, {name}.",
+			People:   map[Category]string{One: "{count} pessoa", Other: "{count} pessoas"},
+		},
+		"ru": {
+			Informal: "Привет, {name}!",
+			Formal:   "Здравствуйте, {name}.",
+			People:   map[Category]string{One: "{count} человек", Few: "{count} человека", Many: "{count} человек", Other: "{count} человека"},
+		},
+		"sv": {
+			Informal: "Hej, {name}!",
+			Formal:   "God dag, {name}.",
+			People:   map[Category]string{One: "{count} person", Other: "{count} personer"},
+		},
+		"tr": {
+			Informal: "Merhaba, {name}!",
+			Formal:   "İyi günler, {name}.",
+			People:   map[Category]string{One: "{count} kişi", Other: "{count} kişi"},
+		},
+		"uk": {
+			Informal: "Привіт, {name}!",
+			Formal:   "Добрий день, {name}.",
+			People:   map[Category]string{One: "{count} особа", Few: "{count} особи", Many: "{count} осіб", Other: "{count} особи"},
+		},
+		"zh": {
+			Informal: "你好，{name}！",
+			Formal:   "您好，{name}。",
+			People:   map[Category]string{Other: "{count}人"},
+			Group:    "{name}（{people}）",
+		},
+	},
+}
+
+// Greeting returns the greeting for name in locale, which must be a locale of the
+// catalog as returned by Resolve. count is the number of people greeted; values below
+// 2 greet name alone.
+func (c *Catalog) Greeting(locale string, formal bool, name string, count int) string {
+	m := c.Messages[locale]
+	if count > 1 {
+		people := m.People[PluralCategory(locale, count)]
+		if people == "" {
+			people = m.People[Other]
+		}
+		group := m.Group
+		if group == "" {
+			group = "{name} ({people})"
+		}
+		people = strings.Replace(people, "{count}", strconv.Itoa(count), -1)
+		name = strings.NewReplacer("{name}", name, "{people}", people).Replace(group)
+	}
+	message := m.Informal
+	if formal {
+		message = m.Formal
+	}
+	return strings.Replace(message, "{name}", name, -1)
+}
@@ -0,0 +1,118 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PluralCategory(t *testing.T) {
+	tests := map[string]struct {
+		locale   string
+		n        int
+		expected Category
+	}{
+		"en_one":      {locale: "en", n: 1, expected: One},
+		"en_other":    {locale: "en-GB", n: 2, expected: Other},
+		"fr_zero":     {locale: "fr", n: 0, expected: One},
+		"ru_21":       {locale: "ru", n: 21, expected: One},
+		"ru_11":       {locale: "ru", n: 11, expected: Many},
+		"ru_22":       {locale: "ru", n: 22, expected: Few},
+		"ru_12":       {locale: "ru", n: 12, expected: Many},
+		"pl_21":       {locale: "pl", n: 21, expected: Many},
+		"pl_24":       {locale: "pl", n: 24, expected: Few},
+		"cs_5":        {locale: "cs", n: 5, expected: Other},
+		"ar_2":        {locale: "ar", n: 2, expected: Two},
+		"ar_105":      {locale: "ar", n: 105, expected: Few},
+		"ar_111":      {locale: "ar", n: 111, expected: Many},
+		"ar_100":      {locale: "ar", n: 100, expected: Other},
+		"ja_1":        {locale: "ja", n: 1, expected: Other},
+		"unknown_one": {locale: "xx", n: 1, expected: One},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		assert.Equal(t, test.expected, PluralCategory(test.locale, test.n))
+	}
+}
+
+func Test_Resolve(t *testing.T) {
+	tests := map[string]struct {
+		preferences []string
+		expected    string
+	}{
+		"exact":           {preferences: []string{"de"}, expected: "de"},
+		"case":            {preferences: []string{"PT_br"}, expected: "pt-BR"},
+		"truncated":       {preferences: []string{"de-CH-1996"}, expected: "de"},
+		"region_fallback": {preferences: []string{"pt-PT"}, expected: "pt"},
+		"extension":       {preferences: []string{"es-u-co-trad"}, expected: "es"},
+		"parent":          {preferences: []string{"gsw-CH"}, expected: "de"},
+		"second_choice":   {preferences: []string{"xx", "fr"}, expected: "fr"},
+		"order":           {preferences: []string{"it-CH", "de"}, expected: "it"},
+		"wildcard":        {preferences: []string{"*"}, expected: "en"},
+		"default":         {preferences: []string{"xx-YY"}, expected: "en"},
+		"none":            {expected: "en"},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		assert.Equal(t, test.expected, Default.Resolve(test.preferences...))
+	}
+}
+
+func Test_Greeting(t *testing.T) {
+	tests := map[string]struct {
+		locale   string
+		formal   bool
+		count    int
+		expected string
+	}{
+		"en_informal": {locale: "en", expected: "Hi, Ann!"},
+		"en_formal":   {locale: "en", formal: true, expected: "Good day, Ann."},
+		"en_group":    {locale: "en", count: 3, expected: "Hi, Ann (3 people)!"},
+		"de_formal":   {locale: "de", formal: true, count: 1, expected: "Guten Tag, Ann."},
+		"ru_few":      {locale: "ru", count: 3, expected: "Привет, Ann (3 человека)!"},
+		"ru_many":     {locale: "ru", count: 5, expected: "Привет, Ann (5 человек)!"},
+		"pl_many":     {locale: "pl", formal: true, count: 12, expected: "Dzień dobry, Ann (12 osób)."},
+		"ja_group":    {locale: "ja", formal: true, count: 4, expected: "こんにちは、Ann（4人）さん。"},
+		"ar_two":      {locale: "ar", count: 2, expected: "مرحبا يا Ann (شخصان)!"},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		assert.Equal(t, test.expected, Default.Greeting(test.locale, test.formal, "Ann", test.count))
+	}
+}
+
+func Test_Catalog(t *testing.T) {
+	// every locale must be able to greet any number of people
+	for locale, m := range Default.Messages {
+		t.Logf("Running test case: %s", locale)
+		assert.Contains(t, m.Informal, "{name}")
+		assert.Contains(t, m.Formal, "{name}")
+		for n := 2; n < 200; n++ {
+			category := PluralCategory(locale, n)
+			_, ok := m.People[category]
+			assert.True(t, ok || m.People[Other] != "", "%s has no %s form for %d", locale, category, n)
+		}
+	}
+}
+
+func Test_ParseAcceptLanguage(t *testing.T) {
+	tests := map[string]struct {
+		header   string
+		expected []string
+	}{
+		"empty":    {header: "", expected: []string{}},
+		"single":   {header: "de-CH", expected: []string{"de-CH"}},
+		"weighted": {header: "fr;q=0.5, de-CH, en;q=0.9", expected: []string{"de-CH", "en", "fr"}},
+		"stable":   {header: "it;q=0.8,es;q=0.8", expected: []string{"it", "es"}},
+		"refused":  {header: "en, de;q=0", expected: []string{"en"}},
+		"invalid":  {header: "en;q=x, ,fr", expected: []string{"en", "fr"}},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		assert.Equal(t, test.expected, ParseAcceptLanguage(test.header))
+	}
+}
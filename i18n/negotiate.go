@@ -0,0 +1,110 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Resolve returns the catalog locale to use for the preferred locales, best first.
+// Each preference is tried along its fallback chain, e.g. de-CH-1996, de-CH, de, before
+// the next one; when none matches the catalog default is used.
+func (c *Catalog) Resolve(preferences ...string) string {
+	for _, p := range preferences {
+		for _, tag := range c.fallbacks(p) {
+			if locale, ok := c.lookup(tag); ok {
+				return locale
+			}
+		}
+	}
+	return c.Default
+}
+
+// fallbacks returns tag followed by its truncations and parents.
+func (c *Catalog) fallbacks(tag string) []string {
+	var chain []string
+	seen := map[string]bool{}
+	for tag = normalize(tag); tag != "" && tag != "*" && !seen[tag]; {
+		seen[tag] = true
+		chain = append(chain, tag)
+		if parent, ok := c.parent(tag); ok {
+			tag = normalize(parent)
+			continue
+		}
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+		// single-letter subtags introduce extensions and cannot stand alone
+		if j := strings.LastIndex(tag, "-"); j >= 0 && j == len(tag)-2 {
+			tag = tag[:j]
+		}
+	}
+	return chain
+}
+
+func (c *Catalog) parent(tag string) (string, bool) {
+	for locale, parent := range c.Parents {
+		if normalize(locale) == tag {
+			return parent, true
+		}
+	}
+	return "", false
+}
+
+func (c *Catalog) lookup(tag string) (string, bool) {
+	for locale := range c.Messages {
+		if normalize(locale) == tag {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+func normalize(tag string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(tag), "_", "-", -1))
+}
+
+func language(locale string) string {
+	tag := normalize(locale)
+	if i := strings.Index(tag, "-"); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// ParseAcceptLanguage returns the language ranges of an Accept-Language header, most
+// preferred first. Ranges with q=0 are dropped.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var ranges []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, weighted{tag, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	tags := make([]string, len(ranges))
+	for i, r := range ranges {
+		tags[i] = r.tag
+	}
+	return tags
+}
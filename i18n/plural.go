@@ -0,0 +1,110 @@
+package i18n
+
+// Category is a CLDR plural category.
+type Category string
+
+const (
+	Zero  Category = "zero"
+	One   Category = "one"
+	Two   Category = "two"
+	Few   Category = "few"
+	Many  Category = "many"
+	Other Category = "other"
+)
+
+// pluralRules holds the CLDR cardinal rules, restricted to non-negative integers, by
+// language.
+var pluralRules = map[string]func(n int) Category{
+	"ar": arabic,
+	"cs": czech,
+	"de": oneOther,
+	"en": oneOther,
+	"es": oneOther,
+	"fr": zeroOneOther,
+	"it": oneOther,
+	"ja": otherOnly,
+	"ko": otherOnly,
+	"nl": oneOther,
+	"pl": polish,
+	"pt": zeroOneOther,
+	"ru": eastSlavic,
+	"sv": oneOther,
+	"tr": oneOther,
+	"uk": eastSlavic,
+	"zh": otherOnly,
+}
+
+// PluralCategory returns the plural category of n in the language of locale. Languages
+// without known rules use one/other.
+func PluralCategory(locale string, n int) Category {
+	rule, ok := pluralRules[language(locale)]
+	if !ok {
+		rule = oneOther
+	}
+	return rule(n)
+}
+
+func otherOnly(n int) Category {
+	return Other
+}
+
+func oneOther(n int) Category {
+	if n == 1 {
+		return One
+	}
+	return Other
+}
+
+// zeroOneOther treats 0 like 1, as French and Portuguese do.
+func zeroOneOther(n int) Category {
+	if n == 0 || n == 1 {
+		return One
+	}
+	return Other
+}
+
+func eastSlavic(n int) Category {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return One
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return Few
+	}
+	return Many
+}
+
+func polish(n int) Category {
+	switch {
+	case n == 1:
+		return One
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return Few
+	}
+	return Many
+}
+
+func czech(n int) Category {
+	switch {
+	case n == 1:
+		return One
+	case n >= 2 && n <= 4:
+		return Few
+	}
+	return Other
+}
+
+func arabic(n int) Category {
+	switch {
+	case n == 0:
+		return Zero
+	case n == 1:
+		return One
+	case n == 2:
+		return Two
+	case n%100 >= 3 && n%100 <= 10:
+		return Few
+	case n%100 >= 11 && n%100 <= 99:
+		return Many
+	}
+	return Other
+}
@@ -0,0 +1,257 @@
+// Package jobs runs operations asynchronously on a bounded pool of workers and keeps
+// their results until they expire.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound  = errors.New("job not found")
+	ErrQueueFull = errors.New("job queue is full")
+	ErrClosed    = errors.New("job pool is closed")
+)
+
+type State string
+
+const (
+	Pending   State = "pending"
+	Running   State = "running"
+	Succeeded State = "succeeded"
+	Failed    State = "failed"
+	Cancelled State = "cancelled"
+)
+
+// Done reports whether a job in state s has finished.
+func (s State) Done() bool {
+	return s == Succeeded || s == Failed || s == Cancelled
+}
+
+// Job is a snapshot of a submitted operation. Result is set when it succeeded and Err
+// when it failed. Owner is the caller that submitted it, the only one who can see or
+// cancel it.
+type Job struct {
+	ID        string
+	Owner     string
+	State     State
+	Result    string
+	Err       error
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Func is the operation run by a job. Its context is cancelled when the job is.
+type Func func(ctx context.Context) (string, error)
+
+type job struct {
+	Job
+	fn     Func
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// values carries the values of the submitting context, without its cancellation.
+	values context.Context
+}
+
+// Pool runs jobs on a fixed number of workers. Jobs wait in a bounded queue; Submit
+// fails with ErrQueueFull when it is full. A job cancelled while waiting keeps its
+// place in the queue until a worker skips it. Finished jobs are forgotten TTL after they
+// finished.
+type Pool struct {
+	// OnDone, if set, is called with every job once it finished or was cancelled, and
+	// with a context carrying the values of the context it was submitted with, such as
+	// the caller. It must be set before the first Submit.
+	OnDone func(context.Context, Job)
+
+	ttl   time.Duration
+	queue chan *job
+	now   func() time.Time
+	wg    sync.WaitGroup
+
+	mu     sync.Mutex
+	jobs   map[string]*job
+	closed bool
+}
+
+func NewPool(workers, queueSize int, ttl time.Duration) *Pool {
+	p := &Pool{
+		ttl:   ttl,
+		queue: make(chan *job, queueSize),
+		now:   time.Now,
+		jobs:  map[string]*job{},
+	}
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues fn on behalf of owner. The values of ctx, such as the caller, are
+// passed on to fn but its cancellation is not: the job outlives the request submitting
+// it.
+func (p *Pool) Submit(ctx context.Context, owner string, fn Func) (Job, error) {
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+	values := Detach(ctx)
+	jobCtx, cancel := context.WithCancel(values)
+	now := p.now()
+	j := &job{Job: Job{ID: id, Owner: owner, State: Pending, CreatedAt: now, UpdatedAt: now}, fn: fn, ctx: jobCtx, cancel: cancel, values: values}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		cancel()
+		return Job{}, ErrClosed
+	}
+	p.purge()
+	select {
+	case p.queue <- j:
+	default:
+		cancel()
+		return Job{}, ErrQueueFull
+	}
+	p.jobs[id] = j
+	return j.Job, nil
+}
+
+// Get returns the job with id, or ErrNotFound if it does not exist, has expired or
+// belongs to another owner.
+func (p *Pool) Get(owner, id string) (Job, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.purge()
+	j, ok := p.jobs[id]
+	if !ok || j.Owner != owner {
+		return Job{}, ErrNotFound
+	}
+	return j.Job, nil
+}
+
+// Cancel stops the job with id if it has not finished yet and returns it. Like Get, it
+// returns ErrNotFound for the jobs of other owners.
+func (p *Pool) Cancel(owner, id string) (Job, error) {
+	p.mu.Lock()
+	p.purge()
+	j, ok := p.jobs[id]
+	if !ok || j.Owner != owner {
+		p.mu.Unlock()
+		return Job{}, ErrNotFound
+	}
+	cancelled := !j.State.Done()
+	if cancelled {
+		j.State = Cancelled
+		j.UpdatedAt = p.now()
+		j.cancel()
+	}
+	snapshot := j.Job
+	p.mu.Unlock()
+
+	if cancelled {
+		p.done(j.values, snapshot)
+	}
+	return snapshot, nil
+}
+
+// Close cancels the unfinished jobs and waits for the workers to stop.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	var cancelled []*job
+	for _, j := range p.jobs {
+		if !j.State.Done() {
+			j.State = Cancelled
+			j.UpdatedAt = p.now()
+			j.cancel()
+			cancelled = append(cancelled, j)
+		}
+	}
+	close(p.queue)
+	p.mu.Unlock()
+	p.wg.Wait()
+	for _, j := range cancelled {
+		p.done(j.values, j.Job)
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for j := range p.queue {
+		p.mu.Lock()
+		if j.State != Pending {
+			p.mu.Unlock()
+			continue
+		}
+		j.State = Running
+		j.UpdatedAt = p.now()
+		p.mu.Unlock()
+
+		result, err := j.fn(j.ctx)
+
+		p.mu.Lock()
+		finished := j.State == Running
+		if finished {
+			j.State, j.Result, j.Err = Succeeded, result, err
+			if err != nil {
+				j.State, j.Result = Failed, ""
+			}
+			j.UpdatedAt = p.now()
+		}
+		snapshot := j.Job
+		p.mu.Unlock()
+		j.cancel()
+
+		if finished {
+			p.done(j.values, snapshot)
+		}
+	}
+}
+
+func (p *Pool) done(ctx context.Context, j Job) {
+	if p.OnDone != nil {
+		p.OnDone(ctx, j)
+	}
+}
+
+// purge forgets the jobs that finished more than ttl ago. p.mu must be held.
+func (p *Pool) purge() {
+	now := p.now()
+	for id, j := range p.jobs {
+		if j.State.Done() && now.Sub(j.UpdatedAt) > p.ttl {
+			delete(p.jobs, id)
+		}
+	}
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Detach returns a context with the values of ctx, such as the caller, but not its
+// deadline or cancellation, for work that outlives the request starting it.
+func Detach(ctx context.Context) context.Context {
+	return detached{ctx}
+}
+
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detached) Done() <-chan struct{}       { return nil }
+func (detached) Err() error                  { return nil }
@@ -0,0 +1,246 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// wait polls the job with id until it is done.
+func wait(t *testing.T, p *Pool, id string) Job {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		j, err := p.Get("ann", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if j.State.Done() {
+			return j
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return Job{}
+}
+
+// block returns a Func running until its context is cancelled, and a channel
+// receiving a value once it started.
+func block() (Func, chan struct{}) {
+	started := make(chan struct{}, 1)
+	return func(ctx context.Context) (string, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return "", ctx.Err()
+	}, started
+}
+
+func Test_PoolRun(t *testing.T) {
+	failure := errors.New("failure")
+	tests := map[string]struct {
+		fn          Func
+		expectedJob Job
+	}{
+		"succeeded": {
+			fn:          func(ctx context.Context) (string, error) { return "done", nil },
+			expectedJob: Job{State: Succeeded, Result: "done"},
+		},
+		"failed": {
+			fn:          func(ctx context.Context) (string, error) { return "partial", failure },
+			expectedJob: Job{State: Failed, Err: failure},
+		},
+	}
+
+	p := NewPool(2, 10, time.Minute)
+	defer p.Close()
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		submitted, err := p.Submit(context.Background(), "ann", test.fn)
+		assert.Nil(t, err)
+		assert.Equal(t, Pending, submitted.State)
+		assert.Len(t, submitted.ID, 32)
+
+		j := wait(t, p, submitted.ID)
+		assert.Equal(t, test.expectedJob.State, j.State)
+		assert.Equal(t, test.expectedJob.Result, j.Result)
+		assert.Equal(t, test.expectedJob.Err, j.Err)
+		assert.Equal(t, submitted.CreatedAt, j.CreatedAt)
+		assert.False(t, j.UpdatedAt.Before(j.CreatedAt))
+	}
+
+	_, err := p.Get("ann", "missing")
+	assert.Equal(t, ErrNotFound, err)
+	_, err = p.Cancel("ann", "missing")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func Test_PoolOwner(t *testing.T) {
+	p := NewPool(1, 10, time.Minute)
+	defer p.Close()
+	fn, _ := block()
+	submitted, err := p.Submit(context.Background(), "ann", fn)
+	assert.Nil(t, err)
+	assert.Equal(t, "ann", submitted.Owner)
+
+	// the jobs of other callers, anonymous ones included, are not found
+	for _, owner := range []string{"bob", ""} {
+		_, err = p.Get(owner, submitted.ID)
+		assert.Equal(t, ErrNotFound, err)
+		_, err = p.Cancel(owner, submitted.ID)
+		assert.Equal(t, ErrNotFound, err)
+	}
+	j, err := p.Get("ann", submitted.ID)
+	assert.Nil(t, err)
+	assert.False(t, j.State.Done())
+}
+
+type key struct{}
+
+func Test_PoolDetachesContext(t *testing.T) {
+	p := NewPool(1, 1, time.Minute)
+	defer p.Close()
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "alice"))
+	submitted, err := p.Submit(ctx, "ann", func(ctx context.Context) (string, error) {
+		time.Sleep(10 * time.Millisecond)
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return ctx.Value(key{}).(string), nil
+	})
+	assert.Nil(t, err)
+	cancel()
+
+	j := wait(t, p, submitted.ID)
+	assert.Equal(t, Succeeded, j.State)
+	assert.Equal(t, "alice", j.Result)
+}
+
+func Test_PoolCancel(t *testing.T) {
+	p := NewPool(1, 1, time.Minute)
+	defer p.Close()
+
+	fn, started := block()
+	running, err := p.Submit(context.Background(), "ann", fn)
+	assert.Nil(t, err)
+	<-started
+	pending, err := p.Submit(context.Background(), "ann", fn)
+	assert.Nil(t, err)
+
+	// the queue holds a single job
+	_, err = p.Submit(context.Background(), "ann", fn)
+	assert.Equal(t, ErrQueueFull, err)
+
+	j, err := p.Cancel("ann", pending.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, Cancelled, j.State)
+
+	j, err = p.Cancel("ann", running.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, Cancelled, j.State)
+
+	// the worker skips the cancelled pending job, freeing its slot in the queue, and
+	// the result of the cancelled running job is discarded
+	var done Job
+	for err = ErrQueueFull; err == ErrQueueFull; time.Sleep(time.Millisecond) {
+		done, err = p.Submit(context.Background(), "ann", func(ctx context.Context) (string, error) { return "ok", nil })
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, Succeeded, wait(t, p, done.ID).State)
+	j, err = p.Get("ann", running.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, Cancelled, j.State)
+	assert.Nil(t, j.Err)
+
+	// cancelling a finished job leaves it unchanged
+	j, err = p.Cancel("ann", done.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, Succeeded, j.State)
+	assert.Equal(t, "ok", j.Result)
+}
+
+func Test_PoolExpiry(t *testing.T) {
+	p := NewPool(1, 1, time.Minute)
+	defer p.Close()
+	now := time.Now()
+	p.mu.Lock()
+	p.now = func() time.Time { return now }
+	p.mu.Unlock()
+
+	fn, started := block()
+	running, err := p.Submit(context.Background(), "ann", fn)
+	assert.Nil(t, err)
+	<-started
+	finished, err := p.Submit(context.Background(), "ann", fn)
+	assert.Nil(t, err)
+	_, err = p.Cancel("ann", finished.ID)
+	assert.Nil(t, err)
+
+	p.mu.Lock()
+	p.now = func() time.Time { return now.Add(2 * time.Minute) }
+	p.mu.Unlock()
+
+	_, err = p.Get("ann", finished.ID)
+	assert.Equal(t, ErrNotFound, err)
+	// unfinished jobs do not expire
+	j, err := p.Get("ann", running.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, Running, j.State)
+}
+
+func Test_PoolClose(t *testing.T) {
+	p := NewPool(1, 1, time.Minute)
+
+	fn, started := block()
+	running, err := p.Submit(context.Background(), "ann", fn)
+	assert.Nil(t, err)
+	<-started
+
+	p.Close()
+	j, err := p.Get("ann", running.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, Cancelled, j.State)
+
+	_, err = p.Submit(context.Background(), "ann", fn)
+	assert.Equal(t, ErrClosed, err)
+	p.Close()
+}
+
+type ownerKey struct{}
+
+func Test_PoolOnDone(t *testing.T) {
+	p := NewPool(1, 1, time.Minute)
+	done := make(chan Job, 2)
+	values := make(chan interface{}, 2)
+	p.OnDone = func(ctx context.Context, j Job) {
+		values <- ctx.Value(ownerKey{})
+		done <- j
+	}
+
+	// the hook gets the values of the submitting context
+	ctx := context.WithValue(context.Background(), ownerKey{}, "alice")
+	succeeded, err := p.Submit(ctx, "ann", func(ctx context.Context) (string, error) { return "ok", nil })
+	assert.Nil(t, err)
+	j := <-done
+	assert.Equal(t, succeeded.ID, j.ID)
+	assert.Equal(t, Succeeded, j.State)
+	assert.Equal(t, "alice", <-values)
+
+	fn, started := block()
+	cancelled, err := p.Submit(context.Background(), "ann", fn)
+	assert.Nil(t, err)
+	<-started
+	_, err = p.Cancel("ann", cancelled.ID)
+	assert.Nil(t, err)
+	j = <-done
+	assert.Equal(t, cancelled.ID, j.ID)
+	assert.Equal(t, Cancelled, j.State)
+
+	// cancelling again or closing does not report the job twice
+	_, err = p.Cancel("ann", cancelled.ID)
+	assert.Nil(t, err)
+	p.Close()
+	assert.Len(t, done, 0)
+}
@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	service "github.com/tkeech1/gowebsvc/svc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Rule lists the roles and scopes allowed to invoke a method. A caller needs any one
+// of them. The role "*" allows every caller, including anonymous ones.
+type Rule struct {
+	Roles  []string `json:"roles"`
+	Scopes []string `json:"scopes"`
+}
+
+// Policy maps method names (Greet, Expensive, Webhooks, History) to rules. Methods without a rule
+// are denied.
+type Policy struct {
+	Methods map[string]Rule `json:"methods"`
+}
+
+func LoadPolicy(path string) (Policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Policy{}, err
+	}
+	defer f.Close()
+
+	var p Policy
+	if err := json.NewDecoder(f).Decode(&p); err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+func (p Policy) Allowed(method string, c Caller) bool {
+	rule, ok := p.Methods[method]
+	if !ok {
+		return false
+	}
+	for _, r := range rule.Roles {
+		if r == "*" || contains(c.Roles, r) {
+			return true
+		}
+	}
+	for _, s := range rule.Scopes {
+		if contains(c.Scopes, s) {
+			return true
+		}
+	}
+	return false
+}
+
+type AuthorizationMiddleware struct {
+	Policy Policy
+	Logger *log.Logger
+	Next   service.Greeter
+}
+
+type AuthorizationMiddlewareGRPC struct {
+	Policy Policy
+	Logger *log.Logger
+	Next   service.GreeterGRPC
+}
+
+func (mw AuthorizationMiddleware) Greet(ctx context.Context, greeting string) (string, error) {
+	if !mw.authorize(ctx, "Greet") {
+		return "", service.ErrPermissionDenied
+	}
+	return mw.Next.Greet(ctx, greeting)
+}
+
+func (mw AuthorizationMiddleware) Expensive(ctx context.Context, connectionString, username, password string) (string, error) {
+	if !mw.authorize(ctx, "Expensive") {
+		return "", service.ErrPermissionDenied
+	}
+	return mw.Next.Expensive(ctx, connectionString, username, password)
+}
+
+// Handler authorizes the caller of a request for method before passing it on to next.
+// It puts routes that do not go through the Greeter, such as the webhook routes, under
+// the same policy. Denied requests get 403 Forbidden.
+func (mw AuthorizationMiddleware) Handler(method string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !mw.authorize(r.Context(), method) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(service.ErrorResponse{Err: service.ErrPermissionDenied.Error()})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (mw AuthorizationMiddleware) authorize(ctx context.Context, method string) bool {
+	c, _ := CallerFromContext(ctx)
+	return audit(mw.Logger, mw.Policy, method, c)
+}
+
+func (mw AuthorizationMiddlewareGRPC) GreetGRPC(ctx context.Context, in *service.GRPCGreetRequest) (*service.GRPCGreetResponse, error) {
+	if err := mw.authorize(ctx, "Greet"); err != nil {
+		return nil, err
+	}
+	return mw.Next.GreetGRPC(ctx, in)
+}
+
+func (mw AuthorizationMiddlewareGRPC) ExpensiveGRPC(ctx context.Context, in *service.GRPCExpensiveRequest) (*service.GRPCExpensiveResponse, error) {
+	if err := mw.authorize(ctx, "Expensive"); err != nil {
+		return nil, err
+	}
+	return mw.Next.ExpensiveGRPC(ctx, in)
+}
+
+func (mw AuthorizationMiddlewareGRPC) authorize(ctx context.Context, method string) error {
+	c, ok := CallerFromContext(ctx)
+	if !ok {
+		c = CallerFromMetadata(ctx)
+	}
+	if !audit(mw.Logger, mw.Policy, method, c) {
+		return status.Error(codes.PermissionDenied, service.ErrPermissionDenied.Error())
+	}
+	return nil
+}
+
+func audit(logger *log.Logger, p Policy, method string, c Caller) bool {
+	allowed := p.Allowed(method, c)
+	decision := "deny"
+	if allowed {
+		decision = "allow"
+	}
+	if logger != nil {
+		logger.Print(
+			"audit: ", decision+"; ",
+			"method: ", method+"; ",
+			"caller: ", c.ID+"; ",
+			"roles: ", strings.Join(c.Roles, ",")+"; ",
+			"scopes: ", strings.Join(c.Scopes, ","),
+		)
+	}
+	return allowed
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/tkeech1/gowebsvc/cache"
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+// CachingMiddleware caches successful Greet responses for TTL. Lookups are counted
+// with the label "result" set to "hit" or "miss". Cache failures are logged and
+// treated as misses so they never fail a request. Greetings rendered by one of
+// Templates that changes over the day are cached per hour.
+type CachingMiddleware struct {
+	Cache     cache.Cache
+	TTL       time.Duration
+	Lookups   metrics.Counter
+	Logger    *log.Logger
+	Templates *service.Templates
+	Next      service.Greeter
+}
+
+func (mw CachingMiddleware) Greet(ctx context.Context, greeting string) (string, error) {
+	o := service.GreetOptionsFromContext(ctx)
+	key := "greet:" + o.Key() + ":" + greeting
+	if bucket := mw.Templates.Bucket(o.Template); bucket != "" {
+		key += ":" + bucket
+	}
+	v, ok, err := mw.Cache.Get(ctx, key)
+	if err != nil {
+		mw.Logger.Print("cache get failed: ", err)
+	}
+	if ok {
+		mw.Lookups.With("result", "hit").Add(1)
+		return v, nil
+	}
+	mw.Lookups.With("result", "miss").Add(1)
+
+	v, err = mw.Next.Greet(ctx, greeting)
+	if err != nil {
+		return v, err
+	}
+	if err := mw.Cache.Set(ctx, key, v, mw.TTL); err != nil {
+		mw.Logger.Print("cache set failed: ", err)
+	}
+	return v, nil
+}
+
+func (mw CachingMiddleware) Expensive(ctx context.Context, connectionString, username, password string) (string, error) {
+	return mw.Next.Expensive(ctx, connectionString, username, password)
+}
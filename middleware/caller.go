@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Caller identifies who is invoking the service. Identity is established upstream
+// (e.g. by a gateway) and forwarded in the X-Caller-* headers or gRPC metadata.
+type Caller struct {
+	ID     string
+	Roles  []string
+	Scopes []string
+}
+
+type callerKey struct{}
+
+func NewCallerContext(ctx context.Context, c Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, c)
+}
+
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	c, ok := ctx.Value(callerKey{}).(Caller)
+	return c, ok
+}
+
+func CallerFromRequest(r *http.Request) Caller {
+	return Caller{
+		ID:     r.Header.Get("X-Caller-Id"),
+		Roles:  splitList(r.Header.Get("X-Caller-Roles")),
+		Scopes: splitList(r.Header.Get("X-Caller-Scopes")),
+	}
+}
+
+func CallerFromMetadata(ctx context.Context) Caller {
+	md, _ := metadata.FromIncomingContext(ctx)
+	get := func(k string) string {
+		if v := md.Get(k); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	return Caller{
+		ID:     get("x-caller-id"),
+		Roles:  splitList(get("x-caller-roles")),
+		Scopes: splitList(get("x-caller-scopes")),
+	}
+}
+
+// CallerFromHeaders reads the caller from message headers named like the gRPC
+// metadata keys.
+func CallerFromHeaders(h map[string]string) Caller {
+	return Caller{
+		ID:     h["x-caller-id"],
+		Roles:  splitList(h["x-caller-roles"]),
+		Scopes: splitList(h["x-caller-scopes"]),
+	}
+}
+
+// CallerHandler stores the caller from the request headers in the request context.
+func CallerHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewCallerContext(r.Context(), CallerFromRequest(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// CallerUnaryServerInterceptor stores the caller from the gRPC metadata in the context,
+// for services implemented on top of a Greeter.
+func CallerUnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(NewCallerContext(ctx, CallerFromMetadata(ctx)), req)
+}
+
+// ChainUnaryServer runs the interceptors in order, the first one outermost.
+func ChainUnaryServer(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			next, interceptor := handler, interceptors[i]
+			handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
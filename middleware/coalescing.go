@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/tkeech1/gowebsvc/jobs"
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+// CoalescingMiddleware collapses concurrent identical calls into a single call to Next
+// and hands its result to every waiter. A waiter whose context is cancelled returns
+// early; the shared call is only cancelled once every waiter has gone. Calls that
+// joined an in-flight call are counted with the label "method".
+type CoalescingMiddleware struct {
+	Coalesced metrics.Counter
+	Next      service.Greeter
+
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	output  string
+	err     error
+}
+
+func NewCoalescingMiddleware(coalesced metrics.Counter, next service.Greeter) *CoalescingMiddleware {
+	return &CoalescingMiddleware{
+		Coalesced: coalesced,
+		Next:      next,
+		calls:     make(map[string]*flight),
+	}
+}
+
+func (mw *CoalescingMiddleware) Greet(ctx context.Context, greeting string) (string, error) {
+	return mw.do(ctx, "greeting", "greet\x00"+service.GreetOptionsFromContext(ctx).Key()+"\x00"+greeting, func(ctx context.Context) (string, error) {
+		return mw.Next.Greet(ctx, greeting)
+	})
+}
+
+func (mw *CoalescingMiddleware) Expensive(ctx context.Context, connectionString, username, password string) (string, error) {
+	key := "expensive\x00" + connectionString + "\x00" + username + "\x00" + password
+	return mw.do(ctx, "expensive", key, func(ctx context.Context) (string, error) {
+		return mw.Next.Expensive(ctx, connectionString, username, password)
+	})
+}
+
+func (mw *CoalescingMiddleware) do(ctx context.Context, method, key string, fn func(context.Context) (string, error)) (string, error) {
+	mw.mu.Lock()
+	f, ok := mw.calls[key]
+	if ok {
+		f.waiters++
+		mw.mu.Unlock()
+		mw.Coalesced.With("method", method).Add(1)
+	} else {
+		// detached, so one waiter giving up does not cancel the shared call
+		callCtx, cancel := context.WithCancel(jobs.Detach(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel, waiters: 1}
+		mw.calls[key] = f
+		mw.mu.Unlock()
+
+		go func() {
+			f.output, f.err = fn(callCtx)
+			mw.mu.Lock()
+			if mw.calls[key] == f {
+				delete(mw.calls, key)
+			}
+			mw.mu.Unlock()
+			cancel()
+			close(f.done)
+		}()
+	}
+
+	select {
+	case <-f.done:
+		return f.output, f.err
+	case <-ctx.Done():
+		mw.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			if mw.calls[key] == f {
+				delete(mw.calls, key)
+			}
+			f.cancel()
+		}
+		mw.mu.Unlock()
+		return "", service.ErrRequestCancelled
+	}
+}
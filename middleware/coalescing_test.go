@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/stretchr/testify/assert"
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+type fakeCounter struct {
+	total *int64
+}
+
+func (c fakeCounter) With(...string) metrics.Counter { return c }
+func (c fakeCounter) Add(delta float64)              { atomic.AddInt64(c.total, int64(delta)) }
+
+// blockingGreeter holds every call until release is closed and counts calls.
+type blockingGreeter struct {
+	calls     int64
+	release   chan struct{}
+	cancelled chan struct{}
+}
+
+func (g *blockingGreeter) Greet(ctx context.Context, greeting string) (string, error) {
+	atomic.AddInt64(&g.calls, 1)
+	select {
+	case <-g.release:
+		return greeting, nil
+	case <-ctx.Done():
+		close(g.cancelled)
+		return "", service.ErrRequestCancelled
+	}
+}
+
+func (g *blockingGreeter) Expensive(ctx context.Context, connectionString, username, password string) (string, error) {
+	return g.Greet(ctx, connectionString+username+password)
+}
+
+func waiters(mw *CoalescingMiddleware, key string) int {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	if f, ok := mw.calls[key]; ok {
+		return f.waiters
+	}
+	return 0
+}
+
+func Test_Coalescing(t *testing.T) {
+	var coalesced int64
+	next := &blockingGreeter{release: make(chan struct{}), cancelled: make(chan struct{})}
+	mw := NewCoalescingMiddleware(fakeCounter{&coalesced}, next)
+
+	var wg sync.WaitGroup
+	results := make(chan string, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := mw.Greet(context.Background(), "hello")
+			assert.Nil(t, err)
+			results <- v
+		}()
+	}
+	assert.Eventually(t, func() bool { return waiters(mw, "greet\x00"+service.GreetOptions{}.Key()+"\x00hello") == 5 }, time.Second, time.Millisecond)
+
+	// a different call is not coalesced
+	go mw.Expensive(context.Background(), "c", "u", "p")
+	assert.Eventually(t, func() bool { return atomic.LoadInt64(&next.calls) == 2 }, time.Second, time.Millisecond)
+
+	close(next.release)
+	wg.Wait()
+	close(results)
+	for v := range results {
+		assert.Equal(t, "hello", v)
+	}
+	assert.Equal(t, int64(2), atomic.LoadInt64(&next.calls))
+	assert.Equal(t, int64(4), atomic.LoadInt64(&coalesced))
+	assert.Equal(t, 0, waiters(mw, "greet\x00"+service.GreetOptions{}.Key()+"\x00hello"))
+}
+
+func Test_CoalescingCancel(t *testing.T) {
+	var coalesced int64
+	next := &blockingGreeter{release: make(chan struct{}), cancelled: make(chan struct{})}
+	mw := NewCoalescingMiddleware(fakeCounter{&coalesced}, next)
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() { _, err := mw.Greet(ctx1, "hello"); errs <- err }()
+	assert.Eventually(t, func() bool { return waiters(mw, "greet\x00"+service.GreetOptions{}.Key()+"\x00hello") == 1 }, time.Second, time.Millisecond)
+	go func() { _, err := mw.Greet(ctx2, "hello"); errs <- err }()
+	assert.Eventually(t, func() bool { return waiters(mw, "greet\x00"+service.GreetOptions{}.Key()+"\x00hello") == 2 }, time.Second, time.Millisecond)
+
+	// the first waiter gives up; the shared call keeps running for the second
+	cancel1()
+	assert.Equal(t, service.ErrRequestCancelled, <-errs)
+	select {
+	case <-next.cancelled:
+		t.Fatal("shared call cancelled while a waiter remains")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// once the last waiter gives up the shared call is cancelled
+	cancel2()
+	assert.Equal(t, service.ErrRequestCancelled, <-errs)
+	select {
+	case <-next.cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("shared call not cancelled")
+	}
+	assert.Equal(t, 0, waiters(mw, "greet\x00"+service.GreetOptions{}.Key()+"\x00hello"))
+}
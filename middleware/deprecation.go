@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Deprecation announces that an API version is deprecated, with the Deprecation
+// (RFC 9745) and Sunset (RFC 8594) headers.
+type Deprecation struct {
+	// Date is when the version was deprecated.
+	Date time.Time
+	// Sunset is when the version stops being served; zero when not decided yet.
+	Sunset time.Time
+	// Successor is the URL of the replacement, linked as the successor-version.
+	Successor string
+}
+
+func (d Deprecation) deprecation() string {
+	return fmt.Sprintf("@%d", d.Date.Unix())
+}
+
+func (d Deprecation) sunset() string {
+	if d.Sunset.IsZero() {
+		return ""
+	}
+	return d.Sunset.UTC().Format(http.TimeFormat)
+}
+
+// Handler adds the deprecation headers to every response of next.
+func (d Deprecation) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", d.deprecation())
+		if sunset := d.sunset(); sunset != "" {
+			w.Header().Set("Sunset", sunset)
+		}
+		if d.Successor != "" {
+			w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", d.Successor))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// UnaryServerInterceptor sends the deprecation and sunset as response header metadata
+// on calls to the given gRPC services, e.g. "svc.GreetingService".
+func (d Deprecation) UnaryServerInterceptor(services ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		for _, service := range services {
+			if strings.HasPrefix(info.FullMethod, "/"+service+"/") {
+				md := metadata.Pairs("deprecation", d.deprecation())
+				if sunset := d.sunset(); sunset != "" {
+					md.Set("sunset", sunset)
+				}
+				grpc.SetHeader(ctx, md)
+				break
+			}
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tkeech1/gowebsvc/cache"
+)
+
+// IdempotencyKeyHeader names the header with which clients make a POST request
+// idempotent.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotentBody bounds the request bodies read to fingerprint a request.
+const maxIdempotentBody = 1 << 20
+
+// storedResponse is a response recorded for an idempotency key. Fingerprint
+// identifies the request it answered.
+type storedResponse struct {
+	Fingerprint string      `json:"fingerprint"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
+
+// Idempotency makes POST requests carrying an Idempotency-Key header safe to retry.
+// The first response for a caller's key is stored in Cache for TTL and replayed to
+// later requests with that key, marked with an Idempotent-Replayed header. A request
+// arriving while the first one is running waits for its response. Reusing a key for a
+// different method, path or body is rejected with 422. Server errors are not stored,
+// so a retry after one runs the request again.
+type Idempotency struct {
+	Cache  cache.Cache
+	TTL    time.Duration
+	Logger *log.Logger
+
+	mu       sync.Mutex
+	inflight map[string]chan struct{}
+}
+
+func NewIdempotency(c cache.Cache, ttl time.Duration, logger *log.Logger) *Idempotency {
+	return &Idempotency{Cache: c, TTL: ttl, Logger: logger, inflight: map[string]chan struct{}{}}
+}
+
+// Handler applies idempotency to next. It reads the caller from the request context,
+// so it must run inside CallerHandler.
+func (i *Idempotency) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		if err != nil {
+			writeIdempotencyError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		fingerprint := sha256.Sum256([]byte(r.Method + " " + r.URL.Path + "\n" + string(body)))
+
+		caller, _ := CallerFromContext(r.Context())
+		id := sha256.Sum256([]byte(caller.ID + "\x00" + key))
+		cacheKey := "idempotency:" + hex.EncodeToString(id[:])
+
+		for {
+			stored, ok := i.lookup(r.Context(), cacheKey)
+			if ok {
+				if stored.Fingerprint != hex.EncodeToString(fingerprint[:]) {
+					writeIdempotencyError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+					return
+				}
+				replay(w, stored)
+				return
+			}
+
+			done, first := i.begin(cacheKey)
+			if !first {
+				select {
+				case <-done:
+					continue
+				case <-r.Context().Done():
+					return
+				}
+			}
+
+			rec := &responseRecorder{header: http.Header{}, status: http.StatusOK}
+			func() {
+				defer i.end(cacheKey, done)
+				next.ServeHTTP(rec, r)
+				if rec.status < http.StatusInternalServerError && r.Context().Err() == nil {
+					i.store(r.Context(), cacheKey, storedResponse{
+						Fingerprint: hex.EncodeToString(fingerprint[:]),
+						Status:      rec.status,
+						Header:      rec.header,
+						Body:        rec.body.Bytes(),
+					})
+				}
+			}()
+			for k, v := range rec.header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(rec.status)
+			w.Write(rec.body.Bytes())
+			return
+		}
+	})
+}
+
+// begin registers the in-flight request for key, or returns the channel closed when
+// the request already in flight ends.
+func (i *Idempotency) begin(key string) (chan struct{}, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if done, ok := i.inflight[key]; ok {
+		return done, false
+	}
+	done := make(chan struct{})
+	i.inflight[key] = done
+	return done, true
+}
+
+func (i *Idempotency) end(key string, done chan struct{}) {
+	i.mu.Lock()
+	delete(i.inflight, key)
+	i.mu.Unlock()
+	close(done)
+}
+
+func (i *Idempotency) lookup(ctx context.Context, key string) (storedResponse, bool) {
+	v, ok, err := i.Cache.Get(ctx, key)
+	if err != nil {
+		i.Logger.Printf("idempotency lookup failed: %v", err)
+		return storedResponse{}, false
+	}
+	if !ok {
+		return storedResponse{}, false
+	}
+	var stored storedResponse
+	if err := json.Unmarshal([]byte(v), &stored); err != nil {
+		i.Logger.Printf("idempotency lookup failed: %v", err)
+		return storedResponse{}, false
+	}
+	return stored, true
+}
+
+func (i *Idempotency) store(ctx context.Context, key string, stored storedResponse) {
+	v, err := json.Marshal(stored)
+	if err == nil {
+		err = i.Cache.Set(ctx, key, string(v), i.TTL)
+	}
+	if err != nil {
+		i.Logger.Printf("failed to store idempotent response: %v", err)
+	}
+}
+
+func replay(w http.ResponseWriter, stored storedResponse) {
+	for k, v := range stored.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+func writeIdempotencyError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"err": msg})
+}
+
+// responseRecorder buffers a response so it can be stored before it is sent.
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(p)
+}
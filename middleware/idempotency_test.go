@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tkeech1/gowebsvc/cache"
+)
+
+// countingHandler answers with the number of requests it served.
+type countingHandler struct {
+	calls   int64
+	status  int
+	release chan struct{}
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := atomic.AddInt64(&h.calls, 1)
+	if h.release != nil {
+		<-h.release
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "text/plain")
+	if h.status != 0 {
+		w.WriteHeader(h.status)
+	}
+	fmt.Fprintf(w, "%d %s", n, body)
+}
+
+func idempotentRequest(handler http.Handler, caller, key, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("X-Caller-Id", caller)
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func Test_Idempotency(t *testing.T) {
+	next := &countingHandler{}
+	handler := CallerHandler(NewIdempotency(cache.NewLRU(10), time.Minute, log.New(ioutil.Discard, "", 0)).Handler(next))
+
+	tests := []struct {
+		name     string
+		caller   string
+		key      string
+		path     string
+		body     string
+		status   int
+		expected string
+		replayed bool
+	}{
+		{name: "first", caller: "alice", key: "k1", path: "/greeting", body: "a", status: http.StatusOK, expected: "1 a"},
+		{name: "replay", caller: "alice", key: "k1", path: "/greeting", body: "a", status: http.StatusOK, expected: "1 a", replayed: true},
+		{name: "other_body", caller: "alice", key: "k1", path: "/greeting", body: "b", status: http.StatusUnprocessableEntity, expected: `{"err":"Idempotency-Key was already used for a different request"}` + "\n"},
+		{name: "other_path", caller: "alice", key: "k1", path: "/expensive", body: "a", status: http.StatusUnprocessableEntity, expected: `{"err":"Idempotency-Key was already used for a different request"}` + "\n"},
+		{name: "other_caller", caller: "bob", key: "k1", path: "/greeting", body: "a", status: http.StatusOK, expected: "2 a"},
+		{name: "other_key", caller: "alice", key: "k2", path: "/greeting", body: "a", status: http.StatusOK, expected: "3 a"},
+		{name: "no_key", caller: "alice", path: "/greeting", body: "a", status: http.StatusOK, expected: "4 a"},
+		{name: "no_key_again", caller: "alice", path: "/greeting", body: "a", status: http.StatusOK, expected: "5 a"},
+	}
+
+	for _, test := range tests {
+		t.Logf("Running test case: %s", test.name)
+		w := idempotentRequest(handler, test.caller, test.key, test.path, test.body)
+		assert.Equal(t, test.status, w.Code)
+		assert.Equal(t, test.expected, w.Body.String())
+		assert.Equal(t, test.replayed, w.Header().Get("Idempotent-Replayed") == "true")
+		if test.status == http.StatusOK {
+			assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func Test_IdempotencyConcurrent(t *testing.T) {
+	next := &countingHandler{release: make(chan struct{})}
+	handler := CallerHandler(NewIdempotency(cache.NewLRU(10), time.Minute, log.New(ioutil.Discard, "", 0)).Handler(next))
+
+	var wg sync.WaitGroup
+	responses := make(chan string, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses <- idempotentRequest(handler, "alice", "k", "/expensive", "x").Body.String()
+		}()
+	}
+	assert.Eventually(t, func() bool { return atomic.LoadInt64(&next.calls) == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(next.release)
+	wg.Wait()
+	close(responses)
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&next.calls))
+	for response := range responses {
+		assert.Equal(t, "1 x", response)
+	}
+}
+
+func Test_IdempotencyServerError(t *testing.T) {
+	next := &countingHandler{status: http.StatusInternalServerError}
+	handler := CallerHandler(NewIdempotency(cache.NewLRU(10), time.Minute, log.New(ioutil.Discard, "", 0)).Handler(next))
+
+	// server errors are not stored, so a retry runs the request again
+	assert.Equal(t, "1 x", idempotentRequest(handler, "alice", "k", "/expensive", "x").Body.String())
+	next.status = http.StatusOK
+	assert.Equal(t, "2 x", idempotentRequest(handler, "alice", "k", "/expensive", "x").Body.String())
+	assert.Equal(t, "2 x", idempotentRequest(handler, "alice", "k", "/expensive", "x").Body.String())
+}
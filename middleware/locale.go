@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+// AcceptLanguageHandler stores the locales of the Accept-Language header in the
+// GreetOptions of the request context. Responses vary by that header.
+func AcceptLanguageHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Language")
+		if header := r.Header["Accept-Language"]; len(header) > 0 {
+			r = r.WithContext(service.NewAcceptLanguageContext(r.Context(), header...))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -66,8 +66,8 @@ func (mw LoggingMiddlewareGRPC) GreetGRPC(ctx context.Context, in *service.GRPCG
 		}
 		mw.Logger.Print(
 			"method: ", "Greet"+"; ",
-			"input: ", in.S+"; ",
-			"output: ", output.Greeting+"; ",
+			"input: ", in.GetS()+"; ",
+			"output: ", output.GetGreeting()+"; ",
 			"err: ", errMsg+"; ",
 			"took: ", time.Since(begin),
 		)
@@ -76,3 +76,24 @@ func (mw LoggingMiddlewareGRPC) GreetGRPC(ctx context.Context, in *service.GRPCG
 	output, err = mw.Next.GreetGRPC(ctx, in)
 	return
 }
+
+func (mw LoggingMiddlewareGRPC) ExpensiveGRPC(ctx context.Context, in *service.GRPCExpensiveRequest) (output *service.GRPCExpensiveResponse, err error) {
+	defer func(begin time.Time) {
+		errMsg := ""
+		if err != nil {
+			errMsg = err.Error()
+		}
+		mw.Logger.Print(
+			"method: ", "Expensive"+"; ",
+			"connectionString: ", in.GetConnectionString()+"; ",
+			"username: ", in.GetUsername()+"; ",
+			"password: ", in.GetPassword()+"; ",
+			"output: ", output.GetStatus()+"; ",
+			"err: ", errMsg+"; ",
+			"took: ", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.Next.ExpensiveGRPC(ctx, in)
+	return
+}
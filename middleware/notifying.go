@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"log"
+
+	service "github.com/tkeech1/gowebsvc/svc"
+	"github.com/tkeech1/gowebsvc/webhook"
+)
+
+// NotifyingMiddleware publishes a greeting.created webhook event for every successful
+// greeting, to the webhooks of the caller. Failures to publish are logged and do not
+// fail the request.
+type NotifyingMiddleware struct {
+	Webhooks *webhook.Dispatcher
+	Logger   *log.Logger
+	Next     service.Greeter
+}
+
+func (mw NotifyingMiddleware) Greet(ctx context.Context, greeting string) (string, error) {
+	v, err := mw.Next.Greet(ctx, greeting)
+	if err != nil {
+		return v, err
+	}
+	caller, _ := CallerFromContext(ctx)
+	event := service.GreetingEvent{
+		Name:     greeting,
+		Greeting: v,
+		Locale:   service.ResolveLocale(ctx),
+		Caller:   caller.ID,
+	}
+	if err := mw.Webhooks.Publish(caller.ID, webhook.GreetingCreated, event); err != nil {
+		mw.Logger.Printf("failed to publish greeting event: %v", err)
+	}
+	return v, nil
+}
+
+func (mw NotifyingMiddleware) Expensive(ctx context.Context, connectionString, username, password string) (string, error) {
+	return mw.Next.Expensive(ctx, connectionString, username, password)
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"log"
+
+	"github.com/tkeech1/gowebsvc/store"
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+// PersistingMiddleware records every successful greeting in Greetings, with the
+// caller and the locale it was written in. Failures to record are logged and do not
+// fail the request.
+type PersistingMiddleware struct {
+	Greetings store.Repository
+	Logger    *log.Logger
+	Next      service.Greeter
+}
+
+func (mw PersistingMiddleware) Greet(ctx context.Context, greeting string) (string, error) {
+	v, err := mw.Next.Greet(ctx, greeting)
+	if err != nil {
+		return v, err
+	}
+	caller, _ := CallerFromContext(ctx)
+	g := store.Greeting{
+		Name:     greeting,
+		Greeting: v,
+		Locale:   service.ResolveLocale(ctx),
+		Caller:   caller.ID,
+	}
+	if err := mw.Greetings.Save(ctx, &g); err != nil {
+		mw.Logger.Printf("failed to record greeting: %v", err)
+	}
+	return v, nil
+}
+
+func (mw PersistingMiddleware) Expensive(ctx context.Context, connectionString, username, password string) (string, error) {
+	return mw.Next.Expensive(ctx, connectionString, username, password)
+}
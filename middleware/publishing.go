@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/tkeech1/gowebsvc/events"
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+// PublishingMiddleware adds an event to Outbox for every call, successful or not,
+// which publishes it to Topic in the background. Events that cannot be queued are
+// logged and do not fail the request.
+type PublishingMiddleware struct {
+	Outbox *events.Outbox
+	Topic  string
+	Logger *log.Logger
+	Next   service.Greeter
+}
+
+func (mw PublishingMiddleware) Greet(ctx context.Context, greeting string) (string, error) {
+	v, err := mw.Next.Greet(ctx, greeting)
+	event := service.CallEvent{Method: "Greet", Name: greeting, Greeting: v}
+	if err == nil {
+		event.Locale = service.ResolveLocale(ctx)
+	}
+	mw.publish(ctx, event, err)
+	return v, err
+}
+
+func (mw PublishingMiddleware) Expensive(ctx context.Context, connectionString, username, password string) (string, error) {
+	v, err := mw.Next.Expensive(ctx, connectionString, username, password)
+	mw.publish(ctx, service.CallEvent{Method: "Expensive"}, err)
+	return v, err
+}
+
+func (mw PublishingMiddleware) publish(ctx context.Context, event service.CallEvent, err error) {
+	caller, _ := CallerFromContext(ctx)
+	event.Caller = caller.ID
+	event.At = time.Now().UTC().Format(time.RFC3339Nano)
+	if err != nil {
+		event.Err = err.Error()
+	}
+	data, err := json.Marshal(event)
+	if err == nil {
+		err = mw.Outbox.Add(mw.Topic, data)
+	}
+	if err != nil {
+		mw.Logger.Printf("failed to publish %s event: %v", event.Method, err)
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tkeech1/gowebsvc/events"
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+func Test_Publishing(t *testing.T) {
+	bus := events.NewMemory()
+	defer bus.Close()
+	received, _ := bus.Subscribe("greetings", 10)
+	outbox := events.NewOutbox(bus, 10, time.Millisecond, log.New(ioutil.Discard, "", 0))
+	mw := PublishingMiddleware{Outbox: outbox, Topic: "greetings", Logger: log.New(ioutil.Discard, "", 0), Next: service.GreetingService{}}
+
+	ctx := NewCallerContext(context.Background(), Caller{ID: "alice"})
+	deCtx, err := service.NewRequestOptionsContext(ctx, "de", "", 0, "")
+	assert.Nil(t, err)
+	_, err = mw.Greet(deCtx, "hello")
+	assert.Nil(t, err)
+	_, err = mw.Greet(ctx, "")
+	assert.Equal(t, service.ErrEmptyGreeting, err)
+	_, err = mw.Expensive(ctx, "c", "u", "secret")
+	assert.Nil(t, err)
+	assert.Nil(t, outbox.Close(context.Background()))
+
+	tests := []service.CallEvent{
+		{Method: "Greet", Name: "hello", Greeting: "Hallo, hello!", Locale: "de", Caller: "alice"},
+		{Method: "Greet", Caller: "alice", Err: "empty greeting"},
+		{Method: "Expensive", Caller: "alice"},
+	}
+	for _, expected := range tests {
+		t.Logf("Running test case: %s %s", expected.Method, expected.Name)
+		m := <-received
+		var event service.CallEvent
+		assert.Nil(t, json.Unmarshal(m.Data, &event))
+		assert.NotEmpty(t, event.At)
+		event.At = ""
+		assert.Equal(t, expected, event)
+		assert.NotContains(t, string(m.Data), "secret")
+	}
+}
@@ -0,0 +1,322 @@
+package mq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Frame types and the end-of-frame marker of AMQP 0-9-1.
+const (
+	frameMethod    = 1
+	frameHeader    = 2
+	frameBody      = 3
+	frameHeartbeat = 8
+	frameEnd       = 0xCE
+)
+
+var amqpProtocolHeader = []byte("AMQP\x00\x00\x09\x01")
+
+var errMalformedFrame = errors.New("mq: malformed AMQP frame")
+
+// methodID identifies an AMQP method by class and method ID.
+type methodID struct {
+	class, method uint16
+}
+
+var (
+	connectionStart   = methodID{10, 10}
+	connectionStartOk = methodID{10, 11}
+	connectionTune    = methodID{10, 30}
+	connectionTuneOk  = methodID{10, 31}
+	connectionOpen    = methodID{10, 40}
+	connectionOpenOk  = methodID{10, 41}
+	connectionClose   = methodID{10, 50}
+	connectionCloseOk = methodID{10, 51}
+	channelOpen       = methodID{20, 10}
+	channelOpenOk     = methodID{20, 11}
+	channelClose      = methodID{20, 40}
+	channelCloseOk    = methodID{20, 41}
+	queueDeclare      = methodID{50, 10}
+	queueDeclareOk    = methodID{50, 11}
+	basicQos          = methodID{60, 10}
+	basicQosOk        = methodID{60, 11}
+	basicConsume      = methodID{60, 20}
+	basicConsumeOk    = methodID{60, 21}
+	basicCancel       = methodID{60, 30}
+	basicCancelOk     = methodID{60, 31}
+	basicPublish      = methodID{60, 40}
+	basicDeliver      = methodID{60, 60}
+	basicAck          = methodID{60, 80}
+	basicNack         = methodID{60, 120}
+)
+
+const basicClass = 60
+
+func (id methodID) String() string {
+	return fmt.Sprintf("%d.%d", id.class, id.method)
+}
+
+type frame struct {
+	typ     byte
+	channel uint16
+	payload []byte
+}
+
+func readFrame(r io.Reader, max uint32) (frame, error) {
+	var head [7]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return frame{}, err
+	}
+	size := binary.BigEndian.Uint32(head[3:])
+	if max > 0 && size > max {
+		return frame{}, errMalformedFrame
+	}
+	payload := make([]byte, size+1)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return frame{}, err
+	}
+	if payload[size] != frameEnd {
+		return frame{}, errMalformedFrame
+	}
+	return frame{typ: head[0], channel: binary.BigEndian.Uint16(head[1:]), payload: payload[:size]}, nil
+}
+
+func writeFrame(w io.Writer, f frame) error {
+	buf := make([]byte, 7, 8+len(f.payload))
+	buf[0] = f.typ
+	binary.BigEndian.PutUint16(buf[1:], f.channel)
+	binary.BigEndian.PutUint32(buf[3:], uint32(len(f.payload)))
+	buf = append(buf, f.payload...)
+	buf = append(buf, frameEnd)
+	_, err := w.Write(buf)
+	return err
+}
+
+// amqpWriter encodes AMQP fields.
+type amqpWriter struct {
+	bytes.Buffer
+}
+
+func newMethod(id methodID) *amqpWriter {
+	w := &amqpWriter{}
+	w.short(id.class)
+	w.short(id.method)
+	return w
+}
+
+func (w *amqpWriter) octet(v byte)      { w.WriteByte(v) }
+func (w *amqpWriter) short(v uint16)    { binary.Write(w, binary.BigEndian, v) }
+func (w *amqpWriter) long(v uint32)     { binary.Write(w, binary.BigEndian, v) }
+func (w *amqpWriter) longlong(v uint64) { binary.Write(w, binary.BigEndian, v) }
+
+func (w *amqpWriter) shortstr(s string) {
+	if len(s) > math.MaxUint8 {
+		s = s[:math.MaxUint8]
+	}
+	w.octet(byte(len(s)))
+	w.WriteString(s)
+}
+
+func (w *amqpWriter) longstr(s string) {
+	w.long(uint32(len(s)))
+	w.WriteString(s)
+}
+
+// bits packs consecutive bit fields into an octet.
+func (w *amqpWriter) bits(bits ...bool) {
+	var b byte
+	for i, set := range bits {
+		if set {
+			b |= 1 << uint(i)
+		}
+	}
+	w.octet(b)
+}
+
+// table encodes a field table of string values.
+func (w *amqpWriter) table(t map[string]string) {
+	var fields amqpWriter
+	for k, v := range t {
+		fields.shortstr(k)
+		fields.octet('S')
+		fields.longstr(v)
+	}
+	w.long(uint32(fields.Len()))
+	w.Write(fields.Bytes())
+}
+
+// amqpReader decodes AMQP fields. The first error is kept in err and later reads
+// return zero values.
+type amqpReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func newReader(b []byte) *amqpReader {
+	return &amqpReader{r: bytes.NewReader(b)}
+}
+
+func (r *amqpReader) read(v interface{}) {
+	if r.err == nil {
+		r.err = binary.Read(r.r, binary.BigEndian, v)
+	}
+}
+
+func (r *amqpReader) octet() (v byte)      { r.read(&v); return }
+func (r *amqpReader) short() (v uint16)    { r.read(&v); return }
+func (r *amqpReader) long() (v uint32)     { r.read(&v); return }
+func (r *amqpReader) longlong() (v uint64) { r.read(&v); return }
+
+func (r *amqpReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > r.r.Len() {
+		r.err = errMalformedFrame
+		return nil
+	}
+	b := make([]byte, n)
+	io.ReadFull(r.r, b)
+	return b
+}
+
+func (r *amqpReader) shortstr() string { return string(r.bytes(int(r.octet()))) }
+func (r *amqpReader) longstr() string  { return string(r.bytes(int(r.long()))) }
+
+func (r *amqpReader) method() methodID {
+	return methodID{r.short(), r.short()}
+}
+
+// table decodes a field table. Values are converted to strings; nested tables, arrays
+// and other values without a string form are left out.
+func (r *amqpReader) table() map[string]string {
+	fields := newReader(r.bytes(int(r.long())))
+	t := map[string]string{}
+	for fields.err == nil && fields.r.Len() > 0 {
+		k := fields.shortstr()
+		if v, ok := fields.value(); ok {
+			t[k] = v
+		}
+	}
+	if r.err == nil {
+		r.err = fields.err
+	}
+	return t
+}
+
+func (r *amqpReader) value() (string, bool) {
+	switch kind := r.octet(); kind {
+	case 't':
+		return strconv.FormatBool(r.octet() != 0), true
+	case 'b':
+		return strconv.Itoa(int(int8(r.octet()))), true
+	case 'B':
+		return strconv.Itoa(int(r.octet())), true
+	case 's':
+		return strconv.Itoa(int(int16(r.short()))), true
+	case 'u':
+		return strconv.Itoa(int(r.short())), true
+	case 'I':
+		return strconv.FormatInt(int64(int32(r.long())), 10), true
+	case 'i':
+		return strconv.FormatUint(uint64(r.long()), 10), true
+	case 'l':
+		return strconv.FormatInt(int64(r.longlong()), 10), true
+	case 'T':
+		return strconv.FormatUint(r.longlong(), 10), true
+	case 'f':
+		return strconv.FormatFloat(float64(math.Float32frombits(r.long())), 'g', -1, 32), true
+	case 'd':
+		return strconv.FormatFloat(math.Float64frombits(r.longlong()), 'g', -1, 64), true
+	case 'D':
+		r.bytes(5)
+	case 'S', 'x':
+		return r.longstr(), kind == 'S'
+	case 'A':
+		r.bytes(int(r.long()))
+	case 'F':
+		r.table()
+	case 'V':
+	default:
+		r.err = errMalformedFrame
+	}
+	return "", false
+}
+
+// Property flags of the basic class used by the fake broker.
+const (
+	propContentType   = 1 << 15
+	propHeaders       = 1 << 13
+	propDeliveryMode  = 1 << 12
+	propCorrelationID = 1 << 10
+	propReplyTo       = 1 << 9
+)
+
+// encodeHeader returns the content header of a persistent JSON message m.
+func encodeHeader(m Message) []byte {
+	var w amqpWriter
+	w.short(basicClass)
+	w.short(0) // weight
+	w.longlong(uint64(len(m.Body)))
+	flags := uint16(propContentType | propDeliveryMode)
+	if len(m.Headers) > 0 {
+		flags |= propHeaders
+	}
+	if m.CorrelationID != "" {
+		flags |= propCorrelationID
+	}
+	if m.ReplyTo != "" {
+		flags |= propReplyTo
+	}
+	w.short(flags)
+	w.shortstr("application/json")
+	if flags&propHeaders != 0 {
+		w.table(m.Headers)
+	}
+	w.octet(2) // persistent
+	if flags&propCorrelationID != 0 {
+		w.shortstr(m.CorrelationID)
+	}
+	if flags&propReplyTo != 0 {
+		w.shortstr(m.ReplyTo)
+	}
+	return w.Bytes()
+}
+
+// decodeHeader returns the body size announced by a content header and the message
+// properties it carries, along with its message ID.
+func decodeHeader(payload []byte) (size uint64, m Message, messageID string, err error) {
+	r := newReader(payload)
+	r.short() // class
+	r.short() // weight
+	size = r.longlong()
+	flags := r.short()
+	// properties appear in flag order, from bit 15 down
+	for bit := uint(15); bit >= 2 && r.err == nil; bit-- {
+		if flags&(1<<bit) == 0 {
+			continue
+		}
+		switch bit {
+		case 13:
+			m.Headers = r.table()
+		case 12, 11: // delivery mode, priority
+			r.octet()
+		case 10:
+			m.CorrelationID = r.shortstr()
+		case 9:
+			m.ReplyTo = r.shortstr()
+		case 7:
+			messageID = r.shortstr()
+		case 6: // timestamp
+			r.longlong()
+		default:
+			r.shortstr()
+		}
+	}
+	return size, m, messageID, r.err
+}
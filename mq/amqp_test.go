@@ -0,0 +1,365 @@
+package mq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAMQP is a minimal AMQP broker with a single channel per connection, speaking
+// the protocol with the codec of amqp_frame_test.go. Messages
+// published to a queue are delivered to its consumer, or kept until one subscribes;
+// nacked messages are redelivered when requeued and recorded as rejected otherwise.
+type fakeAMQP struct {
+	lis      net.Listener
+	frameMax uint32
+
+	mu       sync.Mutex
+	user     string
+	vhost    string
+	queues   map[string][]fakeMessage
+	acked    []string
+	rejected []string
+	conns    []net.Conn
+}
+
+type fakeMessage struct {
+	Message
+	redelivered bool
+}
+
+func newFakeAMQP(t *testing.T, frameMax uint32) *fakeAMQP {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeAMQP{lis: lis, frameMax: frameMax, queues: map[string][]fakeMessage{}}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.conns = append(f.conns, conn)
+			f.mu.Unlock()
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeAMQP) url() string {
+	return "amqp://alice:secret@" + f.lis.Addr().String() + "/test"
+}
+
+// closeConnections closes every connection the way a broker shutting down does.
+func (f *fakeAMQP) closeConnections() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, conn := range f.conns {
+		w := newMethod(connectionClose)
+		w.short(320)
+		w.shortstr("CONNECTION_FORCED - broker forced connection closure")
+		w.short(0)
+		w.short(0)
+		writeFrame(conn, frame{typ: frameMethod, payload: w.Bytes()})
+	}
+}
+
+func (f *fakeAMQP) serve(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	header := make([]byte, len(amqpProtocolHeader))
+	if _, err := io.ReadFull(rd, header); err != nil || !bytes.Equal(header, amqpProtocolHeader) {
+		return
+	}
+
+	var wmu sync.Mutex
+	send := func(channel uint16, typ byte, payload []byte) {
+		wmu.Lock()
+		defer wmu.Unlock()
+		writeFrame(conn, frame{typ: typ, channel: channel, payload: payload})
+	}
+	start := newMethod(connectionStart)
+	start.octet(0)
+	start.octet(9)
+	start.table(map[string]string{"product": "fake"})
+	start.longstr("PLAIN")
+	start.longstr("en_US")
+	send(0, frameMethod, start.Bytes())
+
+	var (
+		consumers   = map[string]string{} // queue to consumer tag
+		unacked     = map[uint64]fakeMessage{}
+		queues      = map[uint64]string{}
+		deliveryTag uint64
+		publishing  *fakeMessage
+		routingKey  string
+		size        uint64
+	)
+	deliver := func(queue string) {
+		tag, ok := consumers[queue]
+		if !ok {
+			return
+		}
+		f.mu.Lock()
+		pending := f.queues[queue]
+		f.queues[queue] = nil
+		f.mu.Unlock()
+		for _, m := range pending {
+			deliveryTag++
+			unacked[deliveryTag] = m
+			queues[deliveryTag] = queue
+			w := newMethod(basicDeliver)
+			w.shortstr(tag)
+			w.longlong(deliveryTag)
+			w.bits(m.redelivered)
+			w.shortstr("")
+			w.shortstr(queue)
+			send(1, frameMethod, w.Bytes())
+			send(1, frameHeader, encodeHeader(m.Message))
+			for body := m.Body; len(body) > 0; {
+				n := len(body)
+				if n > int(f.frameMax)-8 {
+					n = int(f.frameMax) - 8
+				}
+				send(1, frameBody, body[:n])
+				body = body[n:]
+			}
+		}
+	}
+	enqueue := func(queue string, m fakeMessage) {
+		f.mu.Lock()
+		f.queues[queue] = append(f.queues[queue], m)
+		f.mu.Unlock()
+		deliver(queue)
+	}
+
+	for {
+		fr, err := readFrame(rd, 0)
+		if err != nil {
+			return
+		}
+		switch fr.typ {
+		case frameHeader:
+			size, publishing.Message, _, _ = decodeHeader(fr.payload)
+		case frameBody:
+			publishing.Body = append(publishing.Body, fr.payload...)
+		}
+		if publishing != nil && fr.typ != frameMethod {
+			if uint64(len(publishing.Body)) >= size {
+				m := *publishing
+				publishing = nil
+				enqueue(routingKey, m)
+			}
+			continue
+		}
+
+		r := newReader(fr.payload)
+		switch r.method() {
+		case connectionStartOk:
+			r.table()
+			r.shortstr()
+			response := strings.Split(r.longstr(), "\x00")
+			f.mu.Lock()
+			f.user = response[1]
+			f.mu.Unlock()
+			tune := newMethod(connectionTune)
+			tune.short(1)
+			tune.long(f.frameMax)
+			tune.short(0)
+			send(0, frameMethod, tune.Bytes())
+		case connectionTuneOk:
+		case connectionOpen:
+			f.mu.Lock()
+			f.vhost = r.shortstr()
+			f.mu.Unlock()
+			w := newMethod(connectionOpenOk)
+			w.shortstr("")
+			send(0, frameMethod, w.Bytes())
+		case channelOpen:
+			w := newMethod(channelOpenOk)
+			w.longstr("")
+			send(1, frameMethod, w.Bytes())
+		case queueDeclare:
+			r.short()
+			w := newMethod(queueDeclareOk)
+			w.shortstr(r.shortstr())
+			w.long(0)
+			w.long(0)
+			send(1, frameMethod, w.Bytes())
+		case basicQos:
+			send(1, frameMethod, newMethod(basicQosOk).Bytes())
+		case basicConsume:
+			r.short()
+			queue, tag := r.shortstr(), r.shortstr()
+			consumers[queue] = tag
+			w := newMethod(basicConsumeOk)
+			w.shortstr(tag)
+			send(1, frameMethod, w.Bytes())
+			deliver(queue)
+		case basicCancel:
+			tag := r.shortstr()
+			noWait := r.octet()&1 != 0
+			for queue, t := range consumers {
+				if t == tag {
+					delete(consumers, queue)
+				}
+			}
+			if !noWait {
+				w := newMethod(basicCancelOk)
+				w.shortstr(tag)
+				send(1, frameMethod, w.Bytes())
+			}
+		case basicPublish:
+			r.short()
+			r.shortstr()
+			routingKey = r.shortstr()
+			publishing = &fakeMessage{}
+		case basicAck:
+			tag := r.longlong()
+			f.mu.Lock()
+			f.acked = append(f.acked, string(unacked[tag].Body))
+			f.mu.Unlock()
+			delete(unacked, tag)
+		case basicNack:
+			tag := r.longlong()
+			requeue := r.octet()&2 != 0
+			m := unacked[tag]
+			delete(unacked, tag)
+			if requeue {
+				m.redelivered = true
+				enqueue(queues[tag], m)
+				continue
+			}
+			f.mu.Lock()
+			f.rejected = append(f.rejected, string(m.Body))
+			f.mu.Unlock()
+		case connectionClose:
+			send(0, frameMethod, newMethod(connectionCloseOk).Bytes())
+			return
+		case connectionCloseOk:
+			return
+		}
+	}
+}
+
+func Test_AMQP(t *testing.T) {
+	f := newFakeAMQP(t, 4096)
+	defer f.lis.Close()
+
+	c, err := DialAMQP(f.url(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	f.mu.Lock()
+	assert.Equal(t, "alice", f.user)
+	assert.Equal(t, "test", f.vhost)
+	f.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// bodies larger than the negotiated frame size are split across body frames
+	large := bytes.Repeat([]byte("a"), 10000)
+	m := Message{Body: large, CorrelationID: "c1", ReplyTo: "replies", Headers: map[string]string{"x-caller-id": "alice"}}
+	assert.Nil(t, c.Publish(ctx, "requests", m))
+	assert.Nil(t, c.Publish(ctx, "requests", Message{Body: []byte("small")}))
+
+	deliveries, err := c.Subscribe(ctx, "requests", 2)
+	assert.Nil(t, err)
+	d := receive(t, deliveries)
+	assert.Equal(t, m, d.Message)
+	assert.Nil(t, d.Ack())
+
+	// a requeued message comes back
+	d = receive(t, deliveries)
+	assert.Equal(t, "small", string(d.Body))
+	assert.Nil(t, d.Nack(true))
+	d = receive(t, deliveries)
+	assert.Equal(t, "small", string(d.Body))
+	assert.Nil(t, d.Nack(false))
+
+	time.Sleep(10 * time.Millisecond)
+	f.mu.Lock()
+	assert.Equal(t, []string{string(large)}, f.acked)
+	assert.Equal(t, []string{"small"}, f.rejected)
+	f.mu.Unlock()
+
+	cancel()
+	_, ok := <-deliveries
+	assert.False(t, ok)
+}
+
+func Test_AMQPClosedByBroker(t *testing.T) {
+	f := newFakeAMQP(t, 4096)
+	defer f.lis.Close()
+
+	c, err := DialAMQP(f.url(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	deliveries, err := c.Subscribe(context.Background(), "requests", 1)
+	assert.Nil(t, err)
+
+	f.closeConnections()
+	select {
+	case _, ok := <-deliveries:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("subscription still open")
+	}
+	err = c.Publish(context.Background(), "requests", Message{})
+	assert.Equal(t, amqp.ErrClosed, err)
+	_, err = c.Subscribe(context.Background(), "requests", 1)
+	assert.NotNil(t, err)
+}
+
+func Test_AMQPServer(t *testing.T) {
+	f := newFakeAMQP(t, 4096)
+	defer f.lis.Close()
+
+	c, err := DialAMQP(f.url(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	s := &Server{Greeter: &scriptedGreeter{}, Subscriber: c, Publisher: c, Queue: "requests", Logger: log.New(ioutil.Discard, "", 0)}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() { served <- s.Serve(ctx) }()
+
+	replies, err := c.Subscribe(ctx, "replies", 1)
+	assert.Nil(t, err)
+	assert.Nil(t, c.Publish(ctx, "requests", Message{Body: []byte(`{"s":"Ann"}`), CorrelationID: "c1", ReplyTo: "replies"}))
+	d := receive(t, replies)
+	assert.Equal(t, "c1", d.CorrelationID)
+	assert.Equal(t, `{"greeting":"Ann"}`, strings.TrimSpace(string(d.Body)))
+	assert.Nil(t, d.Ack())
+
+	cancel()
+	assert.Nil(t, <-served)
+}
+
+func Test_DialAMQP(t *testing.T) {
+	for _, rawurl := range []string{"http://localhost", "::"} {
+		t.Logf("Running test case: %s", rawurl)
+		_, err := DialAMQP(rawurl, time.Second)
+		assert.EqualError(t, err, fmt.Sprintf("mq: invalid AMQP URL %q", rawurl))
+	}
+}
@@ -0,0 +1,157 @@
+package mq
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory is an in-process broker. Queues are created on first use; every message is
+// delivered to one subscriber of its queue. Rejected messages are kept and returned
+// by Dead.
+type Memory struct {
+	mu     sync.Mutex
+	queues map[string]*memoryQueue
+	closed bool
+	done   chan struct{}
+}
+
+type memoryQueue struct {
+	ready   []memoryMessage
+	dead    []Message
+	changed chan struct{}
+}
+
+// memoryMessage is a message ready to be delivered; redelivered is set once it was
+// given back to the queue.
+type memoryMessage struct {
+	Message
+	redelivered bool
+}
+
+func NewMemory() *Memory {
+	return &Memory{queues: map[string]*memoryQueue{}, done: make(chan struct{})}
+}
+
+// queue returns the queue named name. b.mu must be held.
+func (b *Memory) queue(name string) *memoryQueue {
+	q, ok := b.queues[name]
+	if !ok {
+		q = &memoryQueue{changed: make(chan struct{})}
+		b.queues[name] = q
+	}
+	return q
+}
+
+// notify wakes the subscribers waiting on q. b.mu must be held.
+func (q *memoryQueue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+func (b *Memory) Publish(ctx context.Context, queue string, m Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	m.Body = append([]byte(nil), m.Body...)
+	q := b.queue(queue)
+	q.ready = append(q.ready, memoryMessage{Message: m})
+	q.notify()
+	return nil
+}
+
+func (b *Memory) Subscribe(ctx context.Context, queue string, prefetch int) (<-chan Delivery, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	if prefetch <= 0 {
+		prefetch = 1
+	}
+	out := make(chan Delivery)
+	go b.consume(ctx, b.queue(queue), prefetch, out)
+	return out, nil
+}
+
+func (b *Memory) consume(ctx context.Context, q *memoryQueue, prefetch int, out chan<- Delivery) {
+	defer close(out)
+	unacked := 0
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return
+		}
+		if unacked >= prefetch || len(q.ready) == 0 {
+			changed := q.changed
+			b.mu.Unlock()
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			case <-b.done:
+				return
+			}
+			continue
+		}
+		m := q.ready[0]
+		q.ready = q.ready[1:]
+		unacked++
+		b.mu.Unlock()
+
+		var once sync.Once
+		settle := func(requeue, reject bool) error {
+			once.Do(func() {
+				b.mu.Lock()
+				defer b.mu.Unlock()
+				unacked--
+				switch {
+				case requeue:
+					q.ready = append([]memoryMessage{{Message: m.Message, redelivered: true}}, q.ready...)
+				case reject:
+					q.dead = append(q.dead, m.Message)
+				}
+				q.notify()
+			})
+			return nil
+		}
+		d := NewDelivery(m.Message, m.redelivered,
+			func() error { return settle(false, false) },
+			func(requeue bool) error { return settle(requeue, !requeue) })
+		select {
+		case out <- d:
+		case <-ctx.Done():
+			settle(true, false)
+			return
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// Len returns the number of messages waiting in queue.
+func (b *Memory) Len(queue string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.queue(queue).ready)
+}
+
+// Dead returns the messages rejected from queue.
+func (b *Memory) Dead(queue string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Message(nil), b.queue(queue).dead...)
+}
+
+// Close ends all subscriptions.
+func (b *Memory) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Package mq drives a svc.Greeter from a message queue. GreetRequest messages are
+// consumed from a queue, answered on their reply-to topic and acknowledged once
+// handled; messages that cannot be handled are dead-lettered.
+package mq
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrClosed = errors.New("mq: connection is closed")
+
+// Message is a message on a queue. Headers carry the caller (x-caller-id,
+// x-caller-roles, x-caller-scopes) and accept-language, like the gRPC metadata.
+type Message struct {
+	Body          []byte
+	CorrelationID string
+	ReplyTo       string
+	Headers       map[string]string
+}
+
+// Delivery is a message received from a queue. It must be acknowledged with Ack, or
+// given back with Nack, exactly once.
+type Delivery struct {
+	Message
+	// Redelivered is set when the broker delivered the message before, to a consumer
+	// that did not acknowledge it.
+	Redelivered bool
+
+	ack  func() error
+	nack func(requeue bool) error
+}
+
+// NewDelivery returns a delivery of m acknowledged with ack and nack; redelivered is
+// set when the broker delivered m before.
+func NewDelivery(m Message, redelivered bool, ack func() error, nack func(requeue bool) error) Delivery {
+	return Delivery{Message: m, Redelivered: redelivered, ack: ack, nack: nack}
+}
+
+// Ack removes the message from the queue.
+func (d Delivery) Ack() error {
+	return d.ack()
+}
+
+// Nack returns the message to the queue to be delivered again when requeue is set, and
+// otherwise rejects it, leaving it to the broker's dead-lettering.
+func (d Delivery) Nack(requeue bool) error {
+	return d.nack(requeue)
+}
+
+// Subscriber consumes messages from the queues of a broker.
+type Subscriber interface {
+	// Subscribe delivers the messages of queue, at most prefetch of them
+	// unacknowledged at a time. The channel is closed once ctx is done or the
+	// subscription failed.
+	Subscribe(ctx context.Context, queue string, prefetch int) (<-chan Delivery, error)
+	Close() error
+}
+
+// Publisher sends messages to the queues of a broker.
+type Publisher interface {
+	Publish(ctx context.Context, queue string, m Message) error
+}
@@ -0,0 +1,365 @@
+package mq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tkeech1/gowebsvc/middleware"
+	"github.com/tkeech1/gowebsvc/retry"
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+func receive(t *testing.T, deliveries <-chan Delivery) Delivery {
+	select {
+	case d, ok := <-deliveries:
+		if !ok {
+			t.Fatal("subscription ended")
+		}
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("no delivery")
+	}
+	return Delivery{}
+}
+
+func Test_Memory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := NewMemory()
+
+	body := []byte("one")
+	assert.Nil(t, b.Publish(ctx, "q", Message{Body: body, CorrelationID: "1"}))
+	body[0] = 'x' // the broker keeps its own copy
+	assert.Nil(t, b.Publish(ctx, "q", Message{Body: []byte("two"), CorrelationID: "2"}))
+	assert.Equal(t, 2, b.Len("q"))
+
+	deliveries, err := b.Subscribe(ctx, "q", 1)
+	assert.Nil(t, err)
+	d := receive(t, deliveries)
+	assert.Equal(t, "one", string(d.Body))
+	assert.False(t, d.Redelivered)
+
+	// the prefetch count holds back the second message until the first is settled
+	select {
+	case <-deliveries:
+		t.Fatal("delivered beyond the prefetch count")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	assert.Nil(t, d.Nack(true))
+	d = receive(t, deliveries)
+	assert.Equal(t, "one", string(d.Body))
+	assert.True(t, d.Redelivered)
+	assert.Nil(t, d.Ack())
+
+	d = receive(t, deliveries)
+	assert.Equal(t, "2", d.CorrelationID)
+	assert.Nil(t, d.Nack(false))
+	assert.Equal(t, []Message{{Body: []byte("two"), CorrelationID: "2"}}, b.Dead("q"))
+	assert.Equal(t, 0, b.Len("q"))
+
+	cancel()
+	_, ok := <-deliveries
+	assert.False(t, ok)
+
+	assert.Nil(t, b.Close())
+	assert.Equal(t, ErrClosed, b.Publish(context.Background(), "q", Message{}))
+	_, err = b.Subscribe(context.Background(), "q", 1)
+	assert.Equal(t, ErrClosed, err)
+}
+
+// scriptedGreeter fails the first failures calls to Greet with err, panics when the
+// name is "panic", and otherwise greets. It records the caller of each call.
+type scriptedGreeter struct {
+	mu       sync.Mutex
+	err      error
+	failures int
+	calls    int
+	callers  []middleware.Caller
+}
+
+func (g *scriptedGreeter) Greet(ctx context.Context, s string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.calls++
+	caller, _ := middleware.CallerFromContext(ctx)
+	g.callers = append(g.callers, caller)
+	if s == "panic" {
+		panic("boom")
+	}
+	if g.calls <= g.failures {
+		return "", g.err
+	}
+	if s == "" {
+		return "", service.ErrEmptyGreeting
+	}
+	return service.GreetingService{}.Greet(ctx, s)
+}
+
+func (g *scriptedGreeter) count() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.calls
+}
+
+func (g *scriptedGreeter) Expensive(ctx context.Context, connectionString, username, password string) (string, error) {
+	return "", nil
+}
+
+func request(t *testing.T, r service.GreetRequest) []byte {
+	body, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func Test_Server(t *testing.T) {
+	type test struct {
+		greeter   *scriptedGreeter
+		message   Message
+		reply     *service.GreetResponse
+		attempts  int
+		deadError string
+		// deadAttempt is the AttemptHeader of the dead-lettered message
+		deadAttempt string
+	}
+	tests := map[string]test{
+		"greeting": {
+			greeter: &scriptedGreeter{},
+			message: Message{
+				Body:          request(t, service.GreetRequest{S: "Ann", Locale: "fr-FR"}),
+				CorrelationID: "c1",
+				ReplyTo:       "replies",
+				Headers:       map[string]string{"x-caller-id": "alice", "x-caller-roles": "admin"},
+			},
+			reply:    &service.GreetResponse{V: "Salut, Ann !", Locale: "fr"},
+			attempts: 1,
+		},
+		"accept-language": {
+			greeter: &scriptedGreeter{},
+			message: Message{
+				Body:          request(t, service.GreetRequest{S: "Ann"}),
+				CorrelationID: "c2",
+				ReplyTo:       "replies",
+				Headers:       map[string]string{"accept-language": "es"},
+			},
+			reply:    &service.GreetResponse{V: "¡Hola, Ann!", Locale: "es"},
+			attempts: 1,
+		},
+		"domain error is replied": {
+			greeter:  &scriptedGreeter{},
+			message:  Message{Body: request(t, service.GreetRequest{}), CorrelationID: "c3", ReplyTo: "replies"},
+			reply:    &service.GreetResponse{Err: service.ErrEmptyGreeting.Error()},
+			attempts: 1,
+		},
+		"invalid options are replied": {
+			greeter:  &scriptedGreeter{},
+			message:  Message{Body: request(t, service.GreetRequest{S: "Ann", Formality: "rude"}), CorrelationID: "c4", ReplyTo: "replies"},
+			reply:    &service.GreetResponse{Err: `unknown formality "rude"`},
+			attempts: 0,
+		},
+		"transient error is redelivered": {
+			greeter:  &scriptedGreeter{err: service.ErrRequestTimedOut, failures: 2},
+			message:  Message{Body: request(t, service.GreetRequest{S: "Ann"}), CorrelationID: "c5", ReplyTo: "replies"},
+			reply:    &service.GreetResponse{V: "Ann"},
+			attempts: 3,
+		},
+		"no reply-to": {
+			greeter:  &scriptedGreeter{},
+			message:  Message{Body: request(t, service.GreetRequest{S: "Ann"})},
+			attempts: 1,
+		},
+		"undecodable message is poison": {
+			greeter:   &scriptedGreeter{},
+			message:   Message{Body: []byte("{"), CorrelationID: "c6", ReplyTo: "replies"},
+			attempts:  0,
+			deadError: "poison message: unexpected end of JSON input",
+		},
+		"failing message is poison after max deliveries": {
+			greeter:     &scriptedGreeter{err: errors.New("database is down"), failures: 10},
+			message:     Message{Body: request(t, service.GreetRequest{S: "Ann"}), CorrelationID: "c7", ReplyTo: "replies"},
+			attempts:    3,
+			deadError:   "database is down",
+			deadAttempt: "3",
+		},
+		"retried message is poison on its last attempt": {
+			greeter: &scriptedGreeter{err: errors.New("database is down"), failures: 10},
+			message: Message{
+				Body:          request(t, service.GreetRequest{S: "Ann"}),
+				CorrelationID: "c9",
+				ReplyTo:       "replies",
+				Headers:       map[string]string{AttemptHeader: "3"},
+			},
+			attempts:    1,
+			deadError:   "database is down",
+			deadAttempt: "3",
+		},
+		"panic is poison after max deliveries": {
+			greeter:     &scriptedGreeter{},
+			message:     Message{Body: request(t, service.GreetRequest{S: "panic"}), CorrelationID: "c8", ReplyTo: "replies"},
+			attempts:    3,
+			deadError:   "panic: boom",
+			deadAttempt: "3",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		b := NewMemory()
+		s := &Server{
+			Greeter:         test.greeter,
+			Subscriber:      b,
+			Publisher:       b,
+			Queue:           "requests",
+			DeadLetterQueue: "dead",
+			MaxDeliveries:   3,
+			Backoff:         retry.Policy{InitialBackoff: time.Millisecond},
+			Concurrency:     2,
+			Logger:          log.New(ioutil.Discard, "", 0),
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		served := make(chan error)
+		go func() { served <- s.Serve(ctx) }()
+
+		replies, _ := b.Subscribe(ctx, "replies", 1)
+		dead, _ := b.Subscribe(ctx, "dead", 1)
+		assert.Nil(t, b.Publish(ctx, "requests", test.message))
+
+		if test.reply != nil {
+			d := receive(t, replies)
+			var response service.GreetResponse
+			assert.Nil(t, json.Unmarshal(d.Body, &response))
+			assert.Equal(t, *test.reply, response)
+			assert.Equal(t, test.message.CorrelationID, d.CorrelationID)
+			d.Ack()
+		}
+		if test.deadError != "" {
+			d := receive(t, dead)
+			assert.Equal(t, test.message.Body, d.Body)
+			assert.Equal(t, test.deadError, d.Headers[ErrorHeader])
+			assert.Equal(t, "requests", d.Headers[OriginalQueueHeader])
+			assert.Equal(t, test.deadAttempt, d.Headers[AttemptHeader])
+			d.Ack()
+		}
+
+		// messages without a reply are only seen through the greeter
+		for i := 0; i < 100 && test.greeter.count() < test.attempts; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+		assert.Nil(t, <-served)
+		assert.Equal(t, 0, b.Len("requests"))
+		test.greeter.mu.Lock()
+		assert.Equal(t, test.attempts, test.greeter.calls)
+		if name == "greeting" {
+			assert.Equal(t, []middleware.Caller{{ID: "alice", Roles: []string{"admin"}}}, test.greeter.callers)
+		}
+		test.greeter.mu.Unlock()
+	}
+}
+
+func Test_ServerBacksOff(t *testing.T) {
+	b := NewMemory()
+	greeter := &scriptedGreeter{err: service.ErrRequestTimedOut, failures: 2}
+	s := &Server{
+		Greeter:    greeter,
+		Subscriber: b,
+		Publisher:  b,
+		Queue:      "requests",
+		Backoff:    retry.Policy{InitialBackoff: 50 * time.Millisecond, Multiplier: 2},
+		Logger:     log.New(ioutil.Discard, "", 0),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() { served <- s.Serve(ctx) }()
+	replies, _ := b.Subscribe(ctx, "replies", 1)
+
+	start := time.Now()
+	assert.Nil(t, b.Publish(ctx, "requests", Message{Body: request(t, service.GreetRequest{S: "Ann"}), ReplyTo: "replies"}))
+	receive(t, replies).Ack()
+	// 50ms after the first attempt, 100ms after the second
+	assert.True(t, time.Since(start) >= 150*time.Millisecond, "retried after %v", time.Since(start))
+	assert.Equal(t, 3, greeter.count())
+
+	cancel()
+	assert.Nil(t, <-served)
+}
+
+func Test_ServerCountsRedeliveries(t *testing.T) {
+	b := NewMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// a consumer gives the message on its second attempt back, as a crash would
+	assert.Nil(t, b.Publish(ctx, "requests", Message{
+		Body:    request(t, service.GreetRequest{S: "Ann"}),
+		Headers: map[string]string{AttemptHeader: "2"},
+	}))
+	crashed, cancelCrashed := context.WithCancel(ctx)
+	deliveries, _ := b.Subscribe(crashed, "requests", 1)
+	assert.Nil(t, receive(t, deliveries).Nack(true))
+	cancelCrashed()
+
+	greeter := &scriptedGreeter{err: errors.New("database is down"), failures: 10}
+	s := &Server{
+		Greeter:         greeter,
+		Subscriber:      b,
+		Publisher:       b,
+		Queue:           "requests",
+		DeadLetterQueue: "dead",
+		MaxDeliveries:   3,
+		Logger:          log.New(ioutil.Discard, "", 0),
+	}
+	served := make(chan error)
+	go func() { served <- s.Serve(ctx) }()
+	dead, _ := b.Subscribe(ctx, "dead", 1)
+
+	// the redelivery is the third attempt, the last
+	d := receive(t, dead)
+	assert.Equal(t, "database is down", d.Headers[ErrorHeader])
+	d.Ack()
+	assert.Equal(t, 1, greeter.count())
+
+	cancel()
+	assert.Nil(t, <-served)
+}
+
+func Test_ServerRejectsWithoutDeadLetterQueue(t *testing.T) {
+	b := NewMemory()
+	s := &Server{
+		Greeter:    &scriptedGreeter{},
+		Subscriber: b,
+		Publisher:  b,
+		Queue:      "requests",
+		Logger:     log.New(ioutil.Discard, "", 0),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() { served <- s.Serve(ctx) }()
+
+	assert.Nil(t, b.Publish(ctx, "requests", Message{Body: []byte("not json")}))
+	for i := 0; i < 100 && len(b.Dead("requests")) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, []Message{{Body: []byte("not json")}}, b.Dead("requests"))
+
+	cancel()
+	assert.Nil(t, <-served)
+}
+
+func Test_ServerSubscriptionEnds(t *testing.T) {
+	b := NewMemory()
+	s := &Server{Greeter: &scriptedGreeter{}, Subscriber: b, Publisher: b, Queue: "requests", Logger: log.New(ioutil.Discard, "", 0)}
+	served := make(chan error)
+	go func() { served <- s.Serve(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	b.Close()
+	assert.EqualError(t, <-served, "mq: subscription to requests ended")
+}
@@ -0,0 +1,251 @@
+package mq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tkeech1/gowebsvc/middleware"
+	"github.com/tkeech1/gowebsvc/retry"
+	service "github.com/tkeech1/gowebsvc/svc"
+	"google.golang.org/grpc/codes"
+)
+
+// DefaultMaxDeliveries is the number of times a message is tried by default before it
+// is dead-lettered.
+const DefaultMaxDeliveries = 5
+
+// Headers set on dead-lettered messages.
+const (
+	ErrorHeader         = "x-error"
+	OriginalQueueHeader = "x-original-queue"
+)
+
+// AttemptHeader counts the attempts of a message, the one it is delivered for
+// included. The server sets it when it publishes a message again to be retried; a
+// message without it is on its first attempt. A message the broker redelivers, after
+// a consumer failed to settle it, counts one more attempt.
+const AttemptHeader = "x-attempt"
+
+// DefaultBackoff is the backoff between the attempts of a message by default.
+var DefaultBackoff = retry.Policy{
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// Server answers the GreetRequest messages of Queue with Greeter, which is usually the
+// same middleware chain the HTTP and gRPC transports use. A message is acknowledged
+// once its GreetResponse, carrying the greeting or the error, is published to the
+// message's reply-to queue with its correlation ID; messages without a reply-to queue
+// are answered nowhere.
+//
+// Cancellations, timeouts, unavailable dependencies, unknown errors and panics are
+// treated as transient: after the Backoff of its attempt, the message is published
+// again to the end of Queue with its AttemptHeader incremented, and the delivery
+// acknowledged. A message
+// that cannot be decoded, or that failed on its MaxDeliveries-th attempt, is poison and
+// is published to DeadLetterQueue with an x-error header, or rejected when there is no
+// dead-letter queue.
+type Server struct {
+	Greeter    service.Greeter
+	Subscriber Subscriber
+	Publisher  Publisher
+	Queue      string
+
+	DeadLetterQueue string
+	// MaxDeliveries defaults to DefaultMaxDeliveries.
+	MaxDeliveries int
+	// Backoff gives the wait before a failed message is tried again; its MaxAttempts
+	// and Retryable are unused. It defaults to DefaultBackoff.
+	Backoff retry.Policy
+	// Concurrency is the number of messages handled at a time; it defaults to 1.
+	Concurrency int
+	// Timeout bounds the handling of a message when set.
+	Timeout time.Duration
+	Logger  *log.Logger
+}
+
+// poisonError marks a message that is dead-lettered without being tried again.
+type poisonError struct {
+	err error
+}
+
+func (e poisonError) Error() string {
+	return "poison message: " + e.err.Error()
+}
+
+// Serve consumes Queue until ctx is done, then waits for the messages being handled.
+// It returns nil once ctx is done, and an error when the subscription failed.
+func (s *Server) Serve(ctx context.Context) error {
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	deliveries, err := s.Subscriber.Subscribe(ctx, s.Queue, concurrency)
+	if err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range deliveries {
+				s.handle(ctx, d)
+			}
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil
+	}
+	return fmt.Errorf("mq: subscription to %s ended", s.Queue)
+}
+
+// handle answers d. The message is handled apart from the Serve context, so a shutdown
+// lets the messages in flight finish; a shutdown only cuts the backoff of a message
+// being retried short.
+func (s *Server) handle(serving context.Context, d Delivery) {
+	ctx := context.Background()
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	response, err := s.greet(ctx, d.Message)
+	if err != nil {
+		maxDeliveries := s.MaxDeliveries
+		if maxDeliveries <= 0 {
+			maxDeliveries = DefaultMaxDeliveries
+		}
+		attempt := attempt(d)
+		// ctx may be what failed the message
+		if _, poison := err.(poisonError); !poison && attempt < maxDeliveries {
+			wait := s.backoff().Backoff(attempt)
+			s.Logger.Printf("mq: message %q failed on attempt %d, retrying in %v: %v", d.CorrelationID, attempt, wait, err)
+			select {
+			case <-time.After(wait):
+			case <-serving.Done():
+			}
+			s.retry(context.Background(), d, attempt+1)
+			return
+		}
+		s.deadLetter(context.Background(), d, attempt, err)
+		return
+	}
+
+	if d.ReplyTo != "" {
+		body, _ := json.Marshal(response)
+		reply := Message{Body: body, CorrelationID: d.CorrelationID}
+		if err := s.Publisher.Publish(ctx, d.ReplyTo, reply); err != nil {
+			s.Logger.Printf("mq: replying to message %q on %s: %v", d.CorrelationID, d.ReplyTo, err)
+			s.settle(d, d.Nack(true))
+			return
+		}
+	}
+	s.settle(d, d.Ack())
+}
+
+// greet returns the response to m. The error is set when m must be tried again or is
+// poison; errors reported to the caller are in the response.
+func (s *Server) greet(ctx context.Context, m Message) (response service.GreetResponse, err error) {
+	var request service.GreetRequest
+	if err := json.Unmarshal(m.Body, &request); err != nil {
+		return response, poisonError{err}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	ctx = middleware.NewCallerContext(ctx, middleware.CallerFromHeaders(m.Headers))
+	if accept := m.Headers["accept-language"]; accept != "" {
+		ctx = service.NewAcceptLanguageContext(ctx, accept)
+	}
+	ctx, err = service.NewRequestOptionsContext(ctx, request.Locale, request.Formality, request.Count, request.Template)
+	if err != nil {
+		return service.GreetResponse{Err: err.Error()}, nil
+	}
+	greeting, err := s.Greeter.Greet(ctx, request.S)
+	if err != nil {
+		switch service.Code(err) {
+		case codes.Canceled, codes.DeadlineExceeded, codes.Unavailable, codes.Unknown:
+			return response, err
+		}
+		return service.GreetResponse{Err: err.Error()}, nil
+	}
+	return service.GreetResponse{V: greeting, Locale: service.ResolveLocale(ctx)}, nil
+}
+
+// attempt returns the attempt d is delivered for, from its AttemptHeader and whether
+// the broker redelivered it.
+func attempt(d Delivery) int {
+	n, err := strconv.Atoi(d.Headers[AttemptHeader])
+	if err != nil || n < 1 {
+		n = 1
+	}
+	if d.Redelivered {
+		n++
+	}
+	return n
+}
+
+func (s *Server) backoff() retry.Policy {
+	if s.Backoff.InitialBackoff <= 0 {
+		return DefaultBackoff
+	}
+	return s.Backoff
+}
+
+// retry publishes d again to Queue as its attempt-th attempt. The delivery is given
+// back to the broker when that fails.
+func (s *Server) retry(ctx context.Context, d Delivery, attempt int) {
+	headers := map[string]string{AttemptHeader: strconv.Itoa(attempt)}
+	for k, v := range d.Headers {
+		if _, ok := headers[k]; !ok {
+			headers[k] = v
+		}
+	}
+	m := Message{Body: d.Body, CorrelationID: d.CorrelationID, ReplyTo: d.ReplyTo, Headers: headers}
+	if err := s.Publisher.Publish(ctx, s.Queue, m); err != nil {
+		s.Logger.Printf("mq: publishing to %s: %v", s.Queue, err)
+		s.settle(d, d.Nack(true))
+		return
+	}
+	s.settle(d, d.Ack())
+}
+
+func (s *Server) deadLetter(ctx context.Context, d Delivery, attempts int, cause error) {
+	s.Logger.Printf("mq: dead-lettering message %q after %d attempts: %v", d.CorrelationID, attempts, cause)
+	if s.DeadLetterQueue == "" {
+		s.settle(d, d.Nack(false))
+		return
+	}
+	headers := map[string]string{ErrorHeader: cause.Error(), OriginalQueueHeader: s.Queue}
+	for k, v := range d.Headers {
+		if _, ok := headers[k]; !ok {
+			headers[k] = v
+		}
+	}
+	m := Message{Body: d.Body, CorrelationID: d.CorrelationID, ReplyTo: d.ReplyTo, Headers: headers}
+	if err := s.Publisher.Publish(ctx, s.DeadLetterQueue, m); err != nil {
+		s.Logger.Printf("mq: publishing to %s: %v", s.DeadLetterQueue, err)
+		s.settle(d, d.Nack(true))
+		return
+	}
+	s.settle(d, d.Ack())
+}
+
+func (s *Server) settle(d Delivery, err error) {
+	if err != nil {
+		s.Logger.Printf("mq: settling message %q: %v", d.CorrelationID, err)
+	}
+}
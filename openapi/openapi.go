@@ -0,0 +1,161 @@
+// Package openapi builds OpenAPI 3 documents from route descriptions and the Go types
+// exchanged on those routes, so the served specification cannot be edited out of step
+// with the code.
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the OpenAPI version of the generated documents.
+const Version = "3.0.2"
+
+// Document is an OpenAPI document. Only the parts needed to describe this service are
+// modelled.
+type Document struct {
+	OpenAPI string              `json:"openapi"`
+	Info    Info                `json:"info"`
+	Paths   map[string]PathItem `json:"paths"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem holds the operations of a path by lower-case HTTP method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+	Deprecated  bool                `json:"deprecated,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Route describes one operation of the API.
+type Route struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	// Params are the path parameters, written as {name} in Path.
+	Params  []Parameter
+	Query   []Parameter
+	Headers []Parameter
+
+	// Request is a value of the type decoded from the JSON request body, or nil when
+	// the operation has no body.
+	Request interface{}
+
+	// Responses describes the replies by status code.
+	Responses map[int]Body
+
+	Deprecated bool
+}
+
+// Body describes a reply. Value is a value of the type encoded in it; it is ignored
+// unless ContentType is set.
+type Body struct {
+	Description string
+	ContentType string
+	Value       interface{}
+}
+
+// New returns the document describing routes.
+func New(title, version string, routes []Route) *Document {
+	d := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: version},
+		Paths:   map[string]PathItem{},
+	}
+	for _, r := range routes {
+		op := &Operation{
+			OperationID: r.OperationID,
+			Summary:     r.Summary,
+			Parameters:  append([]Parameter(nil), r.Query...),
+			Responses:   map[string]Response{},
+			Deprecated:  r.Deprecated,
+		}
+		for i := range op.Parameters {
+			op.Parameters[i].In = "query"
+		}
+		for _, p := range r.Params {
+			p.In, p.Required = "path", true
+			op.Parameters = append(op.Parameters, p)
+		}
+		for _, p := range r.Headers {
+			p.In = "header"
+			op.Parameters = append(op.Parameters, p)
+		}
+		if r.Request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: SchemaOf(r.Request)}},
+			}
+		}
+		for code, b := range r.Responses {
+			description := b.Description
+			if description == "" {
+				description = http.StatusText(code)
+			}
+			response := Response{Description: description}
+			if b.ContentType != "" {
+				response.Content = map[string]MediaType{b.ContentType: {}}
+				if b.Value != nil {
+					response.Content[b.ContentType] = MediaType{Schema: SchemaOf(b.Value)}
+				}
+			}
+			op.Responses[strconv.Itoa(code)] = response
+		}
+		if d.Paths[r.Path] == nil {
+			d.Paths[r.Path] = PathItem{}
+		}
+		d.Paths[r.Path][strings.ToLower(r.Method)] = op
+	}
+	return d
+}
+
+// Operation returns the operation for method on path, or nil.
+func (d *Document) Operation(method, path string) *Operation {
+	return d.Paths[path][strings.ToLower(method)]
+}
+
+// Routes returns the "METHOD path" pairs described by the document, sorted.
+func (d *Document) Routes() []string {
+	var routes []string
+	for path, item := range d.Paths {
+		for method := range item {
+			routes = append(routes, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(routes)
+	return routes
+}
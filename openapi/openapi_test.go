@@ -0,0 +1,123 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+func Test_SchemaOf(t *testing.T) {
+	type nested struct {
+		Count   int               `json:"count"`
+		Ratio   float64           `json:"ratio,omitempty"`
+		Labels  map[string]string `json:"labels,omitempty"`
+		Ignored string            `json:"-"`
+		Plain   bool
+		hidden  string
+	}
+
+	tests := map[string]struct {
+		value    interface{}
+		expected *Schema
+	}{
+		"greet_response": {
+			value: service.GreetResponse{},
+			expected: &Schema{Type: "object", Required: []string{"greeting"}, Properties: map[string]*Schema{
+				"greeting": {Type: "string"},
+				"err":      {Type: "string"},
+				"locale":   {Type: "string"},
+			}},
+		},
+		"batch_request": {
+			value: []service.GreetRequest{},
+			expected: &Schema{Type: "array", Items: &Schema{Type: "object", Required: []string{"s"}, Properties: map[string]*Schema{
+				"s":         {Type: "string"},
+				"locale":    {Type: "string"},
+				"formality": {Type: "string"},
+				"count":     {Type: "integer"},
+				"template":  {Type: "string"},
+			}}},
+		},
+		"nested": {
+			value: &nested{},
+			expected: &Schema{Type: "object", Required: []string{"Plain", "count"}, Properties: map[string]*Schema{
+				"count":  {Type: "integer"},
+				"ratio":  {Type: "number"},
+				"labels": {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+				"Plain":  {Type: "boolean"},
+			}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		assert.Equal(t, test.expected, SchemaOf(test.value))
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	schema := SchemaOf(service.GreetBatchResponse{})
+
+	tests := map[string]struct {
+		json  string
+		valid bool
+	}{
+		"valid":               {json: `{"results":[{"greeting":"a"},{"greeting":"","err":"empty greeting"}]}`, valid: true},
+		"missing_property":    {json: `{"results":[{"err":"x"}]}`, valid: false},
+		"undeclared_property": {json: `{"results":[],"extra":1}`, valid: false},
+		"wrong_type":          {json: `{"results":{}}`, valid: false},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		var v interface{}
+		assert.Nil(t, json.Unmarshal([]byte(test.json), &v))
+		err := schema.Validate(v)
+		assert.Equal(t, test.valid, err == nil, "%v", err)
+	}
+
+	var example interface{}
+	b, _ := json.Marshal(schema.Example())
+	assert.Nil(t, json.Unmarshal(b, &example))
+	assert.Nil(t, schema.Validate(example))
+}
+
+func Test_New(t *testing.T) {
+	doc := New("Test", "1.0.0", []Route{
+		{
+			Method:      "POST",
+			Path:        "/greeting",
+			OperationID: "greet",
+			Request:     service.GreetRequest{},
+			Responses: map[int]Body{
+				200: {ContentType: "application/json", Value: service.GreetResponse{}},
+				304: {},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/greeting",
+			OperationID: "greetQuery",
+			Query:       []Parameter{{Name: "s", Schema: SchemaOf("")}},
+			Responses:   map[int]Body{200: {ContentType: "application/json", Value: service.GreetResponse{}}},
+		},
+		{
+			Method:      "GET",
+			Path:        "/greetings/{id}",
+			OperationID: "getGreeting",
+			Params:      []Parameter{{Name: "id", Schema: SchemaOf("")}},
+			Responses:   map[int]Body{200: {ContentType: "application/json", Value: service.StoredGreeting{}}},
+		},
+	})
+
+	assert.Equal(t, Version, doc.OpenAPI)
+	assert.Equal(t, []string{"GET /greeting", "GET /greetings/{id}", "POST /greeting"}, doc.Routes())
+	assert.Equal(t, "query", doc.Operation("GET", "/greeting").Parameters[0].In)
+	assert.Equal(t, Parameter{Name: "id", In: "path", Required: true, Schema: SchemaOf("")}, doc.Operation("GET", "/greetings/{id}").Parameters[0])
+	post := doc.Operation("post", "/greeting")
+	assert.Equal(t, SchemaOf(service.GreetRequest{}), post.RequestBody.Content["application/json"].Schema)
+	assert.Equal(t, "Not Modified", post.Responses["304"].Description)
+	assert.Nil(t, post.Responses["304"].Content)
+}
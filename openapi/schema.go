@@ -0,0 +1,155 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Schema is the subset of the OpenAPI schema object produced by SchemaOf.
+type Schema struct {
+	Type       string             `json:"type"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+
+	AdditionalProperties *Schema `json:"additionalProperties,omitempty"`
+}
+
+// SchemaOf returns the schema of the JSON encoding of v, following encoding/json rules:
+// exported fields named by their json tag, "-" fields skipped, and omitempty fields
+// not required.
+func SchemaOf(v interface{}) *Schema {
+	return schemaOf(reflect.TypeOf(v))
+}
+
+func schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem())}
+	case reflect.Struct:
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name, opts := f.Name, ""
+			if tag, ok := f.Tag.Lookup("json"); ok {
+				if tag == "-" {
+					continue
+				}
+				if i := strings.Index(tag, ","); i >= 0 {
+					tag, opts = tag[:i], tag[i:]
+				}
+				if tag != "" {
+					name = tag
+				}
+			}
+			s.Properties[name] = schemaOf(f.Type)
+			if !strings.Contains(opts, ",omitempty") {
+				s.Required = append(s.Required, name)
+			}
+		}
+		sort.Strings(s.Required)
+		return s
+	}
+	return &Schema{}
+}
+
+// Validate checks that v, a value decoded from JSON into an interface{}, matches the
+// schema. Objects may not carry properties the schema does not declare.
+func (s *Schema) Validate(v interface{}) error {
+	return s.validate("$", v)
+}
+
+func (s *Schema) validate(path string, v interface{}) error {
+	switch s.Type {
+	case "string":
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("%s: expected string, got %T", path, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %T", path, v)
+		}
+	case "integer", "number":
+		f, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("%s: expected %s, got %T", path, s.Type, v)
+		}
+		if s.Type == "integer" && f != float64(int64(f)) {
+			return fmt.Errorf("%s: expected integer, got %v", path, f)
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", path, v)
+		}
+		for i, item := range items {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	case "object":
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %T", path, v)
+		}
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s: missing property %q", path, name)
+			}
+		}
+		for name, value := range object {
+			property := s.Properties[name]
+			if property == nil {
+				property = s.AdditionalProperties
+			}
+			if property == nil {
+				return fmt.Errorf("%s: undeclared property %q", path, name)
+			}
+			if err := property.validate(path+"."+name, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Example returns a value matching the schema, suitable as a request body in tests.
+// Strings are "x" and numbers are 1.
+func (s *Schema) Example() interface{} {
+	switch s.Type {
+	case "string":
+		return "x"
+	case "boolean":
+		return true
+	case "integer", "number":
+		return 1
+	case "array":
+		return []interface{}{s.Items.Example()}
+	case "object":
+		object := map[string]interface{}{}
+		for name, property := range s.Properties {
+			object[name] = property.Example()
+		}
+		return object
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package retry
+
+import "sync"
+
+// Budget limits retries across calls so a struggling server is not flooded with them.
+// It follows gRPC retry throttling: every failed attempt costs one token, every success
+// earns Ratio tokens, and retries are only sent while more than half of MaxTokens remain.
+type Budget struct {
+	mu        sync.Mutex
+	maxTokens float64
+	ratio     float64
+	tokens    float64
+}
+
+func NewBudget(maxTokens, ratio float64) *Budget {
+	return &Budget{maxTokens: maxTokens, ratio: ratio, tokens: maxTokens}
+}
+
+func (b *Budget) allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens > b.maxTokens/2
+}
+
+func (b *Budget) success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.tokens += b.ratio
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+	b.mu.Unlock()
+}
+
+func (b *Budget) failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.tokens--
+	if b.tokens < 0 {
+		b.tokens = 0
+	}
+	b.mu.Unlock()
+}
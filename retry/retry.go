@@ -0,0 +1,147 @@
+// Package retry provides a go-kit endpoint.Middleware that retries failed calls with
+// exponential backoff and jitter, optionally hedges slow calls, and limits retries
+// across calls with a Budget.
+package retry
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Policy configures how calls through an endpoint are retried.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first. Values below 2
+	// disable retries and hedging.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. Each further retry waits
+	// Multiplier times longer, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter randomizes each delay by up to this fraction in either direction (0-1).
+	Jitter float64
+	// HedgeDelay, when set, sends another attempt if no response arrived within the
+	// delay instead of waiting for a failure. The first successful response wins.
+	HedgeDelay time.Duration
+	// Retryable reports whether a failed attempt may be retried. All errors are
+	// retried when nil.
+	Retryable func(error) bool
+}
+
+// DefaultPolicy retries up to twice with exponential backoff starting at 50ms.
+var DefaultPolicy = Policy{
+	MaxAttempts:    3,
+	InitialBackoff: 50 * time.Millisecond,
+	MaxBackoff:     time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// Middleware retries or hedges calls to the next endpoint according to p. When b is
+// not nil, retries and hedged attempts are only sent while the budget allows.
+func Middleware(p Policy, b *Budget) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		if p.MaxAttempts < 2 {
+			return next
+		}
+		if p.HedgeDelay > 0 {
+			return hedge(p, b, next)
+		}
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			for attempt := 1; ; attempt++ {
+				response, err := next(ctx, request)
+				if err == nil {
+					b.success()
+					return response, nil
+				}
+				b.failure()
+				if attempt >= p.MaxAttempts || !p.retryable(err) || !b.allow() {
+					return nil, err
+				}
+				select {
+				case <-time.After(p.Backoff(attempt)):
+				case <-ctx.Done():
+					return nil, err
+				}
+			}
+		}
+	}
+}
+
+type result struct {
+	response interface{}
+	err      error
+}
+
+func hedge(p Policy, b *Budget, next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		results := make(chan result, p.MaxAttempts)
+		sent, inflight := 0, 0
+		send := func() {
+			sent++
+			inflight++
+			go func() {
+				response, err := next(ctx, request)
+				results <- result{response, err}
+			}()
+		}
+
+		send()
+		timer := time.NewTimer(p.HedgeDelay)
+		defer timer.Stop()
+		for {
+			select {
+			case r := <-results:
+				inflight--
+				if r.err == nil {
+					b.success()
+					return r.response, nil
+				}
+				b.failure()
+				if !p.retryable(r.err) {
+					return nil, r.err
+				}
+				if inflight == 0 {
+					if sent >= p.MaxAttempts || !b.allow() {
+						return nil, r.err
+					}
+					send()
+				}
+			case <-timer.C:
+				if sent < p.MaxAttempts && b.allow() {
+					send()
+					timer.Reset(p.HedgeDelay)
+				}
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+}
+
+// Backoff returns the delay before retrying after the given (1-based) failed attempt.
+func (p Policy) Backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	d := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(d)
+}
+
+func (p Policy) retryable(err error) bool {
+	return p.Retryable == nil || p.Retryable(err)
+}
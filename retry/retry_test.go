@@ -0,0 +1,135 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errTransient = errors.New("transient")
+
+// failing returns an endpoint that fails the first n calls and counts all calls.
+func failing(n int32, calls *int32) func(context.Context, interface{}) (interface{}, error) {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if atomic.AddInt32(calls, 1) <= n {
+			return nil, errTransient
+		}
+		return "ok", nil
+	}
+}
+
+func Test_Retry(t *testing.T) {
+	fast := Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 2}
+	permanent := fast
+	permanent.Retryable = func(err error) bool { return err != errTransient }
+
+	tests := map[string]struct {
+		policy        Policy
+		failures      int32
+		expectedCalls int32
+		errorResponse error
+	}{
+		"success_first_try": {
+			policy:        fast,
+			failures:      0,
+			expectedCalls: 1,
+		},
+		"success_after_retries": {
+			policy:        fast,
+			failures:      2,
+			expectedCalls: 3,
+		},
+		"attempts_exhausted": {
+			policy:        fast,
+			failures:      5,
+			expectedCalls: 3,
+			errorResponse: errTransient,
+		},
+		"not_retryable": {
+			policy:        permanent,
+			failures:      5,
+			expectedCalls: 1,
+			errorResponse: errTransient,
+		},
+		"disabled": {
+			policy:        Policy{},
+			failures:      5,
+			expectedCalls: 1,
+			errorResponse: errTransient,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		var calls int32
+		e := Middleware(test.policy, nil)(failing(test.failures, &calls))
+		_, err := e(context.Background(), nil)
+		assert.Equal(t, test.errorResponse, err)
+		assert.Equal(t, test.expectedCalls, atomic.LoadInt32(&calls))
+	}
+}
+
+func Test_Backoff(t *testing.T) {
+	p := Policy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond, Multiplier: 2}
+	assert.Equal(t, 10*time.Millisecond, p.Backoff(1))
+	assert.Equal(t, 20*time.Millisecond, p.Backoff(2))
+	assert.Equal(t, 40*time.Millisecond, p.Backoff(3))
+	assert.Equal(t, 50*time.Millisecond, p.Backoff(4))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.Backoff(1)
+		assert.True(t, d >= 5*time.Millisecond && d <= 15*time.Millisecond, d)
+	}
+}
+
+func Test_Budget(t *testing.T) {
+	b := NewBudget(4, 0.5)
+	var calls int32
+	e := Middleware(Policy{MaxAttempts: 10, InitialBackoff: time.Millisecond}, b)(failing(100, &calls))
+
+	// tokens go 4 -> 3 -> 2; at 2 (not more than half) retries stop
+	_, err := e(context.Background(), nil)
+	assert.Equal(t, errTransient, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// the budget is exhausted, so the next call is not retried at all
+	_, err = e(context.Background(), nil)
+	assert.Equal(t, errTransient, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func Test_Hedge(t *testing.T) {
+	var calls int32
+	slowFirst := func(ctx context.Context, request interface{}) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-time.After(time.Second):
+				return "slow", nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		return "fast", nil
+	}
+
+	e := Middleware(Policy{MaxAttempts: 2, HedgeDelay: 10 * time.Millisecond}, nil)(slowFirst)
+	begin := time.Now()
+	response, err := e(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "fast", response)
+	assert.True(t, time.Since(begin) < 500*time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// a failed attempt is replaced immediately without waiting for the hedge delay
+	calls = 0
+	e = Middleware(Policy{MaxAttempts: 3, HedgeDelay: time.Hour}, nil)(failing(2, &calls))
+	response, err = e(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", response)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/tkeech1/gowebsvc/graphql"
+	"github.com/tkeech1/gowebsvc/openapi"
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+// maxGraphQLBody bounds the body of a GraphQL request.
+const maxGraphQLBody = 1 << 20
+
+// graphqlSchema is the GraphQL schema of the service. Greetings and the expensive
+// operation are offered both as queries and as mutations, the latter run one at a
+// time; the greetings subscription greets a list of names one after the other.
+const graphqlSchema = `schema {
+  query: Query
+  mutation: Mutation
+  subscription: Subscription
+}
+
+enum Formality {
+  INFORMAL
+  FORMAL
+}
+
+type Greeting {
+  greeting: String!
+  "The locale the greeting is written in, null if none was requested."
+  locale: String
+}
+
+type ExpensiveResult {
+  status: String!
+}
+
+type Query {
+  "Greets s."
+  greet(s: String!, locale: String, formality: Formality, count: Int, template: String): Greeting
+  "Runs the expensive operation."
+  expensive(connectionString: String!, username: String!, password: String!): ExpensiveResult
+}
+
+type Mutation {
+  "Greets s."
+  greet(s: String!, locale: String, formality: Formality, count: Int, template: String): Greeting
+  "Runs the expensive operation."
+  expensive(connectionString: String!, username: String!, password: String!): ExpensiveResult
+}
+
+type Subscription {
+  "Streams a greeting per name, in order."
+  greetings(names: [String!]!, locale: String, formality: Formality, count: Int, template: String): Greeting
+}
+`
+
+// graphqlQuerySchema is graphqlSchema with queries alone, run for GET requests.
+var graphqlQuerySchema = "schema {\n  query: Query\n}\n" + graphqlSchema[strings.Index(graphqlSchema, "\n}\n")+3:]
+
+// graphqlError is a service error reported by a GraphQL resolver, carrying its status
+// code as extensions.code.
+type graphqlError struct {
+	err error
+}
+
+func (e graphqlError) Error() string {
+	return e.err.Error()
+}
+
+func (e graphqlError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": service.Code(e.err).String()}
+}
+
+// graphqlResolver resolves the root fields of the schema with the service.
+type graphqlResolver struct {
+	s *server
+}
+
+// greetOptions are the arguments of the greeting fields besides the names greeted.
+type greetOptions struct {
+	Locale    *string
+	Formality *string
+	Count     *int32
+	Template  *string
+}
+
+func (o greetOptions) context(ctx context.Context) (context.Context, error) {
+	var locale, formality, template string
+	var count int
+	if o.Locale != nil {
+		locale = *o.Locale
+	}
+	if o.Formality != nil {
+		formality = *o.Formality
+	}
+	if o.Count != nil {
+		count = int(*o.Count)
+	}
+	if o.Template != nil {
+		template = *o.Template
+	}
+	return service.NewRequestOptionsContext(ctx, locale, formality, count, template)
+}
+
+type greetingResolver struct {
+	greeting string
+	locale   *string
+	// err is the error of a greeting sent by the subscription, reported on its field.
+	err error
+}
+
+func (g *greetingResolver) Greeting() (string, error) {
+	return g.greeting, g.err
+}
+
+func (g *greetingResolver) Locale() *string {
+	return g.locale
+}
+
+func (r *graphqlResolver) greet(ctx context.Context, name string, options greetOptions) (*greetingResolver, error) {
+	ctx, err := options.context(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v, err := r.s.svc.Greet(ctx, name)
+	if err != nil {
+		return nil, graphqlError{err}
+	}
+	g := &greetingResolver{greeting: v}
+	if locale := service.ResolveLocale(ctx); locale != "" {
+		g.locale = &locale
+	}
+	return g, nil
+}
+
+func (r *graphqlResolver) Greet(ctx context.Context, args struct {
+	S         string
+	Locale    *string
+	Formality *string
+	Count     *int32
+	Template  *string
+}) (*greetingResolver, error) {
+	return r.greet(ctx, args.S, greetOptions{args.Locale, args.Formality, args.Count, args.Template})
+}
+
+type expensiveResolver struct {
+	status string
+}
+
+func (e *expensiveResolver) Status() string {
+	return e.status
+}
+
+func (r *graphqlResolver) Expensive(ctx context.Context, args struct {
+	ConnectionString string
+	Username         string
+	Password         string
+}) (*expensiveResolver, error) {
+	v, err := r.s.runExpensive(ctx, service.ExpensiveRequest{C: args.ConnectionString, U: args.Username, P: args.Password})
+	if err != nil {
+		return nil, graphqlError{err}
+	}
+	return &expensiveResolver{status: v}, nil
+}
+
+func (r *graphqlResolver) Greetings(ctx context.Context, args struct {
+	Names     []string
+	Locale    *string
+	Formality *string
+	Count     *int32
+	Template  *string
+}) (<-chan *greetingResolver, error) {
+	options := greetOptions{args.Locale, args.Formality, args.Count, args.Template}
+	if _, err := options.context(ctx); err != nil {
+		return nil, err
+	}
+	events := make(chan *greetingResolver)
+	go func() {
+		defer close(events)
+		for _, name := range args.Names {
+			g, err := r.greet(ctx, name, options)
+			if err != nil {
+				g = &greetingResolver{err: err}
+			}
+			select {
+			case events <- g:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// initGraphQL parses the GraphQL schemas, limiting queries to graphqlMaxDepth. The
+// GraphQL routes are only served once it succeeded.
+func (s *server) initGraphQL() error {
+	opts := []graphqlgo.SchemaOpt{graphqlgo.UseStringDescriptions(), graphqlgo.MaxDepth(s.graphqlMaxDepth)}
+	schema, err := graphqlgo.ParseSchema(graphqlSchema, &graphqlResolver{s}, opts...)
+	if err != nil {
+		return err
+	}
+	querySchema, err := graphqlgo.ParseSchema(graphqlQuerySchema, &graphqlResolver{s}, opts...)
+	if err != nil {
+		return err
+	}
+	s.graphqlSchema, s.graphqlQuerySchema = schema, querySchema
+	return nil
+}
+
+func (s *server) graphqlRoutes() []route {
+	if s.graphqlSchema == nil {
+		return nil
+	}
+	handler, stream := s.handleGraphQL(), s.handleGraphQLStream()
+	responses := map[int]openapi.Body{
+		http.StatusOK:         jsonBody("The result of the operation; resolver errors are in errors.", graphql.Response{}),
+		http.StatusBadRequest: jsonBody("The request is malformed, invalid or nested too deep.", graphql.Response{}),
+	}
+	return []route{
+		{
+			Route: openapi.Route{
+				Method:      "GET",
+				Path:        "/graphql",
+				OperationID: "graphqlQuery",
+				Summary:     "Runs a GraphQL query; with Accept: text/event-stream the result is sent as Server-Sent Events.",
+				Query: []openapi.Parameter{
+					{Name: "query", Description: "The GraphQL query.", Schema: openapi.SchemaOf("")},
+					{Name: "operationName", Description: "The operation to run, if the query has several.", Schema: openapi.SchemaOf("")},
+					{Name: "variables", Description: "The variables, as a JSON object.", Schema: openapi.SchemaOf("")},
+				},
+				Responses: responses,
+			},
+			handler: handler,
+			stream:  stream,
+		},
+		{
+			Route: openapi.Route{
+				Method:      "POST",
+				Path:        "/graphql",
+				OperationID: "graphql",
+				Summary:     "Runs a GraphQL operation; with Accept: text/event-stream, subscriptions included, the results are sent as Server-Sent Events.",
+				Request:     graphql.Request{},
+				Responses:   responses,
+			},
+			handler: handler,
+			stream:  stream,
+		},
+		{
+			Route: openapi.Route{
+				Method:      "GET",
+				Path:        "/graphql/schema",
+				OperationID: "graphqlSchema",
+				Summary:     "Returns the GraphQL schema in the schema definition language.",
+				Responses: map[int]openapi.Body{
+					http.StatusOK: {Description: "The schema.", ContentType: "text/plain"},
+				},
+			},
+			handler: s.handleGraphQLSchema(),
+		},
+	}
+}
+
+// handleGraphQL serves GraphQL requests, sent as a JSON body in a POST request or as
+// query parameters in a GET request, which may only run queries. Requests that fail
+// before they run, such as syntax errors or queries nested too deep, are answered with
+// 400. Subscriptions are served by handleGraphQLStream.
+func (s *server) handleGraphQL() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		schema, req, ok := s.graphqlRequest(w, r)
+		if !ok {
+			return
+		}
+		response := schema.Exec(r.Context(), req.Query, req.OperationName, req.Variables)
+		status := http.StatusOK
+		if response.Data == nil {
+			status = http.StatusBadRequest
+			if len(response.Errors) == 1 && response.Errors[0].Message == graphqlSubscriptionError {
+				writeGraphQLError(w, "Subscription operations must be subscribed to with Accept: text/event-stream.")
+				return
+			}
+		}
+		writeGraphQL(w, status, newGraphQLResponse(response))
+	}
+}
+
+// graphqlSubscriptionError is the error graphql-go's Exec returns for a subscription.
+const graphqlSubscriptionError = "graphql-ws protocol header is missing"
+
+// graphqlRequest decodes the GraphQL request of r and returns it with the schema it runs
+// against. It answers r itself when the request is malformed.
+func (s *server) graphqlRequest(w http.ResponseWriter, r *http.Request) (*graphqlgo.Schema, graphql.Request, bool) {
+	var req graphql.Request
+	if r.Method == "GET" {
+		q := r.URL.Query()
+		req = graphql.Request{Query: q.Get("query"), OperationName: q.Get("operationName")}
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeGraphQLError(w, "variables must be a JSON object")
+				return nil, req, false
+			}
+		}
+		return s.graphqlQuerySchema, req, true
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLBody)).Decode(&req); err != nil {
+		writeGraphQLError(w, "the body must be a JSON GraphQL request: "+err.Error())
+		return nil, req, false
+	}
+	return s.graphqlSchema, req, true
+}
+func newGraphQLResponse(r *graphqlgo.Response) *graphql.Response {
+	response := &graphql.Response{Errors: r.Errors}
+	if r.Data != nil {
+		response.Data = r.Data
+	}
+	return response
+}
+
+func writeGraphQL(w http.ResponseWriter, status int, response *graphql.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeGraphQLError(w http.ResponseWriter, message string) {
+	writeGraphQL(w, http.StatusBadRequest, &graphql.Response{Errors: []*gqlerrors.QueryError{{Message: message}}})
+}
+
+// handleGraphQLStream serves the GraphQL requests accepting text/event-stream, decoded
+// like those of handleGraphQL, with a next event per result, then a complete event;
+// that is how subscriptions are served.
+func (s *server) handleGraphQLStream() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		schema, req, ok := s.graphqlRequest(w, r)
+		if !ok {
+			return
+		}
+		s.streamGraphQL(w, r, schema, req)
+	}
+}
+
+func (s *server) streamGraphQL(w http.ResponseWriter, r *http.Request, schema *graphqlgo.Schema, req graphql.Request) {
+	ctx := r.Context()
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	var responses <-chan interface{}
+	if schema == s.graphqlQuerySchema {
+		// Subscribe refuses schemas without subscriptions, so the query runs on its own
+		result := make(chan interface{}, 1)
+		result <- schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+		close(result)
+		responses = result
+	} else {
+		var err error
+		responses, err = schema.Subscribe(ctx, req.Query, req.OperationName, req.Variables)
+		if err != nil {
+			writeGraphQLError(w, err.Error())
+			return
+		}
+	}
+	s.streamConnections.With("transport", "graphql").Add(1)
+	defer s.streamConnections.With("transport", "graphql").Add(-1)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(s.heartbeatInterval())
+	defer heartbeat.Stop()
+	for {
+		select {
+		case response, ok := <-responses:
+			if !ok {
+				if ctx.Err() == nil {
+					fmt.Fprint(w, "event: complete\ndata:\n\n")
+					flusher.Flush()
+				}
+				return
+			}
+			data, _ := json.Marshal(newGraphQLResponse(response.(*graphqlgo.Response)))
+			fmt.Fprintf(w, "event: next\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// handleGraphQLSchema serves the schema in the GraphQL schema definition language.
+func (s *server) handleGraphQLSchema() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, graphqlSchema)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+
+	service "github.com/tkeech1/gowebsvc/svc"
+	svcv2 "github.com/tkeech1/gowebsvc/svc/v2"
+)
+
+// greeter is the Greeter behind the gRPC servers. Expensive goes through runExpensive,
+// so it runs once however it is reached: REST, gRPC or the gateway.
+type greeter struct {
+	s *server
+}
+
+func (g greeter) Greet(ctx context.Context, s string) (string, error) {
+	return g.s.svc.Greet(ctx, s)
+}
+
+func (g greeter) Expensive(ctx context.Context, c, u, p string) (string, error) {
+	return g.s.runExpensive(ctx, service.ExpensiveRequest{C: c, U: u, P: p})
+}
+
+// grpcV1 returns the v1 GreetingService. It serves gRPC, the gateway and the
+// hand-written v1 greeting and expensive routes, which all answer alike.
+func (s *server) grpcV1() *service.GreetingServiceGRPC {
+	return &service.GreetingServiceGRPC{Next: greeter{s}}
+}
+
+// grpcV2 returns the v2 GreetingService, serving gRPC, the gateway and the
+// hand-written v2 greeting and expensive routes.
+func (s *server) grpcV2() svcv2.Server {
+	return svcv2.Server{Next: greeter{s}, Greetings: s.greetings, Jobs: s.jobs}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tkeech1/gowebsvc/store"
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+// handleGetGreeting returns the caller's recorded greeting named by the last path
+// segment.
+func (s *server) handleGetGreeting() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.greetings == nil {
+			w.WriteHeader(service.HTTPStatus(service.ErrHistoryDisabled))
+			s.transport.EncodeErrorResponse(&w, service.ErrorResponse{Err: service.ErrHistoryDisabled.Error()})
+			return
+		}
+
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		g, err := s.greetings.Get(r.Context(), id)
+		if err == nil && g.Caller != owner(r) {
+			err = store.ErrNotFound
+		}
+		if err != nil {
+			w.WriteHeader(service.HTTPStatus(err))
+			s.transport.EncodeErrorResponse(&w, service.ErrorResponse{Err: err.Error()})
+			return
+		}
+		s.transport.EncodeStoredGreeting(&w, service.NewStoredGreeting(g))
+	}
+}
+
+// handleListGreetings returns a page of the caller's recorded greetings created at or
+// after the since query parameter.
+func (s *server) handleListGreetings() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fail := func(err error) {
+			w.WriteHeader(service.HTTPStatus(err))
+			s.transport.EncodeGreetingHistoryResponse(&w, service.GreetingHistoryResponse{Greetings: []service.StoredGreeting{}, Err: err.Error()})
+		}
+		if s.greetings == nil {
+			fail(service.ErrHistoryDisabled)
+			return
+		}
+
+		query := r.URL.Query()
+		since, err := service.ParseSince(query.Get("since"))
+		if err != nil {
+			fail(err)
+			return
+		}
+		pageSize := 0
+		if v := query.Get("page_size"); v != "" {
+			if pageSize, err = strconv.Atoi(v); err != nil {
+				fail(service.ErrInvalidPageSize)
+				return
+			}
+		}
+		page, err := s.greetings.List(r.Context(), store.Filter{Since: since, Caller: owner(r)}, query.Get("page_token"), pageSize)
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		response := service.GreetingHistoryResponse{Greetings: []service.StoredGreeting{}, NextPageToken: page.NextPageToken}
+		for _, g := range page.Greetings {
+			response.Greetings = append(response.Greetings, service.NewStoredGreeting(g))
+		}
+		s.transport.EncodeGreetingHistoryResponse(&w, response)
+	}
+}
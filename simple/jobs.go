@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/tkeech1/gowebsvc/jobs"
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+// handleStartExpensiveJob queues the expensive operation on the job pool and replies
+// 202 Accepted with the job, which is polled at its Location.
+func (s *server) handleStartExpensiveJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(service.HTTPStatus(service.ErrJobsDisabled))
+			s.transport.EncodeErrorResponse(&w, service.ErrorResponse{Err: service.ErrJobsDisabled.Error()})
+			return
+		}
+
+		gr, err := s.transport.DecodeExpensiveServiceRequest(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			s.transport.EncodeErrorResponse(&w, service.ErrorResponse{Err: err.Error()})
+			return
+		}
+		j, err := s.jobs.Submit(r.Context(), owner(r), func(ctx context.Context) (string, error) {
+			return s.initExpensive(ctx, gr)
+		})
+		if err != nil {
+			if err == jobs.ErrQueueFull {
+				w.Header().Set("Retry-After", "1")
+			}
+			w.WriteHeader(service.HTTPStatus(err))
+			s.transport.EncodeErrorResponse(&w, service.ErrorResponse{Err: err.Error()})
+			return
+		}
+
+		w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+j.ID)
+		w.WriteHeader(http.StatusAccepted)
+		s.transport.EncodeExpensiveJob(&w, service.NewExpensiveJob(j))
+	}
+}
+
+// handleGetExpensiveJob returns the caller's job named by the last path segment.
+func (s *server) handleGetExpensiveJob() http.HandlerFunc {
+	return s.handleExpensiveJob((*jobs.Pool).Get)
+}
+
+// handleCancelExpensiveJob cancels the caller's job named by the last path segment and
+// returns it. Finished jobs are returned unchanged.
+func (s *server) handleCancelExpensiveJob() http.HandlerFunc {
+	return s.handleExpensiveJob((*jobs.Pool).Cancel)
+}
+
+func (s *server) handleExpensiveJob(op func(p *jobs.Pool, owner, id string) (jobs.Job, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.jobs == nil {
+			w.WriteHeader(service.HTTPStatus(service.ErrJobsDisabled))
+			s.transport.EncodeErrorResponse(&w, service.ErrorResponse{Err: service.ErrJobsDisabled.Error()})
+			return
+		}
+
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		j, err := op(s.jobs, owner(r), id)
+		if err != nil {
+			w.WriteHeader(service.HTTPStatus(err))
+			s.transport.EncodeErrorResponse(&w, service.ErrorResponse{Err: err.Error()})
+			return
+		}
+		s.transport.EncodeExpensiveJob(&w, service.NewExpensiveJob(j))
+	}
+}
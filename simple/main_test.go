@@ -3,20 +3,49 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"errors"
+	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/gorilla/websocket"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/expfmt"
+	"github.com/tkeech1/gowebsvc/cache"
+	"github.com/tkeech1/gowebsvc/gateway"
+	"github.com/tkeech1/gowebsvc/jobs"
 	middleware "github.com/tkeech1/gowebsvc/middleware"
+	"github.com/tkeech1/gowebsvc/openapi"
+	"github.com/tkeech1/gowebsvc/retry"
+	"github.com/tkeech1/gowebsvc/store"
 	service "github.com/tkeech1/gowebsvc/svc"
+	svcv2 "github.com/tkeech1/gowebsvc/svc/v2"
+	"github.com/tkeech1/gowebsvc/webhook"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -321,3 +350,2037 @@ func Test_ExpensiveServiceMultipleTries(t *testing.T) {
 	assert.Equal(t, tests["2nd_try"].httpStatusResponse, w.Code)
 
 }
+
+func Test_Authorization(t *testing.T) {
+
+	policy := middleware.Policy{Methods: map[string]middleware.Rule{
+		"Greet":     {Roles: []string{"user", "admin"}},
+		"Expensive": {Roles: []string{"admin"}, Scopes: []string{"expensive:invoke"}},
+	}}
+
+	tests := map[string]struct {
+		path               string
+		body               []byte
+		roles              string
+		scopes             string
+		expectedResponse   string
+		httpStatusResponse int
+	}{
+		"greet_allowed": {
+			path:               "/greeting",
+			body:               []byte(`{"s":"hello"}`),
+			roles:              "user",
+			expectedResponse:   `{"greeting":"hello"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"greet_anonymous": {
+			path:               "/greeting",
+			body:               []byte(`{"s":"hello"}`),
+			expectedResponse:   `{"greeting":"","err":"permission denied"}` + "\n",
+			httpStatusResponse: http.StatusForbidden,
+		},
+		"expensive_denied": {
+			path:               "/expensive",
+			body:               []byte(`{"connection_string":"c1","username":"u1","password":"p1"}`),
+			roles:              "user",
+			expectedResponse:   `{"status":"","err":"permission denied"}` + "\n",
+			httpStatusResponse: http.StatusForbidden,
+		},
+		"expensive_scope": {
+			path:               "/expensive",
+			body:               []byte(`{"connection_string":"c1","username":"u1","password":"p1"}`),
+			roles:              "user",
+			scopes:             "expensive:invoke",
+			expectedResponse:   `{"status":"c1u1p1"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		req, err := http.NewRequest("POST", test.path, bytes.NewBuffer(test.body))
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		req.Header.Set("X-Caller-Id", name)
+		req.Header.Set("X-Caller-Roles", test.roles)
+		req.Header.Set("X-Caller-Scopes", test.scopes)
+		w := httptest.NewRecorder()
+
+		authMiddleware := middleware.AuthorizationMiddleware{
+			Policy: policy,
+			Logger: log.New(os.Stdout, "LOG: ", log.Ldate|log.Ltime|log.Lshortfile),
+			Next:   service.GreetingService{},
+		}
+		s := server{transport: HttpJson{}, svc: authMiddleware}
+
+		handler := s.handleGreeting()
+		if test.path == "/expensive" {
+			handler = s.handleExpensive()
+		}
+		middleware.CallerHandler(handler).ServeHTTP(w, req)
+		assert.Equal(t, test.expectedResponse, w.Body.String())
+		assert.Equal(t, test.httpStatusResponse, w.Code)
+	}
+}
+
+func Test_AuthorizationGRPC(t *testing.T) {
+
+	policy := middleware.Policy{Methods: map[string]middleware.Rule{
+		"Greet": {Roles: []string{"user"}},
+	}}
+	authMiddleware := middleware.AuthorizationMiddlewareGRPC{
+		Policy: policy,
+		Logger: log.New(os.Stdout, "LOG: ", log.Ldate|log.Ltime|log.Lshortfile),
+		Next:   &service.GreetingServiceGRPC{Next: service.GreetingService{}},
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-caller-roles", "user"))
+	response, err := authMiddleware.GreetGRPC(ctx, &service.GRPCGreetRequest{S: "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", response.Greeting)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-caller-roles", "guest"))
+	_, err = authMiddleware.GreetGRPC(ctx, &service.GRPCGreetRequest{S: "hello"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func Test_GreetingServiceGRPC(t *testing.T) {
+
+	policy := middleware.Policy{Methods: map[string]middleware.Rule{
+		"Greet":     {Roles: []string{"*"}},
+		"Expensive": {Roles: []string{"admin"}},
+	}}
+	svc := &service.GreetingServiceGRPC{Next: middleware.AuthorizationMiddleware{Policy: policy, Next: service.GreetingService{}}}
+
+	response, err := svc.GreetGRPC(context.Background(), &service.GRPCGreetRequest{S: "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", response.Greeting)
+
+	response, err = svc.GreetGRPC(context.Background(), &service.GRPCGreetRequest{S: ""})
+	assert.Nil(t, err)
+	assert.Equal(t, "empty greeting", response.Err)
+
+	_, err = svc.ExpensiveGRPC(context.Background(), &service.GRPCExpensiveRequest{ConnectionString: "c1", Username: "u1", Password: "p1"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	ctx := middleware.NewCallerContext(context.Background(), middleware.Caller{Roles: []string{"admin"}})
+	expensive, err := svc.ExpensiveGRPC(ctx, &service.GRPCExpensiveRequest{ConnectionString: "c1", Username: "u1", Password: "p1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "c1u1p1", expensive.Status)
+}
+
+func Test_GreetingCaching(t *testing.T) {
+
+	lookups := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: "Test_GreetingCaching",
+		Subsystem: "greeting_service",
+		Name:      "cache_lookups",
+		Help:      "Number of Greet cache lookups by result (hit or miss).",
+	}, []string{"result"})
+
+	cachingMiddleware := middleware.CachingMiddleware{
+		Cache:   cache.NewLRU(10),
+		TTL:     time.Minute,
+		Lookups: lookups,
+		Logger:  log.New(os.Stdout, "LOG: ", log.Ldate|log.Ltime|log.Lshortfile),
+		Next:    service.GreetingService{},
+	}
+	s := server{transport: HttpJson{}, svc: cachingMiddleware, cacheMaxAge: time.Minute}
+	handler := s.handleGreeting()
+
+	tests := []struct {
+		name               string
+		greeting           []byte
+		ifNoneMatch        bool
+		expectedResponse   string
+		httpStatusResponse int
+	}{
+		{
+			name:               "miss",
+			greeting:           []byte(`{"s":"hello"}`),
+			expectedResponse:   `{"greeting":"hello"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "hit",
+			greeting:           []byte(`{"s":"hello"}`),
+			expectedResponse:   `{"greeting":"hello"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "not_modified",
+			greeting:           []byte(`{"s":"hello"}`),
+			ifNoneMatch:        true,
+			expectedResponse:   "",
+			httpStatusResponse: http.StatusNotModified,
+		},
+		{
+			name:               "error_not_cached",
+			greeting:           []byte(`{"s":""}`),
+			expectedResponse:   `{"greeting":"","err":"empty greeting"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+	}
+
+	var etag string
+	for _, test := range tests {
+		t.Logf("Running test case: %s", test.name)
+		req, err := http.NewRequest("POST", "/greeting", bytes.NewBuffer(test.greeting))
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		if test.ifNoneMatch {
+			req.Header.Set("If-None-Match", `W/"other", `+etag)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, test.expectedResponse, w.Body.String())
+		assert.Equal(t, test.httpStatusResponse, w.Code)
+		if w.Code == http.StatusOK && etag == "" {
+			etag = w.Header().Get("ETag")
+			assert.NotEmpty(t, etag)
+			assert.Equal(t, "private, max-age=60", w.Header().Get("Cache-Control"))
+		}
+	}
+
+	// check prometheus stats
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	w := httptest.NewRecorder()
+	promhttp.Handler().ServeHTTP(w, req)
+
+	parser := expfmt.TextParser{}
+	parsedData, err := parser.TextToMetricFamilies(w.Body)
+	if err != nil {
+		t.Fatal(" unable to get prometheus metrics ")
+	}
+	var hitCount, missCount float64
+	for _, metric := range parsedData["Test_GreetingCaching_greeting_service_cache_lookups"].GetMetric() {
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == "result" && label.GetValue() == "hit" {
+				hitCount = metric.GetCounter().GetValue()
+			}
+			if label.GetName() == "result" && label.GetValue() == "miss" {
+				missCount = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, 2.0, hitCount)
+	assert.Equal(t, 2.0, missCount)
+}
+
+// concurrencyGreeter records the highest number of concurrent Greet calls.
+type concurrencyGreeter struct {
+	service.GreetingService
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+func (g *concurrencyGreeter) Greet(ctx context.Context, greeting string) (string, error) {
+	g.mu.Lock()
+	g.current++
+	if g.current > g.max {
+		g.max = g.current
+	}
+	g.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	g.mu.Lock()
+	g.current--
+	g.mu.Unlock()
+	return g.GreetingService.Greet(ctx, greeting)
+}
+
+func Test_GreetingBatch(t *testing.T) {
+
+	tests := map[string]struct {
+		batch              []byte
+		expectedResponse   string
+		httpStatusResponse int
+	}{
+		"partial_failure": {
+			batch:              []byte(`[{"s":"a"},{"s":""},{"s":"c"},{"s":"d"},{"s":"e"}]`),
+			expectedResponse:   `{"results":[{"greeting":"a"},{"greeting":"","err":"empty greeting"},{"greeting":"c"},{"greeting":"d"},{"greeting":"e"}]}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"empty": {
+			batch:              []byte(`[]`),
+			expectedResponse:   `{"results":[]}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"too_large": {
+			batch:              []byte(`[{"s":"a"},{"s":"b"},{"s":"c"},{"s":"d"},{"s":"e"},{"s":"f"}]`),
+			expectedResponse:   `{"results":[],"err":"batch of 6 exceeds the maximum of 5"}` + "\n",
+			httpStatusResponse: http.StatusRequestEntityTooLarge,
+		},
+		"body_too_large": {
+			batch:              []byte(`[{"s":"` + strings.Repeat("a", maxBatchBody) + `"}]`),
+			expectedResponse:   `{"results":[],"err":"http: request body too large"}` + "\n",
+			httpStatusResponse: http.StatusRequestEntityTooLarge,
+		},
+		"error_not_an_array": {
+			batch:              []byte(`{"s":"a"}`),
+			expectedResponse:   `{"results":[],"err":"json: cannot unmarshal object into Go value of type []svc.GreetRequest"}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		req, err := http.NewRequest("POST", "/greetings:batch", bytes.NewBuffer(test.batch))
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		w := httptest.NewRecorder()
+
+		greeter := &concurrencyGreeter{}
+		s := server{transport: HttpJson{}, svc: greeter, maxBatchSize: 5, batchConcurrency: 2}
+		handler := s.handleGreetingBatch()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, test.expectedResponse, w.Body.String())
+		assert.Equal(t, test.httpStatusResponse, w.Code)
+		assert.True(t, greeter.max <= 2, "ran %d greetings concurrently", greeter.max)
+	}
+}
+
+// fakeGauge records gauge values by the value of its single label.
+type fakeGauge struct {
+	mu     *sync.Mutex
+	label  string
+	values map[string]float64
+}
+
+func newFakeGauge() *fakeGauge {
+	return &fakeGauge{mu: &sync.Mutex{}, values: map[string]float64{}}
+}
+
+func (g *fakeGauge) With(labelValues ...string) metrics.Gauge {
+	return &fakeGauge{mu: g.mu, label: labelValues[1], values: g.values}
+}
+
+func (g *fakeGauge) Set(value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[g.label] = value
+}
+
+func (g *fakeGauge) Add(delta float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[g.label] += delta
+}
+
+func (g *fakeGauge) value(label string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.values[label]
+}
+
+// slowGreeter greets after a delay.
+type slowGreeter struct {
+	service.GreetingService
+	delay time.Duration
+}
+
+func (g slowGreeter) Greet(ctx context.Context, s string) (string, error) {
+	select {
+	case <-time.After(g.delay):
+	case <-ctx.Done():
+		return "", service.ErrRequestCancelled
+	}
+	return g.GreetingService.Greet(ctx, s)
+}
+
+func Test_GreetingStream(t *testing.T) {
+
+	tests := map[string]struct {
+		url              string
+		lastEventID      string
+		expectedResponse string
+	}{
+		"success": {
+			url: "/greeting/stream?s=a&s=&s=c",
+			expectedResponse: "id: 0\nevent: greeting\ndata: {\"greeting\":\"a\"}\n\n" +
+				"id: 1\nevent: greeting\ndata: {\"greeting\":\"\",\"err\":\"empty greeting\"}\n\n" +
+				"id: 2\nevent: greeting\ndata: {\"greeting\":\"c\"}\n\n" +
+				"event: end\ndata: {}\n\n",
+		},
+		"resume": {
+			url:         "/greeting/stream?s=a&s=b&s=c",
+			lastEventID: "1",
+			expectedResponse: "id: 2\nevent: greeting\ndata: {\"greeting\":\"c\"}\n\n" +
+				"event: end\ndata: {}\n\n",
+		},
+		"no_names": {
+			url:              "/greeting/stream",
+			expectedResponse: "event: end\ndata: {}\n\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		gauge := newFakeGauge()
+		s := server{transport: HttpJson{}, svc: service.GreetingService{}, streamConnections: gauge}
+		ts := httptest.NewServer(s.handleGreetingStream())
+
+		req, err := http.NewRequest("GET", ts.URL+test.url, nil)
+		assert.Nil(t, err)
+		if test.lastEventID != "" {
+			req.Header.Set("Last-Event-ID", test.lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Nil(t, err)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		assert.Equal(t, test.expectedResponse, string(body))
+		assert.Eventually(t, func() bool { return gauge.value("sse") == 0 }, time.Second, 10*time.Millisecond)
+		ts.Close()
+	}
+}
+
+func Test_GreetingStreamHeartbeat(t *testing.T) {
+	gauge := newFakeGauge()
+	s := server{transport: HttpJson{}, svc: slowGreeter{delay: 100 * time.Millisecond}, heartbeat: 10 * time.Millisecond, streamConnections: gauge}
+	ts := httptest.NewServer(s.handleGreetingStream())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/greeting/stream?s=a")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1), gauge.value("sse"))
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), ": heartbeat\n\n")
+	assert.Contains(t, string(body), "id: 0\nevent: greeting\ndata: {\"greeting\":\"a\"}\n\n")
+	assert.Eventually(t, func() bool { return gauge.value("sse") == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func Test_GreetingWebSocket(t *testing.T) {
+	gauge := newFakeGauge()
+	s := server{transport: HttpJson{}, svc: service.GreetingService{}, heartbeat: 10 * time.Millisecond, streamConnections: gauge}
+	ts := httptest.NewServer(s.handleGreetingWebSocket())
+	defer ts.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return gauge.value("websocket") == 1 }, time.Second, 10*time.Millisecond)
+
+	pings := make(chan struct{}, 1)
+	conn.SetPingHandler(func(data string) error {
+		select {
+		case pings <- struct{}{}:
+		default:
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	for _, message := range []string{`{"s":"a"}`, `{"s":""}`, `{"s":`, `{"s":"b"}`} {
+		assert.Nil(t, conn.WriteMessage(websocket.TextMessage, []byte(message)))
+	}
+
+	expected := []service.GreetResponse{
+		{V: "a"},
+		{V: "", Err: "empty greeting"},
+		{V: "", Err: "unexpected end of JSON input"},
+		{V: "b"},
+	}
+	for _, e := range expected {
+		var response service.GreetResponse
+		assert.Nil(t, conn.ReadJSON(&response))
+		assert.Equal(t, e, response)
+	}
+
+	// pings are handled while the connection is being read
+	go conn.ReadMessage()
+	select {
+	case <-pings:
+	case <-time.After(time.Second):
+		t.Errorf("no heartbeat received")
+	}
+
+	conn.Close()
+	assert.Eventually(t, func() bool { return gauge.value("websocket") == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func Test_SinglePort(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	grpcServer := grpc.NewServer()
+	service.RegisterGreetingServiceServer(grpcServer, &service.GreetingServiceGRPC{Next: service.GreetingService{}})
+	s := server{transport: HttpJson{}, svc: service.GreetingService{}}
+	mux := http.NewServeMux()
+	mux.Handle("/greeting", s.handleGreeting())
+	httpServer := &http.Server{Handler: mux}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- serveSinglePort(lis, nil, grpcServer, httpServer)
+	}()
+
+	resp, err := http.Post("http://"+lis.Addr().String()+"/greeting", "application/json", bytes.NewBufferString(`{"s":"hello"}`))
+	assert.Nil(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"greeting":"hello"}`+"\n", string(body))
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer conn.Close()
+	response, err := service.NewGreetingServiceClient(conn).GreetGRPC(context.Background(), &service.GRPCGreetRequest{S: "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", response.Greeting)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	shutdown(ctx, grpcServer, httpServer, nil)
+	select {
+	case <-served:
+	case <-time.After(time.Second):
+		t.Errorf("server did not stop")
+	}
+}
+
+func Test_ShutdownWaitsForRequests(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	grpcServer := grpc.NewServer()
+	s := server{transport: HttpJson{}, svc: slowGreeter{delay: 200 * time.Millisecond}}
+	httpServer := &http.Server{Handler: s.handleGreeting()}
+	go serveSinglePort(lis, nil, grpcServer, httpServer)
+
+	done := make(chan string)
+	go func() {
+		resp, err := http.Post("http://"+lis.Addr().String()+"/greeting", "application/json", bytes.NewBufferString(`{"s":"hello"}`))
+		if err != nil {
+			done <- err.Error()
+			return
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		done <- string(body)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	shutdown(ctx, grpcServer, httpServer)
+	assert.Equal(t, `{"greeting":"hello"}`+"\n", <-done)
+
+	_, err = http.Post("http://"+lis.Addr().String()+"/greeting", "application/json", bytes.NewBufferString(`{"s":"hello"}`))
+	assert.NotNil(t, err)
+}
+
+func Test_H2C(t *testing.T) {
+
+	tests := map[string]struct {
+		h2c           bool
+		expectedProto int
+	}{
+		"h2c":        {h2c: true, expectedProto: 2},
+		"http1_only": {h2c: false, expectedProto: 0},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.Nil(t, err)
+		s := server{transport: HttpJson{}, svc: service.GreetingService{}}
+		httpServer, err := newHTTPServer("", s.handleGreeting(), nil, httpConfig{H2C: test.h2c, MaxConcurrentStreams: 10})
+		assert.Nil(t, err)
+		go httpServer.Serve(lis)
+
+		client := &http.Client{Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		}}
+		resp, err := client.Post("http://"+lis.Addr().String()+"/greeting", "application/json", bytes.NewBufferString(`{"s":"hello"}`))
+		if test.expectedProto == 0 {
+			assert.NotNil(t, err)
+		} else {
+			assert.Nil(t, err)
+			body, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(t, test.expectedProto, resp.ProtoMajor)
+			assert.Equal(t, `{"greeting":"hello"}`+"\n", string(body))
+		}
+		httpServer.Close()
+	}
+}
+
+func Test_ReadHeaderTimeout(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	httpServer, err := newHTTPServer("", http.NotFoundHandler(), nil, httpConfig{ReadHeaderTimeout: 50 * time.Millisecond})
+	assert.Nil(t, err)
+	go httpServer.Serve(lis)
+	defer httpServer.Close()
+
+	// a client that never finishes its headers is disconnected
+	conn, err := net.Dial("tcp", lis.Addr().String())
+	assert.Nil(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\n"))
+	assert.Nil(t, err)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = ioutil.ReadAll(conn)
+	assert.Nil(t, err)
+}
+
+func Test_WriteTimeout(t *testing.T) {
+	gauge := newFakeGauge()
+	s := server{
+		transport:         HttpJson{},
+		svc:               slowGreeter{delay: 60 * time.Millisecond},
+		writeTimeout:      50 * time.Millisecond,
+		heartbeat:         10 * time.Millisecond,
+		streamConnections: gauge,
+	}
+	assert.Nil(t, s.initGraphQL())
+	mux := http.NewServeMux()
+	register(mux, s.routes())
+	httpServer, err := newHTTPServer("", mux, nil, httpConfig{})
+	assert.Nil(t, err)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go httpServer.Serve(lis)
+	defer httpServer.Close()
+	url := "http://" + lis.Addr().String()
+
+	// a stream outlasting the write timeout is not cut off
+	resp, err := http.Get(url + "/v1/greeting/stream?s=a&s=b&s=c")
+	assert.Nil(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), "id: 2\nevent: greeting\ndata: {\"greeting\":\"c\"}\n\n")
+	assert.True(t, strings.HasSuffix(string(body), "event: end\ndata: {}\n\n"))
+
+	// so is a GraphQL subscription
+	req, err := http.NewRequest("POST", url+"/graphql", strings.NewReader(`{"query":"subscription { greetings(names: [\"a\", \"b\", \"c\"]) { greeting } }"}`))
+	assert.Nil(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	body, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), "event: next\ndata: {\"data\":{\"greetings\":{\"greeting\":\"c\"}}}\n\n")
+	assert.True(t, strings.HasSuffix(string(body), "event: complete\ndata:\n\n"))
+
+	// a request that is not streamed is answered with 503 once it times out
+	resp, err = http.Post(url+"/v2/greeting", "application/json", strings.NewReader(`{"name":"hello"}`))
+	assert.Nil(t, err)
+	body, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, `{"err":"request timed out"}`, string(body))
+
+	// so is a GraphQL request that is not streamed
+	resp, err = http.Post(url+"/graphql", "application/json", strings.NewReader(`{"query":"{ greet(s: \"hello\") { greeting } }"}`))
+	assert.Nil(t, err)
+	body, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, `{"err":"request timed out"}`, string(body))
+}
+
+// Test_OpenAPIDrift calls every documented operation with a request built from its
+// documented body and checks that the handler answers with a documented status,
+// content type and schema.
+func Test_OpenAPIDrift(t *testing.T) {
+	greetings, cleanup := newTestHistory(t)
+	defer cleanup()
+	pool := jobs.NewPool(1, 1, time.Minute)
+	defer pool.Close()
+	webhooks := webhook.NewDispatcher(http.DefaultClient, webhook.DefaultPolicy, log.New(ioutil.Discard, "", 0))
+	defer webhooks.Close()
+	s := server{transport: HttpJson{}, svc: service.GreetingService{}, greetings: greetings, jobs: pool, webhooks: webhooks, maxBatchSize: 5, batchConcurrency: 2, streamConnections: newFakeGauge()}
+	assert.Nil(t, s.initGraphQL())
+	mux := http.NewServeMux()
+	register(mux, s.routes())
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/openapi.json")
+	assert.Nil(t, err)
+	var doc openapi.Document
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&doc))
+	resp.Body.Close()
+	assert.Equal(t, []string{
+		"DELETE /v2/expensive/jobs/{id}",
+		"DELETE /v2/webhooks/{id}",
+		"GET /graphql",
+		"GET /graphql/schema",
+		"GET /greeting/stream",
+		"GET /greeting/ws",
+		"GET /v1/greeting/stream",
+		"GET /v1/greeting/ws",
+		"GET /v2/expensive/jobs/{id}",
+		"GET /v2/greetings",
+		"GET /v2/greetings/{id}",
+		"GET /v2/webhooks",
+		"GET /v2/webhooks/dead-letters",
+		"GET /v2/webhooks/deliveries",
+		"POST /expensive",
+		"POST /graphql",
+		"POST /greeting",
+		"POST /greetings:batch",
+		"POST /v1/expensive",
+		"POST /v1/greeting",
+		"POST /v1/greetings:batch",
+		"POST /v2/expensive",
+		"POST /v2/expensive/jobs",
+		"POST /v2/greeting",
+		"POST /v2/webhooks",
+	}, doc.Routes())
+
+	for _, name := range doc.Routes() {
+		t.Logf("Running test case: %s", name)
+		parts := strings.SplitN(name, " ", 2)
+		op := doc.Operation(parts[0], parts[1])
+
+		var body []byte
+		if op.RequestBody != nil {
+			body, err = json.Marshal(op.RequestBody.Content["application/json"].Schema.Example())
+			assert.Nil(t, err)
+		}
+		url := ts.URL + parts[1]
+		query := "?"
+		for _, p := range op.Parameters {
+			if p.In == "path" {
+				url = strings.Replace(url, "{"+p.Name+"}", "x", 1)
+				continue
+			}
+			url += query + p.Name + "=x"
+			query = "&"
+		}
+		req, err := http.NewRequest(parts[0], url, bytes.NewBuffer(body))
+		assert.Nil(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+		payload, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Nil(t, err)
+
+		assert.Equal(t, op.Deprecated, resp.Header.Get("Deprecation") != "")
+
+		response, ok := op.Responses[strconv.Itoa(resp.StatusCode)]
+		if !assert.True(t, ok, "undocumented status %d", resp.StatusCode) {
+			continue
+		}
+		if len(response.Content) == 0 {
+			assert.Empty(t, payload)
+			continue
+		}
+		contentType := strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0])
+		media, ok := response.Content[contentType]
+		if !assert.True(t, ok, "undocumented content type %q", contentType) {
+			continue
+		}
+		if media.Schema != nil {
+			var v interface{}
+			assert.Nil(t, json.Unmarshal(payload, &v))
+			assert.Nil(t, media.Schema.Validate(v))
+		}
+	}
+
+	req, err := http.NewRequest("DELETE", ts.URL+"/greeting", nil)
+	assert.Nil(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	assert.Equal(t, "POST", resp.Header.Get("Allow"))
+}
+
+func Test_Versions(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		path               string
+		body               []byte
+		expectedResponse   string
+		httpStatusResponse int
+		successor          string
+	}{
+		{
+			name:               "v1",
+			path:               "/v1/greeting",
+			body:               []byte(`{"s":"hello"}`),
+			expectedResponse:   `{"greeting":"hello"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+			successor:          "/v2/greeting",
+		},
+		{
+			name:               "unversioned_batch",
+			path:               "/greetings:batch",
+			body:               []byte(`[{"s":"hello"}]`),
+			expectedResponse:   `{"results":[{"greeting":"hello"}]}` + "\n",
+			httpStatusResponse: http.StatusOK,
+			successor:          "/v1/greetings:batch",
+		},
+		{
+			name:               "v1_batch",
+			path:               "/v1/greetings:batch",
+			body:               []byte(`[{"s":"hello"}]`),
+			expectedResponse:   `{"results":[{"greeting":"hello"}]}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "v2",
+			path:               "/v2/greeting",
+			body:               []byte(`{"name":"hello","locale":"en","formality":"informal"}`),
+			expectedResponse:   `{"greeting":"Hi, hello!","locale":"en"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "v2_empty",
+			path:               "/v2/greeting",
+			body:               []byte(`{"name":""}`),
+			expectedResponse:   `{"greeting":"","err":"empty greeting"}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		{
+			name:               "v2_unknown_formality",
+			path:               "/v2/greeting",
+			body:               []byte(`{"name":"hello","formality":"casual"}`),
+			expectedResponse:   `{"greeting":"","err":"unknown formality \"casual\""}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		{
+			name:               "v2_expensive",
+			path:               "/v2/expensive",
+			body:               []byte(`{"connection_string":"c1","username":"u1","password":"p1"}`),
+			expectedResponse:   `{"status":"c1u1p1"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "unversioned_expensive_after_v2",
+			path:               "/expensive",
+			body:               []byte(`{"connection_string":"c1","username":"u1","password":"p1"}`),
+			expectedResponse:   `{"status":"already initialized"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+			successor:          "/v2/expensive",
+		},
+	}
+
+	s := server{
+		transport:   HttpJson{},
+		svc:         service.GreetingService{},
+		deprecation: middleware.Deprecation{Date: time.Unix(1000, 0), Sunset: time.Unix(2000, 0)},
+	}
+	mux := http.NewServeMux()
+	register(mux, s.routes())
+
+	for _, test := range tests {
+		t.Logf("Running test case: %s", test.name)
+		req, err := http.NewRequest("POST", test.path, bytes.NewBuffer(test.body))
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		assert.Equal(t, test.expectedResponse, w.Body.String())
+		assert.Equal(t, test.httpStatusResponse, w.Code)
+		if test.successor != "" {
+			assert.Equal(t, "@1000", w.Header().Get("Deprecation"))
+			assert.Equal(t, "Thu, 01 Jan 1970 00:33:20 GMT", w.Header().Get("Sunset"))
+			assert.Equal(t, `<`+test.successor+`>; rel="successor-version"`, w.Header().Get("Link"))
+		} else {
+			assert.Empty(t, w.Header().Get("Deprecation"))
+		}
+	}
+}
+
+func Test_VersionsGRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	deprecation := middleware.Deprecation{Date: time.Unix(1000, 0)}
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(middleware.ChainUnaryServer(
+		middleware.CallerUnaryServerInterceptor,
+		deprecation.UnaryServerInterceptor("svc.GreetingService"),
+	)))
+	service.RegisterGreetingServiceServer(grpcServer, &service.GreetingServiceGRPC{Next: service.GreetingService{}})
+	svcv2.RegisterGreetingServiceServer(grpcServer, svcv2.Server{Next: service.GreetingService{}})
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	var header metadata.MD
+	response, err := service.NewGreetingServiceClient(conn).GreetGRPC(context.Background(), &service.GRPCGreetRequest{S: "hello"}, grpc.Header(&header))
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", response.Greeting)
+	assert.Equal(t, []string{"@1000"}, header.Get("deprecation"))
+
+	header = nil
+	client := svcv2.NewGreetingServiceClient(conn)
+	responseV2, err := client.Greet(context.Background(), &svcv2.GreetRequest{Name: "hello", Formality: svcv2.Formality_FORMAL}, grpc.Header(&header))
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", responseV2.Greeting)
+	assert.Empty(t, header.Get("deprecation"))
+
+	_, err = client.Greet(context.Background(), &svcv2.GreetRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// Test_Reflection checks that reflection serves the schema of each API version: both
+// are in a file named greeting.proto, so they are registered under distinct paths.
+func Test_Reflection(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	grpcServer := grpc.NewServer()
+	service.RegisterGreetingServiceServer(grpcServer, &service.GreetingServiceGRPC{Next: service.GreetingService{}})
+	svcv2.RegisterGreetingServiceServer(grpcServer, svcv2.Server{Next: service.GreetingService{}})
+	reflection.Register(grpcServer)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer conn.Close()
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	assert.Nil(t, err)
+
+	tests := map[string]struct {
+		symbol          string
+		expectedFile    string
+		expectedPackage string
+	}{
+		"v1": {symbol: "svc.GreetingService", expectedFile: "greeting.proto", expectedPackage: "svc"},
+		"v2": {symbol: "svc.v2.GreetingService", expectedFile: "svc/v2/greeting.proto", expectedPackage: "svc.v2"},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		err := stream.Send(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: test.symbol},
+		})
+		assert.Nil(t, err)
+		response, err := stream.Recv()
+		assert.Nil(t, err)
+		files := response.GetFileDescriptorResponse().GetFileDescriptorProto()
+		if assert.NotEmpty(t, files) {
+			var fd descriptor.FileDescriptorProto
+			assert.Nil(t, proto.Unmarshal(files[0], &fd))
+			assert.Equal(t, test.expectedFile, fd.GetName())
+			assert.Equal(t, test.expectedPackage, fd.GetPackage())
+		}
+	}
+}
+
+func Test_GatewayParity(t *testing.T) {
+	policy := middleware.Policy{Methods: map[string]middleware.Rule{
+		"Greet":     {Roles: []string{"*"}},
+		"Expensive": {Roles: []string{"admin"}},
+	}}
+	newServer := func() *server {
+		return &server{
+			transport: HttpJson{},
+			svc:       middleware.AuthorizationMiddleware{Policy: policy, Next: service.GreetingService{}},
+		}
+	}
+
+	tests := map[string]struct {
+		path           string
+		body           string
+		gatewayBody    string
+		roles          string
+		acceptLanguage string
+	}{
+		"v1":                {path: "/v1/greeting", body: `{"s":"hello"}`},
+		"v1_locale":         {path: "/v1/greeting", body: `{"s":"hello","locale":"fr","formality":"formal"}`},
+		"v1_accept":         {path: "/greeting", body: `{"s":"hello"}`, acceptLanguage: "de"},
+		"v1_empty":          {path: "/v1/greeting", body: `{"s":""}`},
+		"v1_expensive":      {path: "/v1/expensive", body: `{"connection_string":"c1","username":"u1","password":"p1"}`, roles: "admin"},
+		"v1_expensive_deny": {path: "/expensive", body: `{"connection_string":"c1","username":"u1","password":"p1"}`},
+		"v2": {
+			path:        "/v2/greeting",
+			body:        `{"name":"hello","locale":"en","formality":"informal","count":2}`,
+			gatewayBody: `{"name":"hello","locale":"en","formality":"INFORMAL","count":2}`,
+		},
+		"v2_empty":          {path: "/v2/greeting", body: `{"name":""}`},
+		"v2_expensive":      {path: "/v2/expensive", body: `{"connection_string":"c1","username":"u1","password":"p1"}`, roles: "admin"},
+		"v2_expensive_deny": {path: "/v2/expensive", body: `{"connection_string":"c1","username":"u1","password":"p1"}`},
+	}
+
+	// result is what both bodies are compared on; the gateway reports status errors in
+	// message rather than err.
+	type result struct {
+		Greeting string `json:"greeting"`
+		Locale   string `json:"locale"`
+		Status   string `json:"status"`
+		Err      string `json:"err"`
+		Message  string `json:"message"`
+	}
+	serve := func(h http.Handler, path, body, roles, acceptLanguage string) (int, string, result) {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("X-Caller-Roles", roles)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		var r result
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &r))
+		r.Err, r.Message = r.Err+r.Message, ""
+		return w.Code, w.Header().Get("Content-Language"), r
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		s := newServer()
+		mux := http.NewServeMux()
+		register(mux, s.routes())
+		code, language, response := serve(mux, test.path, test.body, test.roles, test.acceptLanguage)
+
+		s = newServer()
+		gw, err := gateway.NewHandler(context.Background(), s.grpcV1(), s.grpcV2(), s.deprecation)
+		assert.Nil(t, err)
+		body := test.gatewayBody
+		if body == "" {
+			body = test.body
+		}
+		gatewayCode, gatewayLanguage, gatewayResponse := serve(gw, test.path, body, test.roles, test.acceptLanguage)
+
+		assert.NotEqual(t, result{}, response)
+		assert.Equal(t, gatewayCode, code)
+		assert.Equal(t, gatewayLanguage, language)
+		assert.Equal(t, gatewayResponse, response)
+	}
+}
+
+func Test_Localization(t *testing.T) {
+	tests := []struct {
+		name               string
+		path               string
+		body               []byte
+		acceptLanguage     string
+		expectedResponse   string
+		contentLanguage    string
+		httpStatusResponse int
+	}{
+		{
+			name:               "v1_no_locale",
+			path:               "/v1/greeting",
+			body:               []byte(`{"s":"hello"}`),
+			expectedResponse:   `{"greeting":"hello"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "v1_accept_language",
+			path:               "/v1/greeting",
+			body:               []byte(`{"s":"hello"}`),
+			acceptLanguage:     "xx, de-CH;q=0.9, en;q=0.8",
+			expectedResponse:   `{"greeting":"Hallo, hello!","locale":"de"}` + "\n",
+			contentLanguage:    "de",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "v1_locale_before_accept_language",
+			path:               "/v1/greeting",
+			body:               []byte(`{"s":"hello","locale":"fr","formality":"formal"}`),
+			acceptLanguage:     "de",
+			expectedResponse:   `{"greeting":"Bonjour, hello.","locale":"fr"}` + "\n",
+			contentLanguage:    "fr",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "v1_unknown_formality",
+			path:               "/v1/greeting",
+			body:               []byte(`{"s":"hello","formality":"casual"}`),
+			expectedResponse:   `{"greeting":"","err":"unknown formality \"casual\""}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "v1_batch",
+			path:               "/v1/greetings:batch",
+			body:               []byte(`[{"s":"hello","locale":"es"},{"s":"world"}]`),
+			acceptLanguage:     "it",
+			expectedResponse:   `{"results":[{"greeting":"¡Hola, hello!","locale":"es"},{"greeting":"Ciao, world!","locale":"it"}]}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "v2_plural",
+			path:               "/v2/greeting",
+			body:               []byte(`{"name":"team","locale":"ru-RU","count":22}`),
+			expectedResponse:   `{"greeting":"Привет, team (22 человека)!","locale":"ru"}` + "\n",
+			contentLanguage:    "ru",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "v2_unsupported_locale",
+			path:               "/v2/greeting",
+			body:               []byte(`{"name":"hello","locale":"xx"}`),
+			expectedResponse:   `{"greeting":"Hi, hello!","locale":"en"}` + "\n",
+			contentLanguage:    "en",
+			httpStatusResponse: http.StatusOK,
+		},
+	}
+
+	// the cache must not serve a greeting in another language
+	s := server{
+		transport: HttpJson{},
+		svc: middleware.CachingMiddleware{
+			Cache:   cache.NewLRU(10),
+			TTL:     time.Minute,
+			Lookups: discard.NewCounter(),
+			Logger:  log.New(os.Stdout, "LOG: ", log.Ldate|log.Ltime|log.Lshortfile),
+			Next:    service.GreetingService{},
+		},
+	}
+	mux := http.NewServeMux()
+	register(mux, s.routes())
+
+	for _, test := range tests {
+		t.Logf("Running test case: %s", test.name)
+		req, err := http.NewRequest("POST", test.path, bytes.NewBuffer(test.body))
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		if test.acceptLanguage != "" {
+			req.Header.Set("Accept-Language", test.acceptLanguage)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		assert.Equal(t, test.expectedResponse, w.Body.String())
+		assert.Equal(t, test.httpStatusResponse, w.Code)
+		assert.Equal(t, test.contentLanguage, w.Header().Get("Content-Language"))
+		assert.Equal(t, "Accept-Language", w.Header().Get("Vary"))
+	}
+}
+
+func Test_LocalizationGRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	grpcServer := grpc.NewServer()
+	service.RegisterGreetingServiceServer(grpcServer, &service.GreetingServiceGRPC{Next: service.GreetingService{}})
+	svcv2.RegisterGreetingServiceServer(grpcServer, svcv2.Server{Next: service.GreetingService{}})
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "accept-language", "nl-BE, en;q=0.5")
+
+	response, err := service.NewGreetingServiceClient(conn).GreetGRPC(ctx, &service.GRPCGreetRequest{S: "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hoi, hello!", response.Greeting)
+	assert.Equal(t, "nl", response.Locale)
+
+	response, err = service.NewGreetingServiceClient(conn).GreetGRPC(ctx, &service.GRPCGreetRequest{S: "hello", Locale: "sv", Formality: "formal", Count: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, "God dag, hello (2 personer).", response.Greeting)
+	assert.Equal(t, "sv", response.Locale)
+
+	response, err = service.NewGreetingServiceClient(conn).GreetGRPC(context.Background(), &service.GRPCGreetRequest{S: "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", response.Greeting)
+	assert.Empty(t, response.Locale)
+
+	responseV2, err := svcv2.NewGreetingServiceClient(conn).Greet(ctx, &svcv2.GreetRequest{Name: "hello", Formality: svcv2.Formality_FORMAL})
+	assert.Nil(t, err)
+	assert.Equal(t, "Goedendag, hello.", responseV2.Greeting)
+	assert.Equal(t, "nl", responseV2.Locale)
+}
+
+func Test_Templates(t *testing.T) {
+	templates, err := service.LoadTemplates("templates")
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name               string
+		path               string
+		body               []byte
+		expectedResponse   string
+		httpStatusResponse int
+	}{
+		{
+			name:               "v2",
+			path:               "/v2/greeting",
+			body:               []byte(`{"name":"hello","locale":"fr","template":"shout"}`),
+			expectedResponse:   `{"greeting":"SALUT, HELLO !","locale":"fr"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "v2_unknown",
+			path:               "/v2/greeting",
+			body:               []byte(`{"name":"hello","template":"missing"}`),
+			expectedResponse:   `{"greeting":"","err":"unknown template"}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		{
+			name:               "v1",
+			path:               "/v1/greeting",
+			body:               []byte(`{"s":"hello","template":"welcome","formality":"formal"}`),
+			expectedResponse:   `{"greeting":"Welcome aboard, hello. We are glad to have you."}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "v1_unknown",
+			path:               "/v1/greeting",
+			body:               []byte(`{"s":"hello","template":"missing"}`),
+			expectedResponse:   `{"greeting":"","err":"unknown template"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+	}
+
+	s := server{transport: HttpJson{}, svc: service.GreetingService{Templates: templates}}
+	mux := http.NewServeMux()
+	register(mux, s.routes())
+
+	for _, test := range tests {
+		t.Logf("Running test case: %s", test.name)
+		req, err := http.NewRequest("POST", test.path, bytes.NewBuffer(test.body))
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		assert.Equal(t, test.expectedResponse, w.Body.String())
+		assert.Equal(t, test.httpStatusResponse, w.Code)
+	}
+}
+
+func newTestHistory(t *testing.T) (*store.Bolt, func()) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := store.OpenBolt(filepath.Join(dir, "greetings.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func Test_History(t *testing.T) {
+	greetings, cleanup := newTestHistory(t)
+	defer cleanup()
+	logger := log.New(ioutil.Discard, "", 0)
+	s := server{
+		transport: HttpJson{},
+		svc:       middleware.PersistingMiddleware{Greetings: greetings, Logger: logger, Next: service.GreetingService{}},
+		greetings: greetings,
+	}
+	mux := http.NewServeMux()
+	register(mux, s.routes())
+
+	do := func(method, path, body string, header map[string]string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		assert.Nil(t, err)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	alice := map[string]string{"X-Caller-Id": "alice"}
+	before := time.Now().Add(-time.Second).UTC().Format(time.RFC3339Nano)
+	do("POST", "/v2/greeting", `{"name":"hello","locale":"de"}`, alice)
+	do("POST", "/v1/greeting", `{"s":"bob"}`, map[string]string{"X-Caller-Id": "bob"})
+	do("POST", "/v1/greeting", `{"s":"world"}`, alice)
+	do("POST", "/v1/greeting", `{"s":""}`, alice) // failed greetings are not recorded
+
+	w := do("GET", "/v2/greetings?since="+url.QueryEscape(before)+"&page_size=1", "", alice)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var page service.GreetingHistoryResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &page))
+	if assert.Len(t, page.Greetings, 1) {
+		first := page.Greetings[0]
+		assert.Equal(t, "hello", first.Name)
+		assert.Equal(t, "Hallo, hello!", first.Greeting)
+		assert.Equal(t, "de", first.Locale)
+		assert.Equal(t, "alice", first.Caller)
+
+		w = do("GET", "/v2/greetings/"+first.ID, "", alice)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var stored service.StoredGreeting
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &stored))
+		assert.Equal(t, first, stored)
+
+		// the greetings of other callers, anonymous ones included, are not found
+		for _, header := range []map[string]string{{"X-Caller-Id": "bob"}, nil} {
+			w = do("GET", "/v2/greetings/"+first.ID, "", header)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+		}
+	}
+	assert.NotEmpty(t, page.NextPageToken)
+
+	// bob's greeting is left out of alice's history
+	w = do("GET", "/v2/greetings?page_token="+page.NextPageToken, "", alice)
+	page = service.GreetingHistoryResponse{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &page))
+	if assert.Len(t, page.Greetings, 1) {
+		assert.Equal(t, "world", page.Greetings[0].Greeting)
+	}
+	assert.Empty(t, page.NextPageToken)
+
+	w = do("GET", "/v2/greetings", "", nil)
+	assert.Equal(t, `{"greetings":[]}`+"\n", w.Body.String())
+	w = do("GET", "/v2/greetings?since="+url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339)), "", alice)
+	assert.Equal(t, `{"greetings":[]}`+"\n", w.Body.String())
+
+	tests := []struct {
+		name               string
+		path               string
+		expectedResponse   string
+		httpStatusResponse int
+	}{
+		{
+			name:               "unknown_id",
+			path:               "/v2/greetings/missing",
+			expectedResponse:   `{"err":"greeting not found"}` + "\n",
+			httpStatusResponse: http.StatusNotFound,
+		},
+		{
+			name:               "invalid_since",
+			path:               "/v2/greetings?since=yesterday",
+			expectedResponse:   `{"greetings":[],"err":"since must be an RFC 3339 time"}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		{
+			name:               "invalid_page_token",
+			path:               "/v2/greetings?page_token=x",
+			expectedResponse:   `{"greetings":[],"err":"invalid page token"}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Logf("Running test case: %s", test.name)
+		w := do("GET", test.path, "", nil)
+		assert.Equal(t, test.expectedResponse, w.Body.String())
+		assert.Equal(t, test.httpStatusResponse, w.Code)
+	}
+
+	// both endpoints are under the History policy
+	s.authorization = &middleware.AuthorizationMiddleware{Policy: middleware.Policy{Methods: map[string]middleware.Rule{
+		"History": {Roles: []string{"user"}},
+	}}}
+	mux = http.NewServeMux()
+	register(mux, s.routes())
+	for _, path := range []string{"/v2/greetings", "/v2/greetings/1"} {
+		t.Logf("Running test case: denied %s", path)
+		w := do("GET", path, "", alice)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	}
+
+	// without a history both endpoints report it as disabled
+	s = server{transport: HttpJson{}, svc: service.GreetingService{}}
+	mux = http.NewServeMux()
+	register(mux, s.routes())
+	for _, path := range []string{"/v2/greetings", "/v2/greetings/1"} {
+		t.Logf("Running test case: disabled %s", path)
+		w := do("GET", path, "", nil)
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+		assert.Contains(t, w.Body.String(), "greeting history is disabled")
+	}
+}
+
+func Test_HistoryGRPC(t *testing.T) {
+	greetings, cleanup := newTestHistory(t)
+	defer cleanup()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(middleware.CallerUnaryServerInterceptor))
+	svcv2.RegisterGreetingServiceServer(grpcServer, svcv2.Server{
+		Next:      middleware.PersistingMiddleware{Greetings: greetings, Logger: log.New(ioutil.Discard, "", 0), Next: service.GreetingService{}},
+		Greetings: greetings,
+	})
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer conn.Close()
+	client := svcv2.NewGreetingServiceClient(conn)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-caller-id", "bob")
+	_, err = client.Greet(ctx, &svcv2.GreetRequest{Name: "hello"})
+	assert.Nil(t, err)
+
+	list, err := client.ListGreetings(ctx, &svcv2.ListGreetingsRequest{Since: time.Now().Add(-time.Minute).Format(time.RFC3339)})
+	assert.Nil(t, err)
+	if assert.Len(t, list.Greetings, 1) {
+		assert.Equal(t, "hello", list.Greetings[0].Greeting)
+		assert.Equal(t, "bob", list.Greetings[0].Caller)
+
+		g, err := client.GetGreeting(ctx, &svcv2.GetGreetingRequest{Id: list.Greetings[0].Id})
+		assert.Nil(t, err)
+		assert.Equal(t, list.Greetings[0].CreatedAt, g.CreatedAt)
+
+		// other callers do not see bob's greetings
+		_, err = client.GetGreeting(context.Background(), &svcv2.GetGreetingRequest{Id: list.Greetings[0].Id})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	}
+	list, err = client.ListGreetings(context.Background(), &svcv2.ListGreetingsRequest{})
+	assert.Nil(t, err)
+	assert.Empty(t, list.Greetings)
+
+	_, err = client.GetGreeting(ctx, &svcv2.GetGreetingRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.ListGreetings(ctx, &svcv2.ListGreetingsRequest{Since: "yesterday"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_Idempotency(t *testing.T) {
+	greetings, cleanup := newTestHistory(t)
+	defer cleanup()
+	logger := log.New(ioutil.Discard, "", 0)
+
+	tests := []struct {
+		name               string
+		path               string
+		body               string
+		key                string
+		expectedResponse   string
+		httpStatusResponse int
+	}{
+		{
+			name:               "first",
+			path:               "/v2/expensive",
+			body:               `{"connection_string":"c1","username":"u1","password":"p1"}`,
+			key:                "retry-1",
+			expectedResponse:   `{"status":"c1u1p1"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "retry",
+			path:               "/v2/expensive",
+			body:               `{"connection_string":"c1","username":"u1","password":"p1"}`,
+			key:                "retry-1",
+			expectedResponse:   `{"status":"c1u1p1"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "without_key",
+			path:               "/v2/expensive",
+			body:               `{"connection_string":"c1","username":"u1","password":"p1"}`,
+			expectedResponse:   `{"status":"already initialized"}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "mismatch",
+			path:               "/v2/expensive",
+			body:               `{"connection_string":"c2","username":"u1","password":"p1"}`,
+			key:                "retry-1",
+			expectedResponse:   `{"err":"Idempotency-Key was already used for a different request"}` + "\n",
+			httpStatusResponse: http.StatusUnprocessableEntity,
+		},
+		{
+			name:               "graphql_mutation",
+			path:               "/graphql",
+			body:               `{"query":"mutation { greet(s: \"hello\") { greeting } }"}`,
+			key:                "graphql-1",
+			expectedResponse:   `{"data":{"greet":{"greeting":"hello"}}}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "graphql_mismatch",
+			path:               "/graphql",
+			body:               `{"query":"mutation { greet(s: \"world\") { greeting } }"}`,
+			key:                "graphql-1",
+			expectedResponse:   `{"err":"Idempotency-Key was already used for a different request"}` + "\n",
+			httpStatusResponse: http.StatusUnprocessableEntity,
+		},
+	}
+
+	// responses are kept in the history file, so they survive a restart
+	s := server{transport: HttpJson{}, svc: service.GreetingService{}, idempotency: middleware.NewIdempotency(greetings.Cache(), time.Minute, logger)}
+	assert.Nil(t, s.initGraphQL())
+	mux := http.NewServeMux()
+	register(mux, s.routes())
+
+	for _, test := range tests {
+		t.Logf("Running test case: %s", test.name)
+		req, err := http.NewRequest("POST", test.path, strings.NewReader(test.body))
+		assert.Nil(t, err)
+		if test.key != "" {
+			req.Header.Set("Idempotency-Key", test.key)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		assert.Equal(t, test.expectedResponse, w.Body.String())
+		assert.Equal(t, test.httpStatusResponse, w.Code)
+	}
+
+	var doc openapi.Document
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	op := doc.Operation("POST", "/v2/expensive")
+	assert.Equal(t, "Idempotency-Key", op.Parameters[0].Name)
+	assert.Contains(t, op.Responses, "422")
+	assert.Contains(t, doc.Operation("POST", "/graphql").Responses, "422")
+	assert.Empty(t, doc.Operation("GET", "/v2/greetings").Responses["422"])
+}
+
+// blockingGreeter runs the expensive operation until it is cancelled.
+type blockingGreeter struct {
+	service.GreetingService
+	started chan struct{}
+}
+
+func (g blockingGreeter) Expensive(ctx context.Context, c, u, p string) (string, error) {
+	g.started <- struct{}{}
+	<-ctx.Done()
+	return "", service.ErrRequestCancelled
+}
+
+func Test_ExpensiveJobs(t *testing.T) {
+	pool := jobs.NewPool(1, 1, time.Minute)
+	defer pool.Close()
+	s := server{transport: HttpJson{}, svc: service.GreetingService{}, jobs: pool}
+	mux := http.NewServeMux()
+	register(mux, s.routes())
+
+	doAs := func(caller, method, path, body string) (*httptest.ResponseRecorder, service.ExpensiveJob) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("X-Caller-Id", caller)
+		mux.ServeHTTP(w, r)
+		var job service.ExpensiveJob
+		json.Unmarshal(w.Body.Bytes(), &job)
+		return w, job
+	}
+	do := func(method, path, body string) (*httptest.ResponseRecorder, service.ExpensiveJob) {
+		return doAs("alice", method, path, body)
+	}
+	poll := func(location string) service.ExpensiveJob {
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			w, job := do("GET", location, "")
+			assert.Equal(t, http.StatusOK, w.Code)
+			if job.State != "pending" && job.State != "running" || time.Now().After(deadline) {
+				return job
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	w, job := do("POST", "/v2/expensive/jobs", `{"connection_string":"c1","username":"u1","password":"p1"}`)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "pending", job.State)
+	location := w.Header().Get("Location")
+	assert.Equal(t, "/v2/expensive/jobs/"+job.ID, location)
+	job = poll(location)
+	assert.Equal(t, "succeeded", job.State)
+	// the result, which may carry the credentials, is left out
+	w, _ = do("GET", location, "")
+	assert.NotContains(t, w.Body.String(), "c1u1p1")
+
+	// other callers do not see or cancel the job
+	for _, method := range []string{"GET", "DELETE"} {
+		w, _ = doAs("bob", method, location, "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, `{"err":"job not found"}`+"\n", w.Body.String())
+	}
+
+	// jobs share the once-semantics of the synchronous endpoint, and later jobs fail
+	w, _ = do("POST", "/v2/expensive", `{"connection_string":"c1","username":"u1","password":"p1"}`)
+	assert.Equal(t, `{"status":"already initialized"}`+"\n", w.Body.String())
+	w, job = do("POST", "/v2/expensive/jobs", `{"connection_string":"c1","username":"u1","password":"p1"}`)
+	job = poll(w.Header().Get("Location"))
+	assert.Equal(t, "failed", job.State)
+	assert.Equal(t, service.ErrAlreadyInitialized.Error(), job.Err)
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		body               string
+		expectedResponse   string
+		httpStatusResponse int
+	}{
+		{
+			name:               "invalid_body",
+			method:             "POST",
+			path:               "/v2/expensive/jobs",
+			body:               `{`,
+			expectedResponse:   `{"err":"unexpected EOF"}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		{
+			name:               "unknown_id",
+			method:             "GET",
+			path:               "/v2/expensive/jobs/missing",
+			expectedResponse:   `{"err":"job not found"}` + "\n",
+			httpStatusResponse: http.StatusNotFound,
+		},
+		{
+			name:               "cancel_unknown_id",
+			method:             "DELETE",
+			path:               "/v2/expensive/jobs/missing",
+			expectedResponse:   `{"err":"job not found"}` + "\n",
+			httpStatusResponse: http.StatusNotFound,
+		},
+	}
+	for _, test := range tests {
+		t.Logf("Running test case: %s", test.name)
+		w, _ := do(test.method, test.path, test.body)
+		assert.Equal(t, test.expectedResponse, w.Body.String())
+		assert.Equal(t, test.httpStatusResponse, w.Code)
+	}
+
+	// cancelling a running job stops it; a full queue asks clients to retry
+	started := make(chan struct{}, 1)
+	blocking := server{transport: HttpJson{}, svc: blockingGreeter{started: started}, jobs: pool}
+	mux = http.NewServeMux()
+	register(mux, blocking.routes())
+	_, running := do("POST", "/v2/expensive/jobs", `{"connection_string":"c","username":"u","password":"p"}`)
+	<-started
+	_, pending := do("POST", "/v2/expensive/jobs", `{"connection_string":"c","username":"u","password":"p"}`)
+	w, _ = do("POST", "/v2/expensive/jobs", `{"connection_string":"c","username":"u","password":"p"}`)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, `{"err":"job queue is full"}`+"\n", w.Body.String())
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	for _, id := range []string{pending.ID, running.ID} {
+		w, job = do("DELETE", "/v2/expensive/jobs/"+id, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "cancelled", job.State)
+	}
+	assert.Equal(t, "cancelled", poll("/v2/expensive/jobs/"+running.ID).State)
+
+	disabled := server{transport: HttpJson{}, svc: service.GreetingService{}}
+	mux = http.NewServeMux()
+	register(mux, disabled.routes())
+	w, _ = do("GET", "/v2/expensive/jobs/"+job.ID, "")
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+
+	// the job routes are under the policy of the expensive operation
+	authorization := &middleware.AuthorizationMiddleware{Policy: middleware.Policy{Methods: map[string]middleware.Rule{
+		"Expensive": {Roles: []string{"admin"}},
+	}}}
+	authorized := server{transport: HttpJson{}, svc: service.GreetingService{}, jobs: pool, authorization: authorization}
+	mux = http.NewServeMux()
+	register(mux, authorized.routes())
+	for _, method := range []string{"POST", "GET", "DELETE"} {
+		path := "/v2/expensive/jobs"
+		if method != "POST" {
+			path += "/" + running.ID
+		}
+		w, _ = do(method, path, `{"connection_string":"c","username":"u","password":"p"}`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	}
+}
+
+func Test_ExpensiveJobsGRPC(t *testing.T) {
+	pool := jobs.NewPool(1, 1, time.Minute)
+	defer pool.Close()
+	started := make(chan struct{}, 1)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(middleware.CallerUnaryServerInterceptor))
+	svcv2.RegisterGreetingServiceServer(grpcServer, svcv2.Server{Next: blockingGreeter{started: started}, Jobs: pool})
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer conn.Close()
+	client := svcv2.NewGreetingServiceClient(conn)
+	ctx := context.Background()
+
+	op, err := client.StartExpensive(ctx, &svcv2.ExpensiveRequest{ConnectionString: "c", Username: "u", Password: "p"})
+	assert.Nil(t, err)
+	assert.False(t, op.Done)
+	assert.Equal(t, svcv2.OperationState_PENDING, op.State)
+	<-started
+
+	op, err = client.GetOperation(ctx, &svcv2.GetOperationRequest{Name: op.Name})
+	assert.Nil(t, err)
+	assert.Equal(t, svcv2.OperationState_RUNNING, op.State)
+
+	// other callers do not see or cancel the operation
+	other := metadata.AppendToOutgoingContext(ctx, "x-caller-id", "bob")
+	_, err = client.GetOperation(other, &svcv2.GetOperationRequest{Name: op.Name})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.CancelOperation(other, &svcv2.CancelOperationRequest{Name: op.Name})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	op, err = client.CancelOperation(ctx, &svcv2.CancelOperationRequest{Name: op.Name})
+	assert.Nil(t, err)
+	assert.True(t, op.Done)
+	assert.Equal(t, svcv2.OperationState_CANCELLED, op.State)
+	assert.Nil(t, op.Response)
+
+	_, err = client.GetOperation(ctx, &svcv2.GetOperationRequest{Name: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func Test_Webhooks(t *testing.T) {
+	received := make(chan []byte, 10)
+	var secret string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		if err := webhook.Verify(r.Header, secret, body, time.Minute); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received <- body
+	}))
+	defer receiver.Close()
+	next := func() (webhook.Event, string) {
+		body := <-received
+		var event webhook.Event
+		json.Unmarshal(body, &event)
+		return event, string(body)
+	}
+
+	logger := log.New(ioutil.Discard, "", 0)
+	webhooks := webhook.NewDispatcher(http.DefaultClient, retry.Policy{MaxAttempts: 2, InitialBackoff: time.Millisecond}, logger)
+	webhooks.AllowPrivate = true // the receiver listens on the loopback interface
+	defer webhooks.Close()
+	pool := jobs.NewPool(1, 1, time.Minute)
+	pool.OnDone = publishJobs(webhooks, logger)
+	defer pool.Close()
+	authorization := &middleware.AuthorizationMiddleware{
+		Policy: middleware.Policy{Methods: map[string]middleware.Rule{
+			"Greet":     {Roles: []string{"*"}},
+			"Expensive": {Roles: []string{"*"}},
+			"Webhooks":  {Roles: []string{"user"}},
+		}},
+		Logger: logger,
+		Next:   middleware.NotifyingMiddleware{Webhooks: webhooks, Logger: logger, Next: service.GreetingService{}},
+	}
+	s := server{
+		transport:     HttpJson{},
+		svc:           authorization,
+		jobs:          pool,
+		webhooks:      webhooks,
+		authorization: authorization,
+	}
+	mux := http.NewServeMux()
+	register(mux, s.routes())
+	doAs := func(caller, roles, method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("X-Caller-Id", caller)
+		r.Header.Set("X-Caller-Roles", roles)
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		return doAs("alice", "user", method, path, body)
+	}
+
+	w := do("POST", "/v2/webhooks", `{"url":"`+receiver.URL+`/hook"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var hook service.Webhook
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &hook))
+	assert.NotEmpty(t, hook.Secret)
+	assert.Equal(t, []string{}, hook.Events)
+	assert.Equal(t, "/v2/webhooks/"+hook.ID, w.Header().Get("Location"))
+	secret = hook.Secret
+
+	// the greetings of other callers are not delivered to alice's webhook
+	doAs("bob", "user", "POST", "/v2/greeting", `{"name":"bob"}`)
+	do("POST", "/v2/greeting", `{"name":"hello","locale":"de"}`)
+	event, _ := next()
+	assert.Equal(t, webhook.GreetingCreated, event.Type)
+	assert.Equal(t, map[string]interface{}{"name": "hello", "greeting": "Hallo, hello!", "locale": "de", "caller": "alice"}, event.Data)
+
+	// job events leave out the result, which carries the credentials
+	do("POST", "/v2/expensive/jobs", `{"connection_string":"db.example.com","username":"admin","password":"hunter2"}`)
+	event, body := next()
+	assert.Equal(t, webhook.ExpensiveJobCompleted, event.Type)
+	assert.Equal(t, "succeeded", event.Data.(map[string]interface{})["state"])
+	assert.NotContains(t, event.Data, "status")
+	assert.NotContains(t, body, "hunter2")
+
+	// the secret is only shown on registration
+	w = do("GET", "/v2/webhooks", "")
+	var list service.WebhookListResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &list))
+	if assert.Len(t, list.Webhooks, 1) {
+		assert.Equal(t, hook.ID, list.Webhooks[0].ID)
+		assert.Empty(t, list.Webhooks[0].Secret)
+	}
+
+	w = do("GET", "/v2/webhooks/deliveries?webhook_id="+hook.ID, "")
+	var deliveries service.WebhookDeliveriesResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &deliveries))
+	assert.Len(t, deliveries.Deliveries, 2)
+
+	// deliveries the receiver rejects end up as dead letters
+	assert.Equal(t, http.StatusNoContent, do("DELETE", "/v2/webhooks/"+hook.ID, "").Code)
+	w = do("POST", "/v2/webhooks", `{"url":"`+receiver.URL+`/gone","events":["greeting.created"]}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var gone service.Webhook
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &gone))
+	do("POST", "/v2/greeting", `{"name":"world"}`)
+	deadline := time.Now().Add(2 * time.Second)
+	for len(webhooks.DeadLetters("alice")) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	w = do("GET", "/v2/webhooks/dead-letters", "")
+	deliveries = service.WebhookDeliveriesResponse{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &deliveries))
+	if assert.Len(t, deliveries.Deliveries, 1) {
+		dead := deliveries.Deliveries[0]
+		assert.Equal(t, "dead", dead.State)
+		assert.Equal(t, "greeting.created", dead.EventType)
+		if assert.Len(t, dead.Attempts, 1) {
+			assert.Equal(t, http.StatusGone, dead.Attempts[0].StatusCode)
+		}
+	}
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		body               string
+		caller             string
+		roles              string
+		strict             bool
+		expectedResponse   string
+		httpStatusResponse int
+	}{
+		{
+			name:               "invalid_url",
+			method:             "POST",
+			path:               "/v2/webhooks",
+			body:               `{"url":"localhost/hook"}`,
+			expectedResponse:   `{"err":"webhook url must be an absolute http or https URL"}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		{
+			name:               "unknown_event",
+			method:             "POST",
+			path:               "/v2/webhooks",
+			body:               `{"url":"http://localhost/hook","events":["greeting.deleted"]}`,
+			expectedResponse:   `{"err":"unknown webhook event"}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		{
+			name:               "unknown_id",
+			method:             "DELETE",
+			path:               "/v2/webhooks/" + hook.ID,
+			expectedResponse:   `{"err":"webhook not found"}` + "\n",
+			httpStatusResponse: http.StatusNotFound,
+		},
+		{
+			name:               "private_url",
+			method:             "POST",
+			path:               "/v2/webhooks",
+			body:               `{"url":"http://169.254.169.254/latest/meta-data"}`,
+			expectedResponse:   `{"err":"webhook url must not point to a private, loopback or link-local address"}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+			strict:             true,
+		},
+		{
+			name:               "other_callers_webhook",
+			method:             "DELETE",
+			path:               "/v2/webhooks/" + gone.ID,
+			caller:             "bob",
+			expectedResponse:   `{"err":"webhook not found"}` + "\n",
+			httpStatusResponse: http.StatusNotFound,
+		},
+		{
+			name:               "other_callers_deliveries",
+			method:             "GET",
+			path:               "/v2/webhooks/dead-letters",
+			caller:             "bob",
+			expectedResponse:   `{"deliveries":[]}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		{
+			name:               "denied",
+			method:             "GET",
+			path:               "/v2/webhooks",
+			roles:              "guest",
+			expectedResponse:   `{"err":"permission denied"}` + "\n",
+			httpStatusResponse: http.StatusForbidden,
+		},
+	}
+	for _, test := range tests {
+		t.Logf("Running test case: %s", test.name)
+		caller, roles := test.caller, test.roles
+		if caller == "" {
+			caller = "alice"
+		}
+		if roles == "" {
+			roles = "user"
+		}
+		webhooks.AllowPrivate = !test.strict
+		w := doAs(caller, roles, test.method, test.path, test.body)
+		assert.Equal(t, test.expectedResponse, w.Body.String())
+		assert.Equal(t, test.httpStatusResponse, w.Code)
+	}
+}
+
+func Test_GraphQL(t *testing.T) {
+
+	tests := map[string]struct {
+		method             string
+		path               string
+		body               string
+		expectedResponse   string
+		httpStatusResponse int
+	}{
+		"query": {
+			method:             "POST",
+			path:               "/graphql",
+			body:               `{"query":"{ greet(s: \"hello\") { greeting locale } }"}`,
+			expectedResponse:   `{"data":{"greet":{"greeting":"hello","locale":null}}}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"query_variables": {
+			method:             "POST",
+			path:               "/graphql",
+			body:               `{"query":"query Greet($s: String!, $f: Formality) { greet(s: $s, locale: \"fr\", formality: $f) { greeting locale } }","variables":{"s":"hello","f":"FORMAL"}}`,
+			expectedResponse:   `{"data":{"greet":{"greeting":"Bonjour, hello.","locale":"fr"}}}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"query_get": {
+			method:             "GET",
+			path:               "/graphql?query=" + url.QueryEscape(`{ greet(s: "hello") { greeting } }`),
+			expectedResponse:   `{"data":{"greet":{"greeting":"hello"}}}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"mutation": {
+			method:             "POST",
+			path:               "/graphql",
+			body:               `{"query":"mutation { expensive(connectionString: \"c1\", username: \"u1\", password: \"p1\") { status } }"}`,
+			expectedResponse:   `{"data":{"expensive":{"status":"c1u1p1"}}}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"error_mutation_get": {
+			method:             "GET",
+			path:               "/graphql?query=" + url.QueryEscape(`mutation { greet(s: "hello") { greeting } }`),
+			expectedResponse:   `{"errors":[{"message":"no mutations are offered by the schema"}]}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		"error_service": {
+			method:             "POST",
+			path:               "/graphql",
+			body:               `{"query":"{ greet(s: \"\") { greeting } }"}`,
+			expectedResponse:   `{"data":{"greet":null},"errors":[{"message":"empty greeting","path":["greet"],"extensions":{"code":"InvalidArgument"}}]}` + "\n",
+			httpStatusResponse: http.StatusOK,
+		},
+		"error_syntax": {
+			method:             "POST",
+			path:               "/graphql",
+			body:               `{"query":"{ greet(s: \"hello\") { greeting }"}`,
+			expectedResponse:   `{"errors":[{"message":"syntax error: unexpected \"\", expecting Ident","locations":[{"line":1,"column":33}]}]}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		"error_body": {
+			method:             "POST",
+			path:               "/graphql",
+			body:               `{"query":`,
+			expectedResponse:   `{"errors":[{"message":"the body must be a JSON GraphQL request: unexpected EOF"}]}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		"error_body_too_large": {
+			method:             "POST",
+			path:               "/graphql",
+			body:               `{"query":"` + strings.Repeat(" ", maxGraphQLBody) + `{ greet(s: \"hello\") { greeting } }"}`,
+			expectedResponse:   `{"errors":[{"message":"the body must be a JSON GraphQL request: http: request body too large"}]}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		"error_too_deep": {
+			method:             "POST",
+			path:               "/graphql",
+			body:               `{"query":"{ __schema { types { fields { type { name } } } } }"}`,
+			expectedResponse:   `{"errors":[{"message":"Field \"type\" has depth 4 that exceeds max depth 3","locations":[{"line":1,"column":31}]}]}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		"error_subscription": {
+			method:             "POST",
+			path:               "/graphql",
+			body:               `{"query":"subscription { greetings(names: [\"a\"]) { greeting } }"}`,
+			expectedResponse:   `{"errors":[{"message":"Subscription operations must be subscribed to with Accept: text/event-stream."}]}` + "\n",
+			httpStatusResponse: http.StatusBadRequest,
+		},
+		"error_method": {
+			method:             "PUT",
+			path:               "/graphql",
+			expectedResponse:   "Method Not Allowed\n",
+			httpStatusResponse: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		s := server{transport: HttpJson{}, svc: service.GreetingService{}, graphqlMaxDepth: 3}
+		assert.Nil(t, s.initGraphQL())
+		mux := http.NewServeMux()
+		register(mux, s.graphqlRoutes())
+		r := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		assert.Equal(t, test.expectedResponse, w.Body.String())
+		assert.Equal(t, test.httpStatusResponse, w.Code)
+	}
+}
+
+func Test_GraphQLSubscription(t *testing.T) {
+	gauge := newFakeGauge()
+	s := server{transport: HttpJson{}, svc: service.GreetingService{}, streamConnections: gauge}
+	assert.Nil(t, s.initGraphQL())
+	mux := http.NewServeMux()
+	register(mux, s.graphqlRoutes())
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	req, err := http.NewRequest("POST", ts.URL+"/graphql", strings.NewReader(`{"query":"subscription { greetings(names: [\"a\", \"\", \"c\"]) { greeting } }"}`))
+	assert.Nil(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "event: next\ndata: {\"data\":{\"greetings\":{\"greeting\":\"a\"}}}\n\n"+
+		"event: next\ndata: {\"data\":{\"greetings\":null},\"errors\":[{\"message\":\"empty greeting\",\"path\":[\"greetings\",\"greeting\"],\"extensions\":{\"code\":\"InvalidArgument\"}}]}\n\n"+
+		"event: next\ndata: {\"data\":{\"greetings\":{\"greeting\":\"c\"}}}\n\n"+
+		"event: complete\ndata:\n\n", string(body))
+	assert.Eventually(t, func() bool { return gauge.value("graphql") == 0 }, time.Second, 10*time.Millisecond)
+
+	// a query sent as a GET is streamed as a single result
+	req, err = http.NewRequest("GET", ts.URL+"/graphql?query="+url.QueryEscape(`{ greet(s: "a") { greeting } }`), nil)
+	assert.Nil(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	body, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, "event: next\ndata: {\"data\":{\"greet\":{\"greeting\":\"a\"}}}\n\nevent: complete\ndata:\n\n", string(body))
+
+	resp, err = http.Get(ts.URL + "/graphql/schema")
+	assert.Nil(t, err)
+	body, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), "type Subscription {\n")
+	assert.Contains(t, string(body), "  greet(s: String!, locale: String, formality: Formality, count: Int, template: String): Greeting\n")
+}
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/tkeech1/gowebsvc/middleware"
+	"github.com/tkeech1/gowebsvc/openapi"
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+// route is an HTTP route of the server together with its OpenAPI description. The
+// routes are registered and documented from the same list, so /openapi.json always
+// lists what is served.
+type route struct {
+	openapi.Route
+	handler http.Handler
+
+	// successor is the path of the v2 route replacing a v1 route, if any.
+	successor string
+
+	// streaming routes write for as long as the client listens, so they are exempt
+	// from the write timeout.
+	streaming bool
+	// stream, if set, serves the requests of the route accepting text/event-stream,
+	// which are exempt from idempotency and the write timeout like streaming routes.
+	stream http.Handler
+}
+
+func jsonBody(description string, v interface{}) openapi.Body {
+	return openapi.Body{Description: description, ContentType: "application/json", Value: v}
+}
+
+// routes returns the v1 routes under /v1, the v2 routes under /v2 and, for clients
+// predating versioning, the v1 routes at their unversioned paths, followed by the
+// GraphQL routes. Unversioned routes and v1 routes with a v2 successor announce their
+// deprecation.
+func (s *server) routes() []route {
+	var routes []route
+	for _, r := range s.v1Routes() {
+		legacy := r
+		legacy.successor = "/v1" + r.Path
+		if r.successor != "" {
+			legacy.successor = "/v2" + r.successor
+		}
+		routes = append(routes, s.deprecate(legacy))
+
+		r.Path = "/v1" + r.Path
+		r.OperationID = "v1" + strings.Title(r.OperationID)
+		if r.successor != "" {
+			r.successor = "/v2" + r.successor
+			r = s.deprecate(r)
+		}
+		routes = append(routes, r)
+	}
+	for _, r := range s.v2Routes() {
+		r.Path = "/v2" + r.Path
+		r.OperationID = "v2" + strings.Title(r.OperationID)
+		routes = append(routes, r)
+	}
+	routes = append(routes, s.graphqlRoutes()...)
+	if s.idempotency != nil {
+		for i, r := range routes {
+			if r.Method == "POST" && !r.streaming {
+				routes[i] = s.idempotent(r)
+			}
+		}
+	}
+	if s.writeTimeout > 0 {
+		for i, r := range routes {
+			if !r.streaming {
+				routes[i] = s.timeout(r)
+			}
+		}
+	}
+	return routes
+}
+
+// timeout answers r with 503 Service Unavailable when its handler takes longer than
+// the write timeout. The HTTP server sets no WriteTimeout of its own, which would cut
+// off the streaming routes too.
+func (s *server) timeout(r route) route {
+	body, _ := json.Marshal(service.ErrorResponse{Err: service.ErrRequestTimedOut.Error()})
+	r.handler = http.TimeoutHandler(r.handler, s.writeTimeout, string(body))
+	if _, ok := r.Responses[http.StatusServiceUnavailable]; !ok {
+		responses := map[int]openapi.Body{
+			http.StatusServiceUnavailable: jsonBody("The request took longer than the write timeout.", service.ErrorResponse{}),
+		}
+		for code, b := range r.Responses {
+			responses[code] = b
+		}
+		r.Responses = responses
+	}
+	return r
+}
+
+// idempotent makes r replay its first response to requests repeating an
+// Idempotency-Key.
+func (s *server) idempotent(r route) route {
+	r.handler = s.idempotency.Handler(r.handler)
+	r.Headers = append(r.Headers, openapi.Parameter{
+		Name:        middleware.IdempotencyKeyHeader,
+		Description: "Unique key of the request; retries with the same key get the first response replayed.",
+		Schema:      openapi.SchemaOf(""),
+	})
+	responses := map[int]openapi.Body{
+		http.StatusUnprocessableEntity: jsonBody("The Idempotency-Key was used for a different request.", service.ErrorResponse{}),
+	}
+	for code, b := range r.Responses {
+		responses[code] = b
+	}
+	r.Responses = responses
+	return r
+}
+
+// authorize puts handler under the authorization policy for method, if there is one.
+func (s *server) authorize(method string, handler http.Handler) http.Handler {
+	if s.authorization == nil {
+		return handler
+	}
+	return s.authorization.Handler(method, handler)
+}
+
+func (s *server) deprecate(r route) route {
+	d := s.deprecation
+	d.Successor = r.successor
+	r.handler = d.Handler(r.handler)
+	r.Deprecated = true
+	return r
+}
+
+func (s *server) v2Routes() []route {
+	return []route{
+		{
+			Route: openapi.Route{
+				Method:      "POST",
+				Path:        "/greeting",
+				OperationID: "greet",
+				Summary:     "Returns a greeting for name in the requested locale and formality.",
+				Request:     service.GreetRequestV2{},
+				Responses: map[int]openapi.Body{
+					http.StatusOK:          jsonBody("The greeting.", service.GreetResponse{}),
+					http.StatusNotModified: {Description: "The greeting matches If-None-Match."},
+					http.StatusBadRequest:  jsonBody("The request is invalid; the reason is in err.", service.GreetResponse{}),
+					http.StatusForbidden:   jsonBody("The caller may not greet.", service.GreetResponse{}),
+				},
+			},
+			handler: s.handleGreetingV2(),
+		},
+		{
+			Route: openapi.Route{
+				Method:      "POST",
+				Path:        "/expensive",
+				OperationID: "expensive",
+				Summary:     "Runs the expensive operation once; later calls report it as already initialized.",
+				Request:     service.ExpensiveRequest{},
+				Responses: map[int]openapi.Body{
+					http.StatusOK:             jsonBody("The status.", service.ExpensiveResponse{}),
+					http.StatusBadRequest:     jsonBody("The request is invalid; the reason is in err.", service.ExpensiveResponse{}),
+					http.StatusForbidden:      jsonBody("The caller may not run the operation.", service.ExpensiveResponse{}),
+					http.StatusGatewayTimeout: jsonBody("The operation timed out.", service.ExpensiveResponse{}),
+				},
+			},
+			handler: s.handleExpensiveV2(),
+		},
+		{
+			Route: openapi.Route{
+				Method:      "POST",
+				Path:        "/expensive/jobs",
+				OperationID: "startExpensiveJob",
+				Summary:     "Starts the expensive operation in the background; poll the returned job at its Location.",
+				Request:     service.ExpensiveRequest{},
+				Responses: map[int]openapi.Body{
+					http.StatusAccepted:           jsonBody("The queued job.", service.ExpensiveJob{}),
+					http.StatusBadRequest:         jsonBody("The request is invalid; the reason is in err.", service.ErrorResponse{}),
+					http.StatusForbidden:          jsonBody("The caller may not run the operation.", service.ErrorResponse{}),
+					http.StatusNotImplemented:     jsonBody("Background jobs are disabled.", service.ErrorResponse{}),
+					http.StatusServiceUnavailable: jsonBody("The job queue is full; retry after Retry-After seconds.", service.ErrorResponse{}),
+				},
+			},
+			handler: s.authorize("Expensive", s.handleStartExpensiveJob()),
+		},
+		{
+			Route: openapi.Route{
+				Method:      "GET",
+				Path:        "/expensive/jobs/{id}",
+				OperationID: "getExpensiveJob",
+				Summary:     "Returns the state of one of the caller's expensive operation jobs; the result is left out, as it may carry the credentials.",
+				Params: []openapi.Parameter{
+					{Name: "id", Description: "ID of the job.", Schema: openapi.SchemaOf("")},
+				},
+				Responses: map[int]openapi.Body{
+					http.StatusOK:             jsonBody("The job.", service.ExpensiveJob{}),
+					http.StatusForbidden:      jsonBody("The caller may not run the operation.", service.ErrorResponse{}),
+					http.StatusNotFound:       jsonBody("The caller has no job with this ID, or it has expired.", service.ErrorResponse{}),
+					http.StatusNotImplemented: jsonBody("Background jobs are disabled.", service.ErrorResponse{}),
+				},
+			},
+			handler: s.authorize("Expensive", s.handleGetExpensiveJob()),
+		},
+		{
+			Route: openapi.Route{
+				Method:      "DELETE",
+				Path:        "/expensive/jobs/{id}",
+				OperationID: "cancelExpensiveJob",
+				Summary:     "Cancels one of the caller's expensive operation jobs that is not done yet.",
+				Params: []openapi.Parameter{
+					{Name: "id", Description: "ID of the job.", Schema: openapi.SchemaOf("")},
+				},
+				Responses: map[int]openapi.Body{
+					http.StatusOK:             jsonBody("The job after cancelling it.", service.ExpensiveJob{}),
+					http.StatusForbidden:      jsonBody("The caller may not run the operation.", service.ErrorResponse{}),
+					http.StatusNotFound:       jsonBody("The caller has no job with this ID, or it has expired.", service.ErrorResponse{}),
+					http.StatusNotImplemented: jsonBody("Background jobs are disabled.", service.ErrorResponse{}),
+				},
+			},
+			handler: s.authorize("Expensive", s.handleCancelExpensiveJob()),
+		},
+		{
+			Route: openapi.Route{
+				Method:      "POST",
+				Path:        "/webhooks",
+				OperationID: "createWebhook",
+				Summary:     "Registers a callback URL for the webhook events (greeting.created, expensive_job.completed) of the caller's own calls, signed with HMAC-SHA256. Private, loopback and link-local addresses are rejected.",
+				Request:     service.WebhookRequest{},
+				Responses: map[int]openapi.Body{
+					http.StatusCreated:        jsonBody("The webhook, with the secret signing its deliveries.", service.Webhook{}),
+					http.StatusBadRequest:     jsonBody("The request is invalid; the reason is in err.", service.ErrorResponse{}),
+					http.StatusForbidden:      jsonBody("The caller may not manage webhooks.", service.ErrorResponse{}),
+					http.StatusNotImplemented: jsonBody("Webhooks are disabled.", service.ErrorResponse{}),
+				},
+			},
+			handler: s.authorize("Webhooks", s.handleCreateWebhook()),
+		},
+		{
+			Route: openapi.Route{
+				Method:      "GET",
+				Path:        "/webhooks",
+				OperationID: "listWebhooks",
+				Summary:     "Lists the webhooks registered by the caller, oldest first.",
+				Responses: map[int]openapi.Body{
+					http.StatusOK:             jsonBody("The webhooks.", service.WebhookListResponse{}),
+					http.StatusForbidden:      jsonBody("The caller may not manage webhooks.", service.ErrorResponse{}),
+					http.StatusNotImplemented: jsonBody("Webhooks are disabled.", service.WebhookListResponse{}),
+				},
+			},
+			handler: s.authorize("Webhooks", s.handleListWebhooks()),
+		},
+		{
+			Route: openapi.Route{
+				Method:      "DELETE",
+				Path:        "/webhooks/{id}",
+				OperationID: "deleteWebhook",
+				Summary:     "Removes a webhook of the caller; deliveries already started are still retried.",
+				Params: []openapi.Parameter{
+					{Name: "id", Description: "ID of the webhook.", Schema: openapi.SchemaOf("")},
+				},
+				Responses: map[int]openapi.Body{
+					http.StatusNoContent:      {Description: "The webhook was removed."},
+					http.StatusNotFound:       jsonBody("There is no webhook with this ID.", service.ErrorResponse{}),
+					http.StatusForbidden:      jsonBody("The caller may not manage webhooks.", service.ErrorResponse{}),
+					http.StatusNotImplemented: jsonBody("Webhooks are disabled.", service.ErrorResponse{}),
+				},
+			},
+			handler: s.authorize("Webhooks", s.handleDeleteWebhook()),
+		},
+		{
+			Route: openapi.Route{
+				Method:      "GET",
+				Path:        "/webhooks/deliveries",
+				OperationID: "listWebhookDeliveries",
+				Summary:     "Lists the recent deliveries to the caller's webhooks and their attempts, oldest first.",
+				Query: []openapi.Parameter{
+					{Name: "webhook_id", Description: "Only list the deliveries to this webhook.", Schema: openapi.SchemaOf("")},
+				},
+				Responses: map[int]openapi.Body{
+					http.StatusOK:             jsonBody("The deliveries.", service.WebhookDeliveriesResponse{}),
+					http.StatusForbidden:      jsonBody("The caller may not manage webhooks.", service.ErrorResponse{}),
+					http.StatusNotImplemented: jsonBody("Webhooks are disabled.", service.WebhookDeliveriesResponse{}),
+				},
+			},
+			handler: s.authorize("Webhooks", s.handleWebhookDeliveries()),
+		},
+		{
+			Route: openapi.Route{
+				Method:      "GET",
+				Path:        "/webhooks/dead-letters",
+				OperationID: "listWebhookDeadLetters",
+				Summary:     "Lists the deliveries to the caller's webhooks that failed for good, oldest first.",
+				Responses: map[int]openapi.Body{
+					http.StatusOK:             jsonBody("The dead deliveries.", service.WebhookDeliveriesResponse{}),
+					http.StatusForbidden:      jsonBody("The caller may not manage webhooks.", service.ErrorResponse{}),
+					http.StatusNotImplemented: jsonBody("Webhooks are disabled.", service.WebhookDeliveriesResponse{}),
+				},
+			},
+			handler: s.authorize("Webhooks", s.handleWebhookDeadLetters()),
+		},
+		{
+			Route: openapi.Route{
+				Method:      "GET",
+				Path:        "/greetings/{id}",
+				OperationID: "getGreeting",
+				Summary:     "Returns one of the caller's greetings from the history.",
+				Params: []openapi.Parameter{
+					{Name: "id", Description: "ID of the greeting.", Schema: openapi.SchemaOf("")},
+				},
+				Responses: map[int]openapi.Body{
+					http.StatusOK:             jsonBody("The greeting.", service.StoredGreeting{}),
+					http.StatusForbidden:      jsonBody("The caller may not read the history.", service.ErrorResponse{}),
+					http.StatusNotFound:       jsonBody("The caller has no greeting with this ID.", service.ErrorResponse{}),
+					http.StatusNotImplemented: jsonBody("The history is not recorded.", service.ErrorResponse{}),
+				},
+			},
+			handler: s.authorize("History", s.handleGetGreeting()),
+		},
+		{
+			Route: openapi.Route{
+				Method:      "GET",
+				Path:        "/greetings",
+				OperationID: "listGreetings",
+				Summary:     "Lists the caller's greeting history, oldest first.",
+				Query: []openapi.Parameter{
+					{Name: "since", Description: "Only list greetings created at or after this RFC 3339 time.", Schema: openapi.SchemaOf("")},
+					{Name: "page_token", Description: "next_page_token of the previous page.", Schema: openapi.SchemaOf("")},
+					{Name: "page_size", Description: "Maximum number of greetings returned.", Schema: openapi.SchemaOf(0)},
+				},
+				Responses: map[int]openapi.Body{
+					http.StatusOK:             jsonBody("A page of greetings.", service.GreetingHistoryResponse{}),
+					http.StatusBadRequest:     jsonBody("A parameter is invalid; the reason is in err.", service.GreetingHistoryResponse{}),
+					http.StatusForbidden:      jsonBody("The caller may not read the history.", service.ErrorResponse{}),
+					http.StatusNotImplemented: jsonBody("The history is not recorded.", service.GreetingHistoryResponse{}),
+				},
+			},
+			handler: s.authorize("History", s.handleListGreetings()),
+		},
+	}
+}
+
+func (s *server) v1Routes() []route {
+	return []route{
+		{
+			Route: openapi.Route{
+				Method:      "POST",
+				Path:        "/greeting",
+				OperationID: "greet",
+				Summary:     "Returns a greeting for s.",
+				Request:     service.GreetRequest{},
+				Responses: map[int]openapi.Body{
+					http.StatusOK:          jsonBody("The greeting, or the reason it failed in err.", service.GreetResponse{}),
+					http.StatusNotModified: {Description: "The greeting matches If-None-Match."},
+					http.StatusForbidden:   jsonBody("The caller may not greet.", service.GreetResponse{}),
+				},
+			},
+			handler:   s.handleGreeting(),
+			successor: "/greeting",
+		},
+		{
+			Route: openapi.Route{
+				Method:      "POST",
+				Path:        "/greetings:batch",
+				OperationID: "greetBatch",
+				Summary:     "Returns the greetings for a list of requests, in order.",
+				Request:     []service.GreetRequest{},
+				Responses: map[int]openapi.Body{
+					http.StatusOK:                    jsonBody("The results; failed greetings set their own err.", service.GreetBatchResponse{}),
+					http.StatusBadRequest:            jsonBody("The body is not a list of greeting requests.", service.GreetBatchResponse{}),
+					http.StatusRequestEntityTooLarge: jsonBody("The batch exceeds the maximum size, or its body 1 MiB.", service.GreetBatchResponse{}),
+				},
+			},
+			handler: s.handleGreetingBatch(),
+		},
+		{
+			Route: openapi.Route{
+				Method:      "GET",
+				Path:        "/greeting/stream",
+				OperationID: "greetStream",
+				Summary:     "Streams a greeting event per s parameter as Server-Sent Events.",
+				Query: []openapi.Parameter{
+					{Name: "s", Description: "Name to greet; repeat for several greetings.", Schema: openapi.SchemaOf([]string{})},
+				},
+				Responses: map[int]openapi.Body{
+					http.StatusOK: {Description: "greeting events carrying a JSON greeting response, then an end event.", ContentType: "text/event-stream"},
+				},
+			},
+			handler:   s.handleGreetingStream(),
+			streaming: true,
+		},
+		{
+			Route: openapi.Route{
+				Method:      "GET",
+				Path:        "/greeting/ws",
+				OperationID: "greetWebSocket",
+				Summary:     "Answers every greeting request message on a WebSocket with its greeting response.",
+				Responses: map[int]openapi.Body{
+					http.StatusSwitchingProtocols: {Description: "The WebSocket is open."},
+					http.StatusBadRequest:         {Description: "The request is not a WebSocket handshake.", ContentType: "text/plain"},
+				},
+			},
+			handler:   s.handleGreetingWebSocket(),
+			streaming: true,
+		},
+		{
+			Route: openapi.Route{
+				Method:      "POST",
+				Path:        "/expensive",
+				OperationID: "expensive",
+				Summary:     "Runs the expensive operation once; later calls report it as already initialized.",
+				Request:     service.ExpensiveRequest{},
+				Responses: map[int]openapi.Body{
+					http.StatusOK:        jsonBody("The status, or the reason it failed in err.", service.ExpensiveResponse{}),
+					http.StatusForbidden: jsonBody("The caller may not run the operation.", service.ExpensiveResponse{}),
+				},
+			},
+			handler:   s.handleExpensive(),
+			successor: "/expensive",
+		},
+	}
+}
+
+// register adds the routes to mux, answering 405 Method Not Allowed for undeclared
+// methods, and serves their OpenAPI document at /openapi.json. A path ending in a
+// {parameter} is registered as a subtree; its handler reads the parameter from the
+// last path segment.
+func register(mux *http.ServeMux, routes []route) {
+	byPath := map[string]map[string]http.Handler{}
+	var specs []openapi.Route
+	for _, r := range routes {
+		if byPath[r.Path] == nil {
+			byPath[r.Path] = map[string]http.Handler{}
+		}
+		byPath[r.Path][r.Method] = r.handler
+		if r.stream != nil {
+			byPath[r.Path][r.Method] = eventStream(r.stream, r.handler)
+		}
+		specs = append(specs, r.Route)
+	}
+	for path, handlers := range byPath {
+		if i := strings.Index(path, "{"); i >= 0 {
+			path = path[:i]
+		}
+		mux.Handle(path, middleware.CallerHandler(middleware.AcceptLanguageHandler(methods(handlers))))
+	}
+	mux.Handle("/openapi.json", handleOpenAPI(openapi.New("Greeting Service", "1.0.0", specs)))
+}
+
+// eventStream passes the requests accepting text/event-stream on to stream, and the
+// others to handler.
+func eventStream(stream, handler http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			stream.ServeHTTP(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}
+}
+
+func methods(handlers map[string]http.Handler) http.HandlerFunc {
+	var allowed []string
+	for method := range handlers {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	return func(w http.ResponseWriter, r *http.Request) {
+		h, ok := handlers[r.Method]
+		if !ok {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}
+}
+
+func handleOpenAPI(doc *openapi.Document) http.HandlerFunc {
+	body, err := json.MarshalIndent(doc, "", "  ")
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/soheilhy/cmux"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+)
+
+// httpConfig holds the settings shared by the HTTP servers.
+type httpConfig struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	// H2C accepts HTTP/2 without TLS, either with prior knowledge or as an upgrade from
+	// HTTP/1.1.
+	H2C                  bool
+	MaxConcurrentStreams uint32
+}
+
+// newHTTPServer returns a server for handler with the timeouts of c. HTTP/2 is
+// negotiated over TLS when tlsConfig is set, and spoken in cleartext when c.H2C is set;
+// both use c.MaxConcurrentStreams and c.IdleTimeout.
+func newHTTPServer(addr string, handler http.Handler, tlsConfig *tls.Config, c httpConfig) (*http.Server, error) {
+	if handler == nil {
+		handler = http.DefaultServeMux
+	}
+	h2 := &http2.Server{MaxConcurrentStreams: c.MaxConcurrentStreams, IdleTimeout: c.IdleTimeout}
+	if c.H2C {
+		handler = h2c.NewHandler(handler, h2)
+	}
+	s := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: c.ReadHeaderTimeout,
+		ReadTimeout:       c.ReadTimeout,
+		WriteTimeout:      c.WriteTimeout,
+		IdleTimeout:       c.IdleTimeout,
+	}
+	if tlsConfig != nil {
+		if err := http2.ConfigureServer(s, h2); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// serveSinglePort serves GRPC and HTTP on one listener. A connection is handed to the
+// GRPC server when it speaks HTTP/2 and its first HEADERS frame carries the
+// application/grpc content-type; everything else goes to the HTTP server. With TLS the
+// listener terminates TLS before sniffing, so grpcServer must not have transport
+// credentials of its own and httpServer must accept h2c to serve HTTP/2 clients. It
+// returns when the listener is closed.
+func serveSinglePort(lis net.Listener, tlsConfig *tls.Config, grpcServer *grpc.Server, httpServer *http.Server) error {
+	if tlsConfig != nil {
+		cfg := tlsConfig.Clone()
+		cfg.NextProtos = []string{"h2", "http/1.1"}
+		lis = tls.NewListener(lis, cfg)
+	}
+
+	m := cmux.New(lis)
+	grpcL := m.MatchWithWriters(cmux.HTTP2MatchHeaderFieldSendSettings("content-type", "application/grpc"))
+	httpL := m.Match(cmux.Any())
+
+	go grpcServer.Serve(grpcL)
+	go httpServer.Serve(httpL)
+	return m.Serve()
+}
+
+// shutdown stops the servers from accepting new connections and waits for in-flight
+// requests and RPCs to finish. Whatever is still running when ctx expires is cut off.
+// Nil servers are skipped.
+func shutdown(ctx context.Context, grpcServer *grpc.Server, httpServers ...*http.Server) {
+	var wg sync.WaitGroup
+	for _, s := range httpServers {
+		if s == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(s *http.Server) {
+			defer wg.Done()
+			if err := s.Shutdown(ctx); err != nil {
+				s.Close()
+			}
+		}(s)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	service "github.com/tkeech1/gowebsvc/svc"
+)
+
+const defaultHeartbeat = 15 * time.Second
+
+// streamBuffer is the number of greetings queued for a WebSocket client. When it is
+// full the server stops reading names from that client until it catches up.
+const streamBuffer = 16
+
+var upgrader = websocket.Upgrader{}
+
+func (s *server) heartbeatInterval() time.Duration {
+	if s.heartbeat > 0 {
+		return s.heartbeat
+	}
+	return defaultHeartbeat
+}
+
+// handleGreetingStream streams greetings as Server-Sent Events. Names are given as
+// repeated s query parameters and each result is sent as a "greeting" event with the
+// index of the name as its id; an "end" event follows the last one. A reconnecting
+// client sending Last-Event-ID resumes after that greeting. The next name is only
+// greeted once the previous event was flushed, so a slow client slows the stream down
+// instead of results piling up, and comment lines are sent as heartbeats while a
+// greeting is pending.
+func (s *server) handleGreetingStream() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		s.streamConnections.With("transport", "sse").Add(1)
+		defer s.streamConnections.With("transport", "sse").Add(-1)
+
+		names := r.URL.Query()["s"]
+		start := 0
+		if id, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil {
+			start = id + 1
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(s.heartbeatInterval())
+		defer heartbeat.Stop()
+
+		for i := start; i < len(names); i++ {
+			result := s.greetAsync(ctx, service.GreetRequest{S: names[i]})
+			var response service.GreetResponse
+		wait:
+			for {
+				select {
+				case response = <-result:
+					break wait
+				case <-heartbeat.C:
+					fmt.Fprint(w, ": heartbeat\n\n")
+					flusher.Flush()
+				case <-ctx.Done():
+					return
+				}
+			}
+			data, _ := json.Marshal(response)
+			fmt.Fprintf(w, "id: %d\nevent: greeting\ndata: %s\n\n", i, data)
+			flusher.Flush()
+		}
+		fmt.Fprint(w, "event: end\ndata: {}\n\n")
+		flusher.Flush()
+	}
+}
+
+// handleGreetingWebSocket greets every {"s": ...} message received on a WebSocket and
+// sends back the responses in order. The server pings the client every heartbeat
+// interval and drops the connection when no pong arrives within two intervals.
+func (s *server) handleGreetingWebSocket() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.streamConnections.With("transport", "websocket").Add(1)
+		defer s.streamConnections.With("transport", "websocket").Add(-1)
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		interval := s.heartbeatInterval()
+		conn.SetReadDeadline(time.Now().Add(2 * interval))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * interval))
+		})
+
+		out := make(chan service.GreetResponse, streamBuffer)
+		go func() {
+			defer close(out)
+			for {
+				_, message, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				conn.SetReadDeadline(time.Now().Add(2 * interval))
+				var gr service.GreetRequest
+				var response service.GreetResponse
+				if err := json.Unmarshal(message, &gr); err != nil {
+					response = service.GreetResponse{V: "", Err: err.Error()}
+				} else {
+					response = <-s.greetAsync(ctx, gr)
+				}
+				select {
+				case out <- response:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		heartbeat := time.NewTicker(interval)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case response, ok := <-out:
+				if !ok {
+					return
+				}
+				conn.SetWriteDeadline(time.Now().Add(interval))
+				if err := conn.WriteJSON(response); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(interval)); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *server) greetAsync(ctx context.Context, gr service.GreetRequest) <-chan service.GreetResponse {
+	result := make(chan service.GreetResponse, 1)
+	go func() {
+		ctx, err := service.NewRequestOptionsContext(ctx, gr.Locale, gr.Formality, gr.Count, gr.Template)
+		if err != nil {
+			result <- service.GreetResponse{V: "", Err: err.Error()}
+			return
+		}
+		greeting, err := s.svc.Greet(ctx, gr.S)
+		if err != nil {
+			result <- service.GreetResponse{V: "", Err: err.Error()}
+			return
+		}
+		result <- service.GreetResponse{V: greeting, Err: "", Locale: service.ResolveLocale(ctx)}
+	}()
+	return result
+}
@@ -10,8 +10,19 @@ import (
 type HttpJsonCoderDecoder interface {
 	DecodeGreetingServiceRequest(*http.Request) (service.GreetRequest, error)
 	EncodeGreetingServiceRequest(*http.ResponseWriter, service.GreetResponse) error
+	DecodeGreetingBatchRequest(*http.Request) ([]service.GreetRequest, error)
+	EncodeGreetingBatchResponse(*http.ResponseWriter, service.GreetBatchResponse) error
+	DecodeGreetingV2Request(*http.Request) (service.GreetRequestV2, error)
 	DecodeExpensiveServiceRequest(*http.Request) (service.ExpensiveRequest, error)
 	EncodeExpensiveServiceRequest(*http.ResponseWriter, service.ExpensiveResponse) error
+	EncodeStoredGreeting(*http.ResponseWriter, service.StoredGreeting) error
+	EncodeGreetingHistoryResponse(*http.ResponseWriter, service.GreetingHistoryResponse) error
+	EncodeErrorResponse(*http.ResponseWriter, service.ErrorResponse) error
+	EncodeExpensiveJob(*http.ResponseWriter, service.ExpensiveJob) error
+	DecodeWebhookRequest(*http.Request) (service.WebhookRequest, error)
+	EncodeWebhook(*http.ResponseWriter, service.Webhook) error
+	EncodeWebhookListResponse(*http.ResponseWriter, service.WebhookListResponse) error
+	EncodeWebhookDeliveriesResponse(*http.ResponseWriter, service.WebhookDeliveriesResponse) error
 }
 
 type HttpJson struct{}
@@ -28,6 +39,26 @@ func (s HttpJson) EncodeGreetingServiceRequest(w *http.ResponseWriter, response
 	return json.NewEncoder(*w).Encode(response)
 }
 
+func (s HttpJson) DecodeGreetingBatchRequest(r *http.Request) ([]service.GreetRequest, error) {
+	var request []service.GreetRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func (s HttpJson) EncodeGreetingBatchResponse(w *http.ResponseWriter, response service.GreetBatchResponse) error {
+	return json.NewEncoder(*w).Encode(response)
+}
+
+func (s HttpJson) DecodeGreetingV2Request(r *http.Request) (service.GreetRequestV2, error) {
+	var request service.GreetRequestV2
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return service.GreetRequestV2{}, err
+	}
+	return request, nil
+}
+
 func (s HttpJson) DecodeExpensiveServiceRequest(r *http.Request) (service.ExpensiveRequest, error) {
 	var request service.ExpensiveRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -39,3 +70,39 @@ func (s HttpJson) DecodeExpensiveServiceRequest(r *http.Request) (service.Expens
 func (s HttpJson) EncodeExpensiveServiceRequest(w *http.ResponseWriter, response service.ExpensiveResponse) error {
 	return json.NewEncoder(*w).Encode(response)
 }
+
+func (s HttpJson) EncodeStoredGreeting(w *http.ResponseWriter, response service.StoredGreeting) error {
+	return json.NewEncoder(*w).Encode(response)
+}
+
+func (s HttpJson) EncodeGreetingHistoryResponse(w *http.ResponseWriter, response service.GreetingHistoryResponse) error {
+	return json.NewEncoder(*w).Encode(response)
+}
+
+func (s HttpJson) EncodeErrorResponse(w *http.ResponseWriter, response service.ErrorResponse) error {
+	return json.NewEncoder(*w).Encode(response)
+}
+
+func (s HttpJson) EncodeExpensiveJob(w *http.ResponseWriter, response service.ExpensiveJob) error {
+	return json.NewEncoder(*w).Encode(response)
+}
+
+func (s HttpJson) DecodeWebhookRequest(r *http.Request) (service.WebhookRequest, error) {
+	var request service.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return service.WebhookRequest{}, err
+	}
+	return request, nil
+}
+
+func (s HttpJson) EncodeWebhook(w *http.ResponseWriter, response service.Webhook) error {
+	return json.NewEncoder(*w).Encode(response)
+}
+
+func (s HttpJson) EncodeWebhookListResponse(w *http.ResponseWriter, response service.WebhookListResponse) error {
+	return json.NewEncoder(*w).Encode(response)
+}
+
+func (s HttpJson) EncodeWebhookDeliveriesResponse(w *http.ResponseWriter, response service.WebhookDeliveriesResponse) error {
+	return json.NewEncoder(*w).Encode(response)
+}
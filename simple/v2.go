@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+
+	service "github.com/tkeech1/gowebsvc/svc"
+	svcv2 "github.com/tkeech1/gowebsvc/svc/v2"
+	"google.golang.org/grpc/status"
+)
+
+// handleGreetingV2 greets the name of a v2 request in its locale and formality through
+// the v2 GreetingService. Unlike v1, failures are reported with a matching status code.
+func (s *server) handleGreetingV2() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		w.Header().Set("Content-Type", "application/json")
+
+		gr, err := s.transport.DecodeGreetingV2Request(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			s.transport.EncodeGreetingServiceRequest(&w, service.GreetResponse{V: "", Err: err.Error()})
+			return
+		}
+		formality, err := service.ParseFormality(gr.Formality)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			s.transport.EncodeGreetingServiceRequest(&w, service.GreetResponse{V: "", Err: err.Error()})
+			return
+		}
+
+		greeting, err := s.grpcV2().Greet(ctx, &svcv2.GreetRequest{
+			Name:      gr.Name,
+			Locale:    gr.Locale,
+			Formality: svcv2.FormalityFromService(formality),
+			Count:     int32(gr.Count),
+			Template:  gr.Template,
+		})
+		if err != nil {
+			w.WriteHeader(service.HTTPStatusFromCode(status.Code(err)))
+			s.transport.EncodeGreetingServiceRequest(&w, service.GreetResponse{V: "", Err: status.Convert(err).Message()})
+			return
+		}
+
+		response := service.GreetResponse{V: greeting.Greeting, Err: "", Locale: localize(w, greeting.Locale)}
+		if s.notModified(w, r, response) {
+			return
+		}
+		s.transport.EncodeGreetingServiceRequest(&w, response)
+	}
+}
+
+// handleExpensiveV2 runs the expensive operation through the v2 GreetingService,
+// reporting failures with a matching status code.
+func (s *server) handleExpensiveV2() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		w.Header().Set("Content-Type", "application/json")
+
+		gr, err := s.transport.DecodeExpensiveServiceRequest(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			s.transport.EncodeExpensiveServiceRequest(&w, service.ExpensiveResponse{V: "", Err: err.Error()})
+			return
+		}
+
+		expensive, err := s.grpcV2().Expensive(ctx, &svcv2.ExpensiveRequest{ConnectionString: gr.C, Username: gr.U, Password: gr.P})
+		if err != nil {
+			w.WriteHeader(service.HTTPStatusFromCode(status.Code(err)))
+			s.transport.EncodeExpensiveServiceRequest(&w, service.ExpensiveResponse{V: "", Err: status.Convert(err).Message()})
+			return
+		}
+		s.transport.EncodeExpensiveServiceRequest(&w, service.ExpensiveResponse{V: expensive.Status, Err: ""})
+	}
+}
+
+// localize announces the locale of a greeting in the Content-Language header and
+// returns it.
+func localize(w http.ResponseWriter, locale string) string {
+	if locale != "" {
+		w.Header().Set("Content-Language", locale)
+	}
+	return locale
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/tkeech1/gowebsvc/jobs"
+	"github.com/tkeech1/gowebsvc/middleware"
+	service "github.com/tkeech1/gowebsvc/svc"
+	"github.com/tkeech1/gowebsvc/webhook"
+)
+
+// publishJobs returns a jobs.Pool OnDone hook publishing an expensive_job.completed
+// webhook event for every finished job to the webhooks of the caller who started it.
+func publishJobs(webhooks *webhook.Dispatcher, logger *log.Logger) func(context.Context, jobs.Job) {
+	return func(ctx context.Context, j jobs.Job) {
+		if err := webhooks.Publish(j.Owner, webhook.ExpensiveJobCompleted, service.NewExpensiveJobEvent(j)); err != nil {
+			logger.Printf("failed to publish job event: %v", err)
+		}
+	}
+}
+
+// handleCreateWebhook registers a callback URL for the caller and returns it with the
+// secret signing its deliveries.
+func (s *server) handleCreateWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.webhooks == nil {
+			w.WriteHeader(service.HTTPStatus(service.ErrWebhooksDisabled))
+			s.transport.EncodeErrorResponse(&w, service.ErrorResponse{Err: service.ErrWebhooksDisabled.Error()})
+			return
+		}
+
+		wr, err := s.transport.DecodeWebhookRequest(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			s.transport.EncodeErrorResponse(&w, service.ErrorResponse{Err: err.Error()})
+			return
+		}
+		sub, err := s.webhooks.Subscribe(owner(r), wr.URL, wr.Secret, wr.Events)
+		if err != nil {
+			w.WriteHeader(service.HTTPStatus(err))
+			s.transport.EncodeErrorResponse(&w, service.ErrorResponse{Err: err.Error()})
+			return
+		}
+
+		response := service.NewWebhook(sub)
+		response.Secret = sub.Secret
+		w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+sub.ID)
+		w.WriteHeader(http.StatusCreated)
+		s.transport.EncodeWebhook(&w, response)
+	}
+}
+
+// handleListWebhooks returns the webhooks of the caller, oldest first.
+func (s *server) handleListWebhooks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.webhooks == nil {
+			w.WriteHeader(service.HTTPStatus(service.ErrWebhooksDisabled))
+			s.transport.EncodeWebhookListResponse(&w, service.WebhookListResponse{Webhooks: []service.Webhook{}, Err: service.ErrWebhooksDisabled.Error()})
+			return
+		}
+
+		response := service.WebhookListResponse{Webhooks: []service.Webhook{}}
+		for _, sub := range s.webhooks.Subscriptions(owner(r)) {
+			response.Webhooks = append(response.Webhooks, service.NewWebhook(sub))
+		}
+		s.transport.EncodeWebhookListResponse(&w, response)
+	}
+}
+
+// handleDeleteWebhook removes the caller's webhook named by the last path segment.
+func (s *server) handleDeleteWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.webhooks == nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(service.HTTPStatus(service.ErrWebhooksDisabled))
+			s.transport.EncodeErrorResponse(&w, service.ErrorResponse{Err: service.ErrWebhooksDisabled.Error()})
+			return
+		}
+
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if err := s.webhooks.Unsubscribe(owner(r), id); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(service.HTTPStatus(err))
+			s.transport.EncodeErrorResponse(&w, service.ErrorResponse{Err: err.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleWebhookDeliveries returns the recent deliveries to the caller's webhooks, or to
+// the one of the webhook_id query parameter if given.
+func (s *server) handleWebhookDeliveries() http.HandlerFunc {
+	return s.handleDeliveries(func(r *http.Request) []webhook.Delivery {
+		return s.webhooks.Deliveries(owner(r), r.URL.Query().Get("webhook_id"))
+	})
+}
+
+// handleWebhookDeadLetters returns the deliveries to the caller's webhooks that failed
+// for good.
+func (s *server) handleWebhookDeadLetters() http.HandlerFunc {
+	return s.handleDeliveries(func(r *http.Request) []webhook.Delivery {
+		return s.webhooks.DeadLetters(owner(r))
+	})
+}
+
+func (s *server) handleDeliveries(list func(*http.Request) []webhook.Delivery) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if s.webhooks == nil {
+			w.WriteHeader(service.HTTPStatus(service.ErrWebhooksDisabled))
+			s.transport.EncodeWebhookDeliveriesResponse(&w, service.WebhookDeliveriesResponse{Deliveries: []service.WebhookDelivery{}, Err: service.ErrWebhooksDisabled.Error()})
+			return
+		}
+
+		response := service.WebhookDeliveriesResponse{Deliveries: []service.WebhookDelivery{}}
+		for _, d := range list(r) {
+			response.Deliveries = append(response.Deliveries, service.NewWebhookDelivery(d))
+		}
+		s.transport.EncodeWebhookDeliveriesResponse(&w, response)
+	}
+}
+
+// owner returns the ID of the caller of r, who owns the webhooks they register.
+func owner(r *http.Request) string {
+	caller, _ := middleware.CallerFromContext(r.Context())
+	return caller.ID
+}
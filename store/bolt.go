@@ -0,0 +1,138 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	greetingsBucket = []byte("greetings")
+	// createdBucket indexes greetings by creation time: the key is the big-endian
+	// creation time in nanoseconds followed by the ID's sequence number.
+	createdBucket = []byte("created")
+)
+
+// Bolt is a Repository kept in a single BoltDB file.
+type Bolt struct {
+	db  *bolt.DB
+	now func() time.Time
+}
+
+// OpenBolt opens or creates the database file at path.
+func OpenBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{greetingsBucket, createdBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Bolt{db: db, now: time.Now}, nil
+}
+
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+func (b *Bolt) Save(ctx context.Context, g *Greeting) error {
+	if g.CreatedAt.IsZero() {
+		g.CreatedAt = b.now()
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		greetings := tx.Bucket(greetingsBucket)
+		seq, err := greetings.NextSequence()
+		if err != nil {
+			return err
+		}
+		g.ID = fmt.Sprintf("%016x", seq)
+		v, err := json.Marshal(g)
+		if err != nil {
+			return err
+		}
+		if err := greetings.Put([]byte(g.ID), v); err != nil {
+			return err
+		}
+		return tx.Bucket(createdBucket).Put(createdKey(g.CreatedAt, seq), []byte(g.ID))
+	})
+}
+
+func (b *Bolt) Get(ctx context.Context, id string) (Greeting, error) {
+	var g Greeting
+	err := b.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(greetingsBucket).Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(v, &g)
+	})
+	return g, err
+}
+
+func (b *Bolt) List(ctx context.Context, f Filter, pageToken string, pageSize int) (Page, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	start := createdKey(f.Since, 0)
+	if pageToken != "" {
+		token, err := hex.DecodeString(pageToken)
+		if err != nil || len(token) != 16 {
+			return Page{}, ErrInvalidPageToken
+		}
+		if bytes.Compare(token, start) > 0 {
+			start = token
+		}
+	}
+
+	page := Page{Greetings: []Greeting{}}
+	err := b.db.View(func(tx *bolt.Tx) error {
+		greetings := tx.Bucket(greetingsBucket)
+		c := tx.Bucket(createdBucket).Cursor()
+		for k, id := c.Seek(start); k != nil; k, id = c.Next() {
+			var g Greeting
+			if err := json.Unmarshal(greetings.Get(id), &g); err != nil {
+				return err
+			}
+			if g.Caller != f.Caller {
+				continue
+			}
+			if len(page.Greetings) == pageSize {
+				page.NextPageToken = hex.EncodeToString(k)
+				return nil
+			}
+			page.Greetings = append(page.Greetings, g)
+		}
+		return nil
+	})
+	if err != nil {
+		return Page{}, err
+	}
+	return page, nil
+}
+
+func createdKey(t time.Time, seq uint64) []byte {
+	key := make([]byte, 16)
+	if !t.IsZero() && t.UnixNano() > 0 {
+		binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	}
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func openTestBolt(t *testing.T) (*Bolt, func()) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := OpenBolt(filepath.Join(dir, "greetings.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func Test_BoltSaveGet(t *testing.T) {
+	db, cleanup := openTestBolt(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	created := time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)
+	g := Greeting{Name: "hello", Greeting: "Hi, hello!", Locale: "en", Caller: "alice", CreatedAt: created}
+	assert.Nil(t, db.Save(ctx, &g))
+	assert.NotEmpty(t, g.ID)
+
+	stored, err := db.Get(ctx, g.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, g, stored)
+
+	_, err = db.Get(ctx, "missing")
+	assert.Equal(t, ErrNotFound, err)
+
+	// CreatedAt defaults to now
+	db.now = func() time.Time { return created.Add(time.Hour) }
+	g2 := Greeting{Name: "world"}
+	assert.Nil(t, db.Save(ctx, &g2))
+	assert.NotEqual(t, g.ID, g2.ID)
+	assert.Equal(t, created.Add(time.Hour), g2.CreatedAt)
+}
+
+func Test_BoltList(t *testing.T) {
+	db, cleanup := openTestBolt(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	start := time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)
+	var ids []string
+	for i, caller := range []string{"alice", "bob", "alice", "bob", "alice"} {
+		g := Greeting{Name: "n", Greeting: "g", Caller: caller, CreatedAt: start.Add(time.Duration(i) * time.Minute)}
+		assert.Nil(t, db.Save(ctx, &g))
+		ids = append(ids, g.ID)
+	}
+
+	listIDs := func(f Filter, pageToken string, pageSize int) ([]string, string) {
+		page, err := db.List(ctx, f, pageToken, pageSize)
+		assert.Nil(t, err)
+		var got []string
+		for _, g := range page.Greetings {
+			got = append(got, g.ID)
+		}
+		return got, page.NextPageToken
+	}
+
+	tests := map[string]struct {
+		filter   Filter
+		pageSize int
+		pages    [][]string
+	}{
+		"caller":    {filter: Filter{Caller: "alice"}, pages: [][]string{{ids[0], ids[2], ids[4]}}},
+		"paginated": {filter: Filter{Caller: "bob"}, pageSize: 1, pages: [][]string{{ids[1]}, {ids[3]}}},
+		"since":     {filter: Filter{Since: start.Add(2 * time.Minute), Caller: "alice"}, pages: [][]string{{ids[2], ids[4]}}},
+		"exact":     {filter: Filter{Caller: "alice"}, pageSize: 3, pages: [][]string{{ids[0], ids[2], ids[4]}}},
+		"anonymous": {pages: [][]string{nil}},
+		"future":    {filter: Filter{Since: start.Add(time.Hour), Caller: "alice"}, pages: [][]string{nil}},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		token := ""
+		for i, expected := range test.pages {
+			got, next := listIDs(test.filter, token, test.pageSize)
+			assert.Equal(t, expected, got)
+			if i == len(test.pages)-1 {
+				assert.Empty(t, next)
+			} else {
+				assert.NotEmpty(t, next)
+			}
+			token = next
+		}
+	}
+
+	_, err := db.List(ctx, Filter{}, "not-a-token", 0)
+	assert.Equal(t, ErrInvalidPageToken, err)
+}
+
+func Test_BoltReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "greetings.db")
+
+	db, err := OpenBolt(path)
+	assert.Nil(t, err)
+	g := Greeting{Name: "hello", Greeting: "hello"}
+	assert.Nil(t, db.Save(context.Background(), &g))
+	assert.Nil(t, db.Close())
+
+	db, err = OpenBolt(path)
+	assert.Nil(t, err)
+	defer db.Close()
+	stored, err := db.Get(context.Background(), g.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", stored.Name)
+}
+
+func Test_BoltCache(t *testing.T) {
+	db, cleanup := openTestBolt(t)
+	defer cleanup()
+	ctx := context.Background()
+	now := time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)
+	db.now = func() time.Time { return now }
+	c := db.Cache()
+
+	_, ok, err := c.Get(ctx, "k")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.Nil(t, c.Set(ctx, "k", "v", time.Minute))
+	assert.Nil(t, c.Set(ctx, "short", "v", time.Second))
+	v, ok, err := c.Get(ctx, "k")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "v", v)
+
+	now = now.Add(2 * time.Second)
+	_, ok, _ = c.Get(ctx, "short")
+	assert.False(t, ok)
+
+	// a later Set purges expired entries
+	now = now.Add(purgeInterval)
+	assert.Nil(t, c.Set(ctx, "other", "v", time.Minute))
+	db.db.View(func(tx *bolt.Tx) error {
+		assert.Equal(t, 1, tx.Bucket(cacheBucket).Stats().KeyN)
+		return nil
+	})
+}
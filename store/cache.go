@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var cacheBucket = []byte("cache")
+
+// purgeInterval is how often Set removes expired entries.
+const purgeInterval = time.Minute
+
+// Cache stores values with a time to live in the database of a Bolt repository, for
+// state that must survive restarts. It implements cache.Cache.
+type Cache struct {
+	b *Bolt
+
+	mu        sync.Mutex
+	lastPurge time.Time
+}
+
+// Cache returns the cache kept in b's database.
+func (b *Bolt) Cache() *Cache {
+	return &Cache{b: b}
+}
+
+func (c *Cache) Get(ctx context.Context, key string) (string, bool, error) {
+	var value string
+	var found bool
+	err := c.b.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(cacheBucket)
+		if b == nil {
+			return nil
+		}
+		v := b.Get([]byte(key))
+		if len(v) < 8 || expired(v, c.b.now()) {
+			return nil
+		}
+		value, found = string(v[8:]), true
+		return nil
+	})
+	return value, found, err
+}
+
+func (c *Cache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	now := c.b.now()
+	c.mu.Lock()
+	purge := now.Sub(c.lastPurge) >= purgeInterval
+	if purge {
+		c.lastPurge = now
+	}
+	c.mu.Unlock()
+
+	return c.b.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(cacheBucket)
+		if err != nil {
+			return err
+		}
+		if purge {
+			var keys [][]byte
+			cur := b.Cursor()
+			for k, v := cur.First(); k != nil; k, v = cur.Next() {
+				if len(v) < 8 || expired(v, now) {
+					keys = append(keys, k)
+				}
+			}
+			for _, k := range keys {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+		}
+		v := make([]byte, 8+len(value))
+		binary.BigEndian.PutUint64(v, uint64(now.Add(ttl).UnixNano()))
+		copy(v[8:], value)
+		return b.Put([]byte(key), v)
+	})
+}
+
+func expired(v []byte, now time.Time) bool {
+	return int64(binary.BigEndian.Uint64(v)) <= now.UnixNano()
+}
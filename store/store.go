@@ -0,0 +1,55 @@
+// Package store records the greetings served by the service.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotFound         = errors.New("greeting not found")
+	ErrInvalidPageToken = errors.New("invalid page token")
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// Greeting is a greeting served to a caller.
+type Greeting struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Greeting  string    `json:"greeting"`
+	Locale    string    `json:"locale,omitempty"`
+	Caller    string    `json:"caller,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Filter selects the greetings returned by List.
+type Filter struct {
+	// Since excludes greetings created before it; the zero time excludes none.
+	Since time.Time
+	// Caller selects the greetings of one caller; the empty caller is the anonymous
+	// one.
+	Caller string
+}
+
+// Page is a page of greetings, oldest first. NextPageToken is passed to List to get
+// the following page; it is empty on the last page.
+type Page struct {
+	Greetings     []Greeting
+	NextPageToken string
+}
+
+// Repository stores greetings.
+type Repository interface {
+	// Save stores g, setting its ID and, if it is zero, its CreatedAt.
+	Save(ctx context.Context, g *Greeting) error
+	// Get returns the greeting with id, or ErrNotFound.
+	Get(ctx context.Context, id string) (Greeting, error)
+	// List returns up to pageSize greetings matching f, starting at pageToken. A
+	// pageSize of 0 means DefaultPageSize; larger sizes are capped at MaxPageSize.
+	List(ctx context.Context, f Filter, pageToken string, pageSize int) (Page, error)
+}
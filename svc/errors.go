@@ -0,0 +1,134 @@
+package svc
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/tkeech1/gowebsvc/jobs"
+	"github.com/tkeech1/gowebsvc/store"
+	"github.com/tkeech1/gowebsvc/webhook"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrEmptyGreeting           = errors.New("empty greeting")
+	ErrRequestCancelled        = errors.New("request cancelled")
+	ErrRequestTimedOut         = errors.New("request timed out")
+	ErrMissingConnectionString = errors.New("missing connectionString")
+	ErrMissingUsername         = errors.New("missing username")
+	ErrMissingPassword         = errors.New("missing password")
+
+	// ErrPermissionDenied is returned when the caller is not allowed to invoke a method.
+	ErrPermissionDenied = errors.New("permission denied")
+
+	// ErrUnknownTemplate is returned when a request names a greeting template that is
+	// not loaded.
+	ErrUnknownTemplate = errors.New("unknown template")
+
+	// ErrHistoryDisabled is returned by the history endpoints when greetings are not
+	// recorded.
+	ErrHistoryDisabled = errors.New("greeting history is disabled")
+	ErrInvalidSince    = errors.New("since must be an RFC 3339 time")
+	ErrInvalidPageSize = errors.New("page_size must be a number")
+
+	// ErrJobsDisabled is returned by the job endpoints when there is no job pool.
+	ErrJobsDisabled = errors.New("background jobs are disabled")
+	// ErrAlreadyInitialized fails the expensive operation jobs started once the
+	// operation has run.
+	ErrAlreadyInitialized = errors.New("the expensive operation has already been initialized")
+
+	// ErrWebhooksDisabled is returned by the webhook endpoints when webhooks are not
+	// enabled.
+	ErrWebhooksDisabled = errors.New("webhooks are disabled")
+)
+
+var knownErrors = []error{
+	ErrEmptyGreeting,
+	ErrRequestCancelled,
+	ErrRequestTimedOut,
+	ErrMissingConnectionString,
+	ErrMissingUsername,
+	ErrMissingPassword,
+	ErrPermissionDenied,
+	ErrUnknownTemplate,
+	ErrHistoryDisabled,
+	ErrInvalidSince,
+	ErrInvalidPageSize,
+	ErrJobsDisabled,
+	ErrAlreadyInitialized,
+	ErrWebhooksDisabled,
+}
+
+// DecodeError turns an error message received over the wire back into the matching
+// service error, so remote callers can compare against the values above.
+func DecodeError(msg string) error {
+	if msg == "" {
+		return nil
+	}
+	for _, err := range knownErrors {
+		if err.Error() == msg {
+			return err
+		}
+	}
+	return errors.New(msg)
+}
+
+// Code returns the gRPC status code reported for err by the v2 API, including the
+// errors of the history, the job pool and the webhooks. It is the one table the
+// transports map errors with; HTTPStatus derives the HTTP status from it.
+func Code(err error) codes.Code {
+	switch err {
+	case nil:
+		return codes.OK
+	case ErrEmptyGreeting, ErrMissingConnectionString, ErrMissingUsername, ErrMissingPassword, ErrUnknownTemplate,
+		ErrInvalidSince, ErrInvalidPageSize, store.ErrInvalidPageToken,
+		webhook.ErrInvalidURL, webhook.ErrPrivateURL, webhook.ErrUnknownEvent:
+		return codes.InvalidArgument
+	case ErrRequestCancelled:
+		return codes.Canceled
+	case ErrRequestTimedOut:
+		return codes.DeadlineExceeded
+	case ErrPermissionDenied:
+		return codes.PermissionDenied
+	case store.ErrNotFound, jobs.ErrNotFound, webhook.ErrNotFound:
+		return codes.NotFound
+	case ErrAlreadyInitialized:
+		return codes.FailedPrecondition
+	case ErrHistoryDisabled, ErrJobsDisabled, ErrWebhooksDisabled:
+		return codes.Unimplemented
+	case jobs.ErrQueueFull, jobs.ErrClosed:
+		return codes.Unavailable
+	}
+	return codes.Unknown
+}
+
+// HTTPStatus returns the HTTP status reported for err by the v2 API.
+func HTTPStatus(err error) int {
+	return HTTPStatusFromCode(Code(err))
+}
+
+// HTTPStatusFromCode returns the HTTP status reported for a gRPC status code, so REST
+// handlers calling the gRPC servers answer like the v2 API.
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.Canceled:
+		return 499 // client closed request
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.FailedPrecondition:
+		return http.StatusConflict
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
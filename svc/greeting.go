@@ -2,8 +2,11 @@ package svc
 
 import (
 	"context"
-	"errors"
 	"time"
+
+	"github.com/tkeech1/gowebsvc/i18n"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Greeter interface {
@@ -13,16 +16,52 @@ type Greeter interface {
 
 type GreeterGRPC interface {
 	GreetGRPC(context.Context, *GRPCGreetRequest) (*GRPCGreetResponse, error)
+	ExpensiveGRPC(context.Context, *GRPCExpensiveRequest) (*GRPCExpensiveResponse, error)
 }
 
-type GreetingService struct{}
+// GreetingService greets in the requested locale, or with one of Templates when the
+// request names a template. Templates may be nil.
+type GreetingService struct {
+	Templates *Templates
+}
 
-type GreetingServiceGRPC struct{}
+// GreetingServiceGRPC serves the v1 gRPC API through Next, so gRPC calls return what
+// Greet and Expensive return and go through the same middleware as every other
+// transport. A denied call fails with the PermissionDenied status; other errors are
+// reported in the Err field of the response.
+type GreetingServiceGRPC struct {
+	Next Greeter
+}
 
 func (g *GreetingServiceGRPC) GreetGRPC(ctx context.Context, in *GRPCGreetRequest) (*GRPCGreetResponse, error) {
-	return &GRPCGreetResponse{Greeting: "GRPC - " + in.S}, nil
+	ctx, err := NewRequestOptionsContext(IncomingAcceptLanguageContext(ctx), in.Locale, in.Formality, int(in.Count), in.Template)
+	if err != nil {
+		return &GRPCGreetResponse{Err: err.Error()}, nil
+	}
+	greeting, err := g.Next.Greet(ctx, in.S)
+	if err == ErrPermissionDenied {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		return &GRPCGreetResponse{Err: err.Error()}, nil
+	}
+	return &GRPCGreetResponse{Greeting: greeting, Locale: ResolveLocale(ctx)}, nil
+}
+
+func (g *GreetingServiceGRPC) ExpensiveGRPC(ctx context.Context, in *GRPCExpensiveRequest) (*GRPCExpensiveResponse, error) {
+	v, err := g.Next.Expensive(ctx, in.ConnectionString, in.Username, in.Password)
+	if err == ErrPermissionDenied {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		return &GRPCExpensiveResponse{Err: err.Error()}, nil
+	}
+	return &GRPCExpensiveResponse{Status: v}, nil
 }
 
+// Greet greets greeting in the locale negotiated from the GreetOptions in ctx, or
+// renders the template they name. Without a requested locale or template it returns
+// greeting unchanged, as v1 always did.
 func (g GreetingService) Greet(ctx context.Context, greeting string) (string, error) {
 	ch := make(chan string)
 
@@ -34,23 +73,55 @@ func (g GreetingService) Greet(ctx context.Context, greeting string) (string, er
 	select {
 	case response := <-ch:
 		if response == "" {
-			return "", errors.New("empty greeting")
+			return "", ErrEmptyGreeting
+		}
+		o := GreetOptionsFromContext(ctx)
+		locale := ResolveLocale(ctx)
+		if o.Template != "" {
+			return g.render(o, locale, response)
+		}
+		if locale != "" {
+			return i18n.Default.Greeting(locale, o.Formality == Formal, response, o.Count), nil
 		}
 		return response, nil
 	case <-ctx.Done():
-		return "", errors.New("request cancelled")
+		return "", ErrRequestCancelled
+	}
+}
+
+func (g GreetingService) render(o GreetOptions, locale, name string) (string, error) {
+	if locale == "" {
+		locale = i18n.Default.Default
+	}
+	data := TemplateData{
+		Name:     name,
+		Greeting: i18n.Default.Greeting(locale, o.Formality == Formal, name, o.Count),
+		Locale:   locale,
+		Formal:   o.Formality == Formal,
+		Count:    o.Count,
+	}
+	if g.Templates != nil {
+		data.Hour = g.Templates.hour()
+	}
+	greeting, err := g.Templates.Execute(o.Template, data)
+	if err != nil {
+		return "", err
+	}
+	if greeting == "" {
+		return "", ErrEmptyGreeting
 	}
+	return greeting, nil
 }
 
 func (g GreetingService) Expensive(ctx context.Context, connectionString, username, password string) (string, error) {
 	if connectionString == "" {
-		return "", errors.New("missing connectionString")
+		return "", ErrMissingConnectionString
 	}
 	if username == "" {
-		return "", errors.New("missing username")
+		return "", ErrMissingUsername
 	}
 	if password == "" {
-		return "", errors.New("missing password")
+		return "", ErrMissingPassword
 	}
 
 	ch := make(chan string)
@@ -61,12 +132,12 @@ func (g GreetingService) Expensive(ctx context.Context, connectionString, userna
 	select {
 	case response := <-ch:
 		if response == "" {
-			return "", errors.New("empty greeting")
+			return "", ErrEmptyGreeting
 		}
 		return response, nil
 	case <-ctx.Done():
-		return "", errors.New("request cancelled")
+		return "", ErrRequestCancelled
 	case <-time.After(1 * time.Second):
-		return "", errors.New("request timed out")
+		return "", ErrRequestTimedOut
 	}
 }
@@ -6,6 +6,7 @@ package svc
 import proto "github.com/golang/protobuf/proto"
 import fmt "fmt"
 import math "math"
+import _ "google.golang.org/genproto/googleapis/api/annotations"
 
 import (
 	context "golang.org/x/net/context"
@@ -25,7 +26,15 @@ const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 // The request message containing the user's name.
 type GRPCGreetRequest struct {
-	S                    string   `protobuf:"bytes,1,opt,name=s,proto3" json:"s,omitempty"`
+	S string `protobuf:"bytes,1,opt,name=s,proto3" json:"s,omitempty"`
+	// Optional locale; the accept-language metadata is used when it is empty.
+	Locale string `protobuf:"bytes,2,opt,name=locale,proto3" json:"locale,omitempty"`
+	// "formal", "informal" or empty.
+	Formality string `protobuf:"bytes,3,opt,name=formality,proto3" json:"formality,omitempty"`
+	// The number of people greeted; 0 and 1 greet a single person.
+	Count int32 `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	// The name of a greeting template loaded by the server, if any.
+	Template             string   `protobuf:"bytes,5,opt,name=template,proto3" json:"template,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -35,7 +44,7 @@ func (m *GRPCGreetRequest) Reset()         { *m = GRPCGreetRequest{} }
 func (m *GRPCGreetRequest) String() string { return proto.CompactTextString(m) }
 func (*GRPCGreetRequest) ProtoMessage()    {}
 func (*GRPCGreetRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_greeting_51b1b6e08a7d2511, []int{0}
+	return fileDescriptor_greeting_e514437b9b4aee27, []int{0}
 }
 func (m *GRPCGreetRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_GRPCGreetRequest.Unmarshal(m, b)
@@ -62,10 +71,40 @@ func (m *GRPCGreetRequest) GetS() string {
 	return ""
 }
 
+func (m *GRPCGreetRequest) GetLocale() string {
+	if m != nil {
+		return m.Locale
+	}
+	return ""
+}
+
+func (m *GRPCGreetRequest) GetFormality() string {
+	if m != nil {
+		return m.Formality
+	}
+	return ""
+}
+
+func (m *GRPCGreetRequest) GetCount() int32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *GRPCGreetRequest) GetTemplate() string {
+	if m != nil {
+		return m.Template
+	}
+	return ""
+}
+
 // The response message containing the greetings
 type GRPCGreetResponse struct {
-	Greeting             string   `protobuf:"bytes,1,opt,name=greeting,proto3" json:"greeting,omitempty"`
-	Err                  string   `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	Greeting string `protobuf:"bytes,1,opt,name=greeting,proto3" json:"greeting,omitempty"`
+	Err      string `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	// The locale the greeting is written in, empty if none was requested.
+	Locale               string   `protobuf:"bytes,3,opt,name=locale,proto3" json:"locale,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -75,7 +114,7 @@ func (m *GRPCGreetResponse) Reset()         { *m = GRPCGreetResponse{} }
 func (m *GRPCGreetResponse) String() string { return proto.CompactTextString(m) }
 func (*GRPCGreetResponse) ProtoMessage()    {}
 func (*GRPCGreetResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_greeting_51b1b6e08a7d2511, []int{1}
+	return fileDescriptor_greeting_e514437b9b4aee27, []int{1}
 }
 func (m *GRPCGreetResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_GRPCGreetResponse.Unmarshal(m, b)
@@ -109,9 +148,120 @@ func (m *GRPCGreetResponse) GetErr() string {
 	return ""
 }
 
+func (m *GRPCGreetResponse) GetLocale() string {
+	if m != nil {
+		return m.Locale
+	}
+	return ""
+}
+
+// The request message containing the connection details for the expensive operation.
+type GRPCExpensiveRequest struct {
+	ConnectionString     string   `protobuf:"bytes,1,opt,name=connection_string,json=connectionString,proto3" json:"connection_string,omitempty"`
+	Username             string   `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Password             string   `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GRPCExpensiveRequest) Reset()         { *m = GRPCExpensiveRequest{} }
+func (m *GRPCExpensiveRequest) String() string { return proto.CompactTextString(m) }
+func (*GRPCExpensiveRequest) ProtoMessage()    {}
+func (*GRPCExpensiveRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_greeting_e514437b9b4aee27, []int{2}
+}
+func (m *GRPCExpensiveRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GRPCExpensiveRequest.Unmarshal(m, b)
+}
+func (m *GRPCExpensiveRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GRPCExpensiveRequest.Marshal(b, m, deterministic)
+}
+func (dst *GRPCExpensiveRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GRPCExpensiveRequest.Merge(dst, src)
+}
+func (m *GRPCExpensiveRequest) XXX_Size() int {
+	return xxx_messageInfo_GRPCExpensiveRequest.Size(m)
+}
+func (m *GRPCExpensiveRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GRPCExpensiveRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GRPCExpensiveRequest proto.InternalMessageInfo
+
+func (m *GRPCExpensiveRequest) GetConnectionString() string {
+	if m != nil {
+		return m.ConnectionString
+	}
+	return ""
+}
+
+func (m *GRPCExpensiveRequest) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+func (m *GRPCExpensiveRequest) GetPassword() string {
+	if m != nil {
+		return m.Password
+	}
+	return ""
+}
+
+// The response message containing the result of the expensive operation.
+type GRPCExpensiveResponse struct {
+	Status               string   `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Err                  string   `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GRPCExpensiveResponse) Reset()         { *m = GRPCExpensiveResponse{} }
+func (m *GRPCExpensiveResponse) String() string { return proto.CompactTextString(m) }
+func (*GRPCExpensiveResponse) ProtoMessage()    {}
+func (*GRPCExpensiveResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_greeting_e514437b9b4aee27, []int{3}
+}
+func (m *GRPCExpensiveResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GRPCExpensiveResponse.Unmarshal(m, b)
+}
+func (m *GRPCExpensiveResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GRPCExpensiveResponse.Marshal(b, m, deterministic)
+}
+func (dst *GRPCExpensiveResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GRPCExpensiveResponse.Merge(dst, src)
+}
+func (m *GRPCExpensiveResponse) XXX_Size() int {
+	return xxx_messageInfo_GRPCExpensiveResponse.Size(m)
+}
+func (m *GRPCExpensiveResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GRPCExpensiveResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GRPCExpensiveResponse proto.InternalMessageInfo
+
+func (m *GRPCExpensiveResponse) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *GRPCExpensiveResponse) GetErr() string {
+	if m != nil {
+		return m.Err
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*GRPCGreetRequest)(nil), "svc.GRPCGreetRequest")
 	proto.RegisterType((*GRPCGreetResponse)(nil), "svc.GRPCGreetResponse")
+	proto.RegisterType((*GRPCExpensiveRequest)(nil), "svc.GRPCExpensiveRequest")
+	proto.RegisterType((*GRPCExpensiveResponse)(nil), "svc.GRPCExpensiveResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -128,6 +278,8 @@ const _ = grpc.SupportPackageIsVersion4
 type GreetingServiceClient interface {
 	// Sends a greeting
 	GreetGRPC(ctx context.Context, in *GRPCGreetRequest, opts ...grpc.CallOption) (*GRPCGreetResponse, error)
+	// Runs the expensive operation
+	ExpensiveGRPC(ctx context.Context, in *GRPCExpensiveRequest, opts ...grpc.CallOption) (*GRPCExpensiveResponse, error)
 }
 
 type greetingServiceClient struct {
@@ -147,10 +299,21 @@ func (c *greetingServiceClient) GreetGRPC(ctx context.Context, in *GRPCGreetRequ
 	return out, nil
 }
 
+func (c *greetingServiceClient) ExpensiveGRPC(ctx context.Context, in *GRPCExpensiveRequest, opts ...grpc.CallOption) (*GRPCExpensiveResponse, error) {
+	out := new(GRPCExpensiveResponse)
+	err := c.cc.Invoke(ctx, "/svc.GreetingService/ExpensiveGRPC", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // GreetingServiceServer is the server API for GreetingService service.
 type GreetingServiceServer interface {
 	// Sends a greeting
 	GreetGRPC(context.Context, *GRPCGreetRequest) (*GRPCGreetResponse, error)
+	// Runs the expensive operation
+	ExpensiveGRPC(context.Context, *GRPCExpensiveRequest) (*GRPCExpensiveResponse, error)
 }
 
 func RegisterGreetingServiceServer(s *grpc.Server, srv GreetingServiceServer) {
@@ -175,6 +338,24 @@ func _GreetingService_GreetGRPC_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GreetingService_ExpensiveGRPC_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GRPCExpensiveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GreetingServiceServer).ExpensiveGRPC(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/svc.GreetingService/ExpensiveGRPC",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GreetingServiceServer).ExpensiveGRPC(ctx, req.(*GRPCExpensiveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _GreetingService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "svc.GreetingService",
 	HandlerType: (*GreetingServiceServer)(nil),
@@ -183,23 +364,42 @@ var _GreetingService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GreetGRPC",
 			Handler:    _GreetingService_GreetGRPC_Handler,
 		},
+		{
+			MethodName: "ExpensiveGRPC",
+			Handler:    _GreetingService_ExpensiveGRPC_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "greeting.proto",
 }
 
-func init() { proto.RegisterFile("greeting.proto", fileDescriptor_greeting_51b1b6e08a7d2511) }
-
-var fileDescriptor_greeting_51b1b6e08a7d2511 = []byte{
-	// 156 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x4b, 0x2f, 0x4a, 0x4d,
-	0x2d, 0xc9, 0xcc, 0x4b, 0xd7, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x2e, 0x2e, 0x4b, 0x56,
-	0x52, 0xe0, 0x12, 0x70, 0x0f, 0x0a, 0x70, 0x76, 0x07, 0x49, 0x05, 0xa5, 0x16, 0x96, 0xa6, 0x16,
-	0x97, 0x08, 0xf1, 0x70, 0x31, 0x16, 0x4b, 0x30, 0x2a, 0x30, 0x6a, 0x70, 0x06, 0x31, 0x16, 0x2b,
-	0x39, 0x72, 0x09, 0x22, 0xa9, 0x28, 0x2e, 0xc8, 0xcf, 0x2b, 0x4e, 0x15, 0x92, 0xe2, 0xe2, 0x80,
-	0x99, 0x06, 0x55, 0x09, 0xe7, 0x0b, 0x09, 0x70, 0x31, 0xa7, 0x16, 0x15, 0x49, 0x30, 0x81, 0x85,
-	0x41, 0x4c, 0x23, 0x7f, 0x2e, 0x7e, 0x77, 0xa8, 0x6c, 0x70, 0x6a, 0x51, 0x59, 0x66, 0x72, 0xaa,
-	0x90, 0x0d, 0x17, 0x27, 0x58, 0x08, 0x64, 0xb4, 0x90, 0xa8, 0x5e, 0x71, 0x59, 0xb2, 0x1e, 0xba,
-	0x3b, 0xa4, 0xc4, 0xd0, 0x85, 0x21, 0x96, 0x2b, 0x31, 0x24, 0xb1, 0x81, 0x7d, 0x60, 0x0c, 0x08,
-	0x00, 0x00, 0xff, 0xff, 0xff, 0x5b, 0xf9, 0x20, 0xd3, 0x00, 0x00, 0x00,
+func init() { proto.RegisterFile("greeting.proto", fileDescriptor_greeting_e514437b9b4aee27) }
+
+var fileDescriptor_greeting_e514437b9b4aee27 = []byte{
+	// 395 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x52, 0x3f, 0x8f, 0xd3, 0x30,
+	0x1c, 0x95, 0x1b, 0x5a, 0x91, 0x1f, 0x77, 0xbd, 0x9e, 0x75, 0x57, 0x85, 0xe8, 0x86, 0xca, 0x2c,
+	0x05, 0xa4, 0x46, 0xc0, 0xc6, 0x80, 0x84, 0x00, 0x75, 0x45, 0xe9, 0x44, 0x17, 0x64, 0xc2, 0x8f,
+	0x28, 0x52, 0x6a, 0xa7, 0xb6, 0x13, 0x40, 0x2c, 0x88, 0x05, 0x76, 0x3e, 0x1a, 0x5f, 0x81, 0x0f,
+	0x82, 0xec, 0x3a, 0x49, 0x1b, 0x6e, 0xcb, 0x7b, 0x2f, 0xf6, 0xfb, 0x93, 0xc0, 0x34, 0x57, 0x88,
+	0xa6, 0x10, 0xf9, 0xaa, 0x52, 0xd2, 0x48, 0x1a, 0xe8, 0x26, 0x8b, 0x6f, 0x72, 0x29, 0xf3, 0x12,
+	0x13, 0x5e, 0x15, 0x09, 0x17, 0x42, 0x1a, 0x6e, 0x0a, 0x29, 0xf4, 0xe1, 0x15, 0xf6, 0x8b, 0xc0,
+	0x6c, 0x9d, 0xbe, 0x7d, 0xb5, 0xb6, 0x27, 0x53, 0xdc, 0xd7, 0xa8, 0x0d, 0x3d, 0x03, 0xa2, 0x23,
+	0xb2, 0x20, 0xcb, 0x30, 0x25, 0x9a, 0xce, 0x61, 0x52, 0xca, 0x8c, 0x97, 0x18, 0x8d, 0x1c, 0xe5,
+	0x11, 0xbd, 0x81, 0xf0, 0x93, 0x54, 0x3b, 0x5e, 0x16, 0xe6, 0x6b, 0x14, 0x38, 0xa9, 0x27, 0xe8,
+	0x15, 0x8c, 0x33, 0x59, 0x0b, 0x13, 0xdd, 0x59, 0x90, 0xe5, 0x38, 0x3d, 0x00, 0x1a, 0xc3, 0x5d,
+	0x83, 0xbb, 0xaa, 0xe4, 0x06, 0xa3, 0xb1, 0x3b, 0xd2, 0x61, 0xf6, 0x0e, 0x2e, 0x8f, 0x92, 0xe8,
+	0x4a, 0x0a, 0x8d, 0xf6, 0x40, 0x5b, 0xca, 0x27, 0xea, 0x30, 0x9d, 0x41, 0x80, 0x4a, 0xf9, 0x54,
+	0xf6, 0xf1, 0x28, 0x6a, 0x70, 0x1c, 0x95, 0x7d, 0x83, 0x2b, 0x7b, 0xf5, 0x9b, 0x2f, 0x15, 0x0a,
+	0x5d, 0x34, 0xd8, 0x16, 0x7d, 0x0c, 0x97, 0x99, 0x14, 0x02, 0x33, 0x3b, 0xc9, 0x7b, 0x6d, 0x54,
+	0x6f, 0x33, 0xeb, 0x85, 0x8d, 0xe3, 0x6d, 0x94, 0x5a, 0xa3, 0x12, 0x7c, 0xd7, 0x2e, 0xd1, 0x61,
+	0xab, 0x55, 0x5c, 0xeb, 0xcf, 0x52, 0x7d, 0xf4, 0xd6, 0x1d, 0x66, 0x2f, 0xe1, 0x7a, 0x60, 0xee,
+	0xbb, 0xcd, 0x61, 0xa2, 0x0d, 0x37, 0x75, 0xbb, 0xb5, 0x47, 0xff, 0xf7, 0x7a, 0xfa, 0x73, 0x04,
+	0x17, 0x6b, 0x5f, 0x7b, 0x83, 0xaa, 0x29, 0x32, 0xa4, 0xdf, 0x09, 0x84, 0x8e, 0xb3, 0x97, 0xd3,
+	0xeb, 0x95, 0x6e, 0xb2, 0xd5, 0xf0, 0x4b, 0xc6, 0xf3, 0x21, 0x7d, 0xb0, 0x66, 0xaf, 0x7f, 0xfc,
+	0xf9, 0xfb, 0x7b, 0xf4, 0x82, 0x9d, 0x25, 0xcd, 0x93, 0xa4, 0x1d, 0xf4, 0x39, 0x79, 0xb4, 0x9d,
+	0xd2, 0x13, 0x6a, 0x3b, 0x65, 0xe1, 0x89, 0x7e, 0x8f, 0xf6, 0x98, 0xee, 0xe1, 0xbc, 0x6b, 0xe5,
+	0x52, 0xdc, 0xef, 0xec, 0x86, 0x53, 0xc7, 0xf1, 0x6d, 0x92, 0x4f, 0xf3, 0xd0, 0xa5, 0x79, 0xc0,
+	0xce, 0xad, 0x35, 0xb6, 0xb2, 0xb5, 0xbb, 0x60, 0x70, 0x42, 0x7c, 0x98, 0xb8, 0xdf, 0xf6, 0xd9,
+	0xbf, 0x01, 0x00, 0x64, 0x68, 0x18, 0x8c, 0xeb, 0x02, 0x00, 0x00,
 }
@@ -0,0 +1,32 @@
+package svc
+
+import (
+	"time"
+
+	"github.com/tkeech1/gowebsvc/store"
+)
+
+// ParseSince parses the since parameter of a history listing. Empty means from the
+// beginning.
+func ParseSince(since string) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, since)
+	if err != nil {
+		return time.Time{}, ErrInvalidSince
+	}
+	return t, nil
+}
+
+// NewStoredGreeting returns the wire format of a recorded greeting.
+func NewStoredGreeting(g store.Greeting) StoredGreeting {
+	return StoredGreeting{
+		ID:        g.ID,
+		Name:      g.Name,
+		Greeting:  g.Greeting,
+		Locale:    g.Locale,
+		Caller:    g.Caller,
+		CreatedAt: g.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+}
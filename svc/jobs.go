@@ -0,0 +1,27 @@
+package svc
+
+import (
+	"time"
+
+	"github.com/tkeech1/gowebsvc/jobs"
+)
+
+// NewExpensiveJob returns the wire format of an expensive operation job. The result is
+// left out, as it may carry the credentials the job was started with.
+func NewExpensiveJob(j jobs.Job) ExpensiveJob {
+	job := ExpensiveJob{
+		ID:        j.ID,
+		State:     string(j.State),
+		CreatedAt: j.CreatedAt.UTC().Format(time.RFC3339Nano),
+		UpdatedAt: j.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	if j.Err != nil {
+		job.Err = j.Err.Error()
+	}
+	return job
+}
+
+// NewExpensiveJobEvent returns the webhook event data of a finished job.
+func NewExpensiveJobEvent(j jobs.Job) ExpensiveJobEvent {
+	return ExpensiveJobEvent(NewExpensiveJob(j))
+}
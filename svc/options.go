@@ -0,0 +1,122 @@
+package svc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tkeech1/gowebsvc/i18n"
+	"google.golang.org/grpc/metadata"
+)
+
+// Formality selects between the informal and formal variant of a greeting.
+type Formality int
+
+const (
+	FormalityUnspecified Formality = iota
+	Informal
+	Formal
+)
+
+var formalityNames = map[Formality]string{
+	FormalityUnspecified: "",
+	Informal:             "informal",
+	Formal:               "formal",
+}
+
+func (f Formality) String() string {
+	return formalityNames[f]
+}
+
+// ParseFormality parses "informal", "formal" or "" (unspecified).
+func ParseFormality(s string) (Formality, error) {
+	for f, name := range formalityNames {
+		if strings.EqualFold(s, name) {
+			return f, nil
+		}
+	}
+	return FormalityUnspecified, fmt.Errorf("unknown formality %q", s)
+}
+
+// GreetOptions are the preferences of a greeting request. They travel in the context
+// so the Greeter interface, and every middleware implementing it, stays the same for
+// v1 and v2 callers.
+type GreetOptions struct {
+	// Locale is requested explicitly and takes precedence over Accept.
+	Locale string
+	// Accept are the locales from Accept-Language or the accept-language metadata,
+	// most preferred first.
+	Accept    []string
+	Formality Formality
+	// Count is the number of people greeted; 0 and 1 greet a single person.
+	Count int
+	// Template names the greeting template to render instead of the catalog greeting.
+	Template string
+}
+
+// Preferences returns the requested locales, most preferred first. It is empty when
+// the request did not ask for a locale, in which case the greeting is not localized.
+func (o GreetOptions) Preferences() []string {
+	if o.Locale != "" {
+		return append([]string{o.Locale}, o.Accept...)
+	}
+	return o.Accept
+}
+
+// Key identifies the options in cache and coalescing keys.
+func (o GreetOptions) Key() string {
+	return fmt.Sprintf("%s|%s|%s|%d|%s", o.Locale, strings.Join(o.Accept, ","), o.Formality, o.Count, o.Template)
+}
+
+type greetOptionsKey struct{}
+
+func NewGreetOptionsContext(ctx context.Context, o GreetOptions) context.Context {
+	return context.WithValue(ctx, greetOptionsKey{}, o)
+}
+
+func GreetOptionsFromContext(ctx context.Context) GreetOptions {
+	o, _ := ctx.Value(greetOptionsKey{}).(GreetOptions)
+	return o
+}
+
+// NewRequestOptionsContext sets the locale, formality, count and template given in a
+// request on the GreetOptions in ctx, keeping the locales accepted by the caller.
+func NewRequestOptionsContext(ctx context.Context, locale, formality string, count int, template string) (context.Context, error) {
+	f, err := ParseFormality(formality)
+	if err != nil {
+		return ctx, err
+	}
+	o := GreetOptionsFromContext(ctx)
+	o.Locale, o.Formality, o.Count, o.Template = locale, f, count, template
+	return NewGreetOptionsContext(ctx, o), nil
+}
+
+// NewAcceptLanguageContext adds the locales of Accept-Language headers to the
+// GreetOptions in ctx.
+func NewAcceptLanguageContext(ctx context.Context, headers ...string) context.Context {
+	o := GreetOptionsFromContext(ctx)
+	for _, h := range headers {
+		o.Accept = append(o.Accept, i18n.ParseAcceptLanguage(h)...)
+	}
+	return NewGreetOptionsContext(ctx, o)
+}
+
+// IncomingAcceptLanguageContext adds the locales of the accept-language gRPC metadata
+// to the GreetOptions in ctx.
+func IncomingAcceptLanguageContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("accept-language"); len(values) > 0 {
+		return NewAcceptLanguageContext(ctx, values...)
+	}
+	return ctx
+}
+
+// ResolveLocale returns the catalog locale a greeting in ctx is written in, or "" when
+// no locale was requested.
+func ResolveLocale(ctx context.Context) string {
+	preferences := GreetOptionsFromContext(ctx).Preferences()
+	if len(preferences) == 0 {
+		return ""
+	}
+	return i18n.Default.Resolve(preferences...)
+}
@@ -0,0 +1,347 @@
+package svc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// TemplateExt is the extension of greeting template files. A template is named after
+// its file, without the extension.
+const TemplateExt = ".tmpl"
+
+// maxTemplateOutput bounds the size of a rendered greeting.
+const maxTemplateOutput = 4096
+
+// TemplateData is what a greeting template is executed with.
+type TemplateData struct {
+	Name     string
+	Greeting string // the localized greeting the template replaces
+	Locale   string
+	Formal   bool
+	Count    int
+	Hour     int // hour of the day on the server, 0-23
+}
+
+// templateFuncs are the only functions templates may call besides the harmless
+// builtins in allowedBuiltins.
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"title": strings.Title,
+}
+
+var allowedBuiltins = map[string]bool{
+	"and": true, "or": true, "not": true,
+	"eq": true, "ne": true, "lt": true, "le": true, "gt": true, "ge": true,
+	"len": true, "print": true, "printf": true, "println": true,
+}
+
+// Templates holds the greeting templates of a directory and reloads them when the
+// directory changes. Templates are sandboxed: they may only call templateFuncs and
+// allowedBuiltins, cannot loop or include other templates, and their output is
+// limited.
+type Templates struct {
+	dir string
+	now func() time.Time
+
+	mu        sync.RWMutex
+	templates map[string]*template.Template
+	hourly    map[string]bool // the templates reading .Hour
+	modTime   string
+}
+
+// LoadTemplates loads and validates every template in dir.
+func LoadTemplates(dir string) (*Templates, error) {
+	t := &Templates{dir: dir, now: time.Now}
+	if err := t.Reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Reload reads the templates again. If any of them is invalid the current templates
+// are kept and the error is returned.
+func (t *Templates) Reload() error {
+	modTime, err := t.latestModTime()
+	if err != nil {
+		return err
+	}
+	files, err := filepath.Glob(filepath.Join(t.dir, "*"+TemplateExt))
+	if err != nil {
+		return err
+	}
+	templates := map[string]*template.Template{}
+	hourly := map[string]bool{}
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f), TemplateExt)
+		tmpl, err := parseTemplate(name, f)
+		if err != nil {
+			return err
+		}
+		templates[name] = tmpl
+		hourly[name] = readsHour(tmpl.Root)
+	}
+	t.mu.Lock()
+	t.templates = templates
+	t.hourly = hourly
+	t.modTime = modTime
+	t.mu.Unlock()
+	return nil
+}
+
+// Watch polls the directory every interval and reloads the templates when it changes,
+// until stop is closed.
+func (t *Templates) Watch(interval time.Duration, logger *log.Logger, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			modTime, err := t.latestModTime()
+			t.mu.RLock()
+			changed := err == nil && modTime != t.modTime
+			t.mu.RUnlock()
+			if !changed {
+				continue
+			}
+			if err := t.Reload(); err != nil {
+				logger.Printf("failed to reload templates %s: %v", t.dir, err)
+				continue
+			}
+			logger.Printf("reloaded templates %s", t.dir)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Names returns the names of the loaded templates in order.
+func (t *Templates) Names() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var names []string
+	for name := range t.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Execute renders the named template. t may be nil, in which case no template is known.
+func (t *Templates) Execute(name string, data TemplateData) (string, error) {
+	if t == nil {
+		return "", ErrUnknownTemplate
+	}
+	t.mu.RLock()
+	tmpl, ok := t.templates[name]
+	t.mu.RUnlock()
+	if !ok {
+		return "", ErrUnknownTemplate
+	}
+	return execute(tmpl, data)
+}
+
+func (t *Templates) hour() int {
+	return t.now().Hour()
+}
+
+// Bucket returns what the named template renders differently over time besides the
+// request: the current hour when the template reads .Hour, and "" otherwise. Caches
+// add it to their key so a greeting is not served outside the hour it was rendered
+// for. t may be nil.
+func (t *Templates) Bucket(name string) string {
+	if t == nil {
+		return ""
+	}
+	t.mu.RLock()
+	hourly := t.hourly[name]
+	t.mu.RUnlock()
+	if !hourly {
+		return ""
+	}
+	return "hour=" + strconv.Itoa(t.hour())
+}
+
+// latestModTime summarizes the names and modification times of the directory's
+// templates, so adding, changing and removing one all count as a change.
+func (t *Templates) latestModTime() (string, error) {
+	if _, err := os.Stat(t.dir); err != nil {
+		return "", err
+	}
+	files, err := filepath.Glob(filepath.Join(t.dir, "*"+TemplateExt))
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s %d %d\n", f, fi.ModTime().UnixNano(), fi.Size())
+	}
+	return b.String(), nil
+}
+
+// parseTemplate parses and validates a template file.
+func parseTemplate(name, file string) (*template.Template, error) {
+	text, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(templateFuncs).Parse(string(text))
+	if err != nil {
+		return nil, err
+	}
+	if len(tmpl.Templates()) != 1 {
+		return nil, fmt.Errorf("template %s: define and block are not allowed", name)
+	}
+	if err := validate(tmpl.Tree.Root); err != nil {
+		return nil, fmt.Errorf("template %s: %v", name, err)
+	}
+	// catch references to unknown fields and similar mistakes up front
+	for _, data := range []TemplateData{{Name: "name", Greeting: "greeting", Locale: "en", Count: 1}, {Formal: true, Count: 2}} {
+		if _, err := execute(tmpl, data); err != nil {
+			return nil, err
+		}
+	}
+	return tmpl, nil
+}
+
+// validate rejects the parts of the template language the sandbox does not allow.
+func validate(node parse.Node) error {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := validate(child); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		return validate(n.Pipe)
+	case *parse.IfNode:
+		return validateBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return validateBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		return errors.New("range is not allowed")
+	case *parse.TemplateNode:
+		return errors.New("template is not allowed")
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, cmd := range n.Cmds {
+			if err := validate(cmd); err != nil {
+				return err
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if err := validate(arg); err != nil {
+				return err
+			}
+		}
+	case *parse.ChainNode:
+		return validate(n.Node)
+	case *parse.IdentifierNode:
+		if _, ok := templateFuncs[n.Ident]; !ok && !allowedBuiltins[n.Ident] {
+			return fmt.Errorf("function %s is not allowed", n.Ident)
+		}
+	}
+	return nil
+}
+
+// readsHour reports whether the template may read .Hour. Passing on the whole data,
+// as {{.}} or {{$}} do, counts as reading it.
+func readsHour(node parse.Node) bool {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, child := range n.Nodes {
+			if readsHour(child) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return readsHour(n.Pipe)
+	case *parse.IfNode:
+		return readsHour(n.Pipe) || readsHour(n.List) || readsHour(n.ElseList)
+	case *parse.WithNode:
+		return readsHour(n.Pipe) || readsHour(n.List) || readsHour(n.ElseList)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if readsHour(cmd) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if readsHour(arg) {
+				return true
+			}
+		}
+	case *parse.ChainNode:
+		return readsHour(n.Node) || (len(n.Field) > 0 && n.Field[0] == "Hour")
+	case *parse.FieldNode:
+		return n.Ident[0] == "Hour"
+	case *parse.VariableNode:
+		return len(n.Ident) == 1 || n.Ident[1] == "Hour"
+	case *parse.DotNode:
+		return true
+	}
+	return false
+}
+
+func validateBranch(n *parse.BranchNode) error {
+	for _, child := range []parse.Node{n.Pipe, n.List, n.ElseList} {
+		if err := validate(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func execute(tmpl *template.Template, data TemplateData) (string, error) {
+	w := &limitedBuffer{limit: maxTemplateOutput}
+	if err := tmpl.Execute(w, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(w.String()), nil
+}
+
+var errTemplateOutputTooLarge = fmt.Errorf("template output exceeds %d bytes", maxTemplateOutput)
+
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, errTemplateOutputTooLarge
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,158 @@
+package svc
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTemplates(t *testing.T, dir string, templates map[string]string) {
+	for name, text := range templates {
+		if err := ioutil.WriteFile(filepath.Join(dir, name+TemplateExt), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newTemplateDir(t *testing.T, templates map[string]string) string {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTemplates(t, dir, templates)
+	return dir
+}
+
+func Test_LoadTemplates(t *testing.T) {
+	tests := map[string]struct {
+		text string
+		err  string
+	}{
+		"valid":          {text: `{{if .Formal}}Good morning{{else}}Morning{{end}}, {{title .Name}}{{if gt .Count 1}} and co{{end}}`},
+		"parse_error":    {text: `{{.Name`, err: "unclosed action"},
+		"unknown_field":  {text: `{{.Password}}`, err: "can't evaluate field Password"},
+		"call":           {text: `{{call .Name}}`, err: "function call is not allowed"},
+		"builtin":        {text: `{{urlquery .Name}}`, err: "function urlquery is not allowed"},
+		"range":          {text: `{{range .Name}}x{{end}}`, err: "range is not allowed"},
+		"define":         {text: `{{define "x"}}x{{end}}{{.Name}}`, err: "define and block are not allowed"},
+		"nested_call":    {text: `{{if .Formal}}{{with .Name}}{{call .}}{{end}}{{end}}`, err: "function call is not allowed"},
+		"output_too_big": {text: `{{printf "%5000s" .Name}}`, err: "exceeds"},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		dir := newTemplateDir(t, map[string]string{name: test.text})
+		_, err := LoadTemplates(dir)
+		if test.err == "" {
+			assert.Nil(t, err)
+		} else if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), test.err)
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+func Test_TemplateGreeting(t *testing.T) {
+	dir := newTemplateDir(t, map[string]string{
+		"morning": `{{if lt .Hour 12}}Good morning{{else}}Good day{{end}}, {{.Name}}!`,
+		"shout":   `{{upper .Greeting}}`,
+		"blank":   `{{if false}}x{{end}}`,
+	})
+	defer os.RemoveAll(dir)
+	templates, err := LoadTemplates(dir)
+	assert.Nil(t, err)
+	templates.now = func() time.Time { return time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC) }
+	assert.Equal(t, []string{"blank", "morning", "shout"}, templates.Names())
+
+	tests := map[string]struct {
+		options  GreetOptions
+		greeter  GreetingService
+		expected string
+		err      error
+	}{
+		"morning":          {options: GreetOptions{Template: "morning"}, greeter: GreetingService{Templates: templates}, expected: "Good morning, Ann!"},
+		"localized":        {options: GreetOptions{Template: "shout", Locale: "de", Count: 2}, greeter: GreetingService{Templates: templates}, expected: "HALLO, ANN (2 PERSONEN)!"},
+		"default_locale":   {options: GreetOptions{Template: "shout"}, greeter: GreetingService{Templates: templates}, expected: "HI, ANN!"},
+		"empty":            {options: GreetOptions{Template: "blank"}, greeter: GreetingService{Templates: templates}, err: ErrEmptyGreeting},
+		"unknown":          {options: GreetOptions{Template: "evening"}, greeter: GreetingService{Templates: templates}, err: ErrUnknownTemplate},
+		"no_templates":     {options: GreetOptions{Template: "morning"}, err: ErrUnknownTemplate},
+		"without_template": {greeter: GreetingService{Templates: templates}, expected: "Ann"},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		greeting, err := test.greeter.Greet(NewGreetOptionsContext(context.Background(), test.options), "Ann")
+		assert.Equal(t, test.err, err)
+		assert.Equal(t, test.expected, greeting)
+	}
+}
+
+func Test_TemplateBucket(t *testing.T) {
+	dir := newTemplateDir(t, map[string]string{
+		"morning":  `{{if lt .Hour 12}}Good morning{{else}}Good day{{end}}, {{.Name}}!`,
+		"root":     `{{with .Name}}{{if lt $.Hour 12}}Morning{{end}}{{end}}`,
+		"printed":  `{{printf "%v" .}}`,
+		"shout":    `{{upper .Greeting}}`,
+		"personal": `{{if .Formal}}Dear {{title .Name}}{{else}}Hi {{.Name}}{{end}}`,
+	})
+	defer os.RemoveAll(dir)
+	templates, err := LoadTemplates(dir)
+	assert.Nil(t, err)
+	now := time.Date(2026, time.October, 19, 9, 30, 0, 0, time.UTC)
+	templates.now = func() time.Time { return now }
+
+	tests := map[string]struct {
+		templates *Templates
+		name      string
+		expected  string
+	}{
+		"hour":          {templates: templates, name: "morning", expected: "hour=9"},
+		"root_variable": {templates: templates, name: "root", expected: "hour=9"},
+		"whole_data":    {templates: templates, name: "printed", expected: "hour=9"},
+		"no_hour":       {templates: templates, name: "shout"},
+		"fields":        {templates: templates, name: "personal"},
+		"unknown":       {templates: templates, name: "evening"},
+		"no_templates":  {name: "morning"},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		assert.Equal(t, test.expected, test.templates.Bucket(test.name))
+	}
+
+	now = now.Add(time.Hour)
+	assert.Equal(t, "hour=10", templates.Bucket("morning"))
+}
+
+func Test_TemplatesReload(t *testing.T) {
+	dir := newTemplateDir(t, map[string]string{"hello": `Hello, {{.Name}}`})
+	defer os.RemoveAll(dir)
+	templates, err := LoadTemplates(dir)
+	assert.Nil(t, err)
+
+	var logs strings.Builder
+	stop := make(chan struct{})
+	defer close(stop)
+	go templates.Watch(10*time.Millisecond, log.New(&logs, "", 0), stop)
+
+	// an invalid template is reported and the loaded ones stay in use
+	writeTemplates(t, dir, map[string]string{"bad": `{{call .Name}}`})
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, []string{"hello"}, templates.Names())
+
+	os.Remove(filepath.Join(dir, "bad"+TemplateExt))
+	writeTemplates(t, dir, map[string]string{"hello": `Hey, {{.Name}}`})
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "hello"+TemplateExt), future, future)
+	assert.Eventually(t, func() bool {
+		greeting, _ := templates.Execute("hello", TemplateData{Name: "Ann"})
+		return greeting == "Hey, Ann"
+	}, time.Second, 10*time.Millisecond)
+}
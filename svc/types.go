@@ -1,12 +1,149 @@
 package svc
 
+// GreetRequest is the body of a v1 greeting request. The optional Locale, Formality
+// and Count localize the greeting, as in v2, and Template names a greeting template.
 type GreetRequest struct {
-	S string `json:"s"`
+	S         string `json:"s"`
+	Locale    string `json:"locale,omitempty"`
+	Formality string `json:"formality,omitempty"`
+	Count     int    `json:"count,omitempty"`
+	Template  string `json:"template,omitempty"`
 }
 
+// GreetResponse.Locale is the locale the greeting is written in, empty if none was
+// requested.
 type GreetResponse struct {
-	V   string `json:"greeting"`
-	Err string `json:"err,omitempty"` // errors don't JSON-marshal, so we use a string
+	V      string `json:"greeting"`
+	Err    string `json:"err,omitempty"` // errors don't JSON-marshal, so we use a string
+	Locale string `json:"locale,omitempty"`
+}
+
+type GreetBatchResponse struct {
+	Results []GreetResponse `json:"results"`
+	Err     string          `json:"err,omitempty"`
+}
+
+// GreetRequestV2 is the body of a v2 greeting request. Formality is "formal",
+// "informal" or empty.
+type GreetRequestV2 struct {
+	Name      string `json:"name"`
+	Locale    string `json:"locale,omitempty"`
+	Formality string `json:"formality,omitempty"`
+	Count     int    `json:"count,omitempty"`
+	Template  string `json:"template,omitempty"`
+}
+
+// StoredGreeting is a greeting from the history. CreatedAt is in RFC 3339 format.
+type StoredGreeting struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Greeting  string `json:"greeting"`
+	Locale    string `json:"locale,omitempty"`
+	Caller    string `json:"caller,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// GreetingHistoryResponse is a page of the greeting history, oldest first.
+type GreetingHistoryResponse struct {
+	Greetings     []StoredGreeting `json:"greetings"`
+	NextPageToken string           `json:"next_page_token,omitempty"`
+	Err           string           `json:"err,omitempty"`
+}
+
+// ExpensiveJob is an asynchronous run of the expensive operation. State is pending,
+// running, succeeded, failed or cancelled; Err is set when it failed. The result is
+// left out, as the result of Expensive may carry the credentials it was called with.
+// Times are in RFC 3339 format.
+type ExpensiveJob struct {
+	ID        string `json:"id"`
+	State     string `json:"state"`
+	Err       string `json:"err,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// ExpensiveJobEvent is the data of an expensive_job.completed webhook event, the job as
+// the job endpoints return it.
+type ExpensiveJobEvent struct {
+	ID        string `json:"id"`
+	State     string `json:"state"`
+	Err       string `json:"err,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// WebhookRequest registers URL for webhook events of the listed types, or of all
+// types when none are listed. A secret is generated when none is given.
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+// Webhook is a registered callback URL. The secret signing its deliveries is only
+// returned when it is registered.
+type Webhook struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	Secret    string   `json:"secret,omitempty"`
+	CreatedAt string   `json:"created_at"`
+}
+
+type WebhookListResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+	Err      string    `json:"err,omitempty"`
+}
+
+// WebhookAttempt is one try at delivering an event. StatusCode is omitted when no
+// response arrived.
+type WebhookAttempt struct {
+	At         string `json:"at"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Err        string `json:"err,omitempty"`
+}
+
+// WebhookDelivery is the delivery of an event to a webhook. State is pending,
+// delivered or dead.
+type WebhookDelivery struct {
+	ID        string           `json:"id"`
+	WebhookID string           `json:"webhook_id"`
+	URL       string           `json:"url"`
+	EventID   string           `json:"event_id"`
+	EventType string           `json:"event_type"`
+	State     string           `json:"state"`
+	Attempts  []WebhookAttempt `json:"attempts"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Err        string            `json:"err,omitempty"`
+}
+
+// GreetingEvent is the data of a greeting.created webhook event.
+type GreetingEvent struct {
+	Name     string `json:"name"`
+	Greeting string `json:"greeting"`
+	Locale   string `json:"locale,omitempty"`
+	Caller   string `json:"caller,omitempty"`
+}
+
+// CallEvent is published to the event bus for every call of the service. Like
+// ExpensiveJobEvent, it leaves out the result of Expensive, which may carry the
+// credentials it was called with.
+type CallEvent struct {
+	Method   string `json:"method"`
+	Name     string `json:"name,omitempty"`
+	Greeting string `json:"greeting,omitempty"`
+	Locale   string `json:"locale,omitempty"`
+	Caller   string `json:"caller,omitempty"`
+	Err      string `json:"err,omitempty"`
+	At       string `json:"at"`
+}
+
+// ErrorResponse reports why a request failed.
+type ErrorResponse struct {
+	Err string `json:"err"`
 }
 
 type ExpensiveRequest struct {
@@ -0,0 +1,1055 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: svc/v2/greeting.proto
+
+package svcv2 // import "github.com/tkeech1/gowebsvc/svc/v2"
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import _ "google.golang.org/genproto/googleapis/api/annotations"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type Formality int32
+
+const (
+	Formality_FORMALITY_UNSPECIFIED Formality = 0
+	Formality_INFORMAL              Formality = 1
+	Formality_FORMAL                Formality = 2
+)
+
+var Formality_name = map[int32]string{
+	0: "FORMALITY_UNSPECIFIED",
+	1: "INFORMAL",
+	2: "FORMAL",
+}
+var Formality_value = map[string]int32{
+	"FORMALITY_UNSPECIFIED": 0,
+	"INFORMAL":              1,
+	"FORMAL":                2,
+}
+
+func (x Formality) String() string {
+	return proto.EnumName(Formality_name, int32(x))
+}
+func (Formality) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_greeting_a6c6e73fbada950f, []int{0}
+}
+
+type OperationState int32
+
+const (
+	OperationState_OPERATION_STATE_UNSPECIFIED OperationState = 0
+	OperationState_PENDING                     OperationState = 1
+	OperationState_RUNNING                     OperationState = 2
+	OperationState_SUCCEEDED                   OperationState = 3
+	OperationState_FAILED                      OperationState = 4
+	OperationState_CANCELLED                   OperationState = 5
+)
+
+var OperationState_name = map[int32]string{
+	0: "OPERATION_STATE_UNSPECIFIED",
+	1: "PENDING",
+	2: "RUNNING",
+	3: "SUCCEEDED",
+	4: "FAILED",
+	5: "CANCELLED",
+}
+var OperationState_value = map[string]int32{
+	"OPERATION_STATE_UNSPECIFIED": 0,
+	"PENDING":                     1,
+	"RUNNING":                     2,
+	"SUCCEEDED":                   3,
+	"FAILED":                      4,
+	"CANCELLED":                   5,
+}
+
+func (x OperationState) String() string {
+	return proto.EnumName(OperationState_name, int32(x))
+}
+func (OperationState) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_greeting_a6c6e73fbada950f, []int{1}
+}
+
+// The request message containing the name to greet and how to greet it.
+type GreetRequest struct {
+	Name      string    `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Locale    string    `protobuf:"bytes,2,opt,name=locale,proto3" json:"locale,omitempty"`
+	Formality Formality `protobuf:"varint,3,opt,name=formality,proto3,enum=svc.v2.Formality" json:"formality,omitempty"`
+	// The number of people greeted; 0 and 1 greet a single person.
+	Count int32 `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	// The name of a greeting template loaded by the server, if any.
+	Template             string   `protobuf:"bytes,5,opt,name=template,proto3" json:"template,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GreetRequest) Reset()         { *m = GreetRequest{} }
+func (m *GreetRequest) String() string { return proto.CompactTextString(m) }
+func (*GreetRequest) ProtoMessage()    {}
+func (*GreetRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_greeting_a6c6e73fbada950f, []int{0}
+}
+func (m *GreetRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GreetRequest.Unmarshal(m, b)
+}
+func (m *GreetRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GreetRequest.Marshal(b, m, deterministic)
+}
+func (dst *GreetRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GreetRequest.Merge(dst, src)
+}
+func (m *GreetRequest) XXX_Size() int {
+	return xxx_messageInfo_GreetRequest.Size(m)
+}
+func (m *GreetRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GreetRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GreetRequest proto.InternalMessageInfo
+
+func (m *GreetRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *GreetRequest) GetLocale() string {
+	if m != nil {
+		return m.Locale
+	}
+	return ""
+}
+
+func (m *GreetRequest) GetFormality() Formality {
+	if m != nil {
+		return m.Formality
+	}
+	return Formality_FORMALITY_UNSPECIFIED
+}
+
+func (m *GreetRequest) GetCount() int32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *GreetRequest) GetTemplate() string {
+	if m != nil {
+		return m.Template
+	}
+	return ""
+}
+
+// The response message containing the greeting.
+type GreetResponse struct {
+	Greeting string `protobuf:"bytes,1,opt,name=greeting,proto3" json:"greeting,omitempty"`
+	// The locale the greeting is written in, empty if none was requested.
+	Locale               string   `protobuf:"bytes,2,opt,name=locale,proto3" json:"locale,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GreetResponse) Reset()         { *m = GreetResponse{} }
+func (m *GreetResponse) String() string { return proto.CompactTextString(m) }
+func (*GreetResponse) ProtoMessage()    {}
+func (*GreetResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_greeting_a6c6e73fbada950f, []int{1}
+}
+func (m *GreetResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GreetResponse.Unmarshal(m, b)
+}
+func (m *GreetResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GreetResponse.Marshal(b, m, deterministic)
+}
+func (dst *GreetResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GreetResponse.Merge(dst, src)
+}
+func (m *GreetResponse) XXX_Size() int {
+	return xxx_messageInfo_GreetResponse.Size(m)
+}
+func (m *GreetResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GreetResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GreetResponse proto.InternalMessageInfo
+
+func (m *GreetResponse) GetGreeting() string {
+	if m != nil {
+		return m.Greeting
+	}
+	return ""
+}
+
+func (m *GreetResponse) GetLocale() string {
+	if m != nil {
+		return m.Locale
+	}
+	return ""
+}
+
+// The request message containing the connection details for the expensive operation.
+type ExpensiveRequest struct {
+	ConnectionString     string   `protobuf:"bytes,1,opt,name=connection_string,json=connectionString,proto3" json:"connection_string,omitempty"`
+	Username             string   `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Password             string   `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExpensiveRequest) Reset()         { *m = ExpensiveRequest{} }
+func (m *ExpensiveRequest) String() string { return proto.CompactTextString(m) }
+func (*ExpensiveRequest) ProtoMessage()    {}
+func (*ExpensiveRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_greeting_a6c6e73fbada950f, []int{2}
+}
+func (m *ExpensiveRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExpensiveRequest.Unmarshal(m, b)
+}
+func (m *ExpensiveRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExpensiveRequest.Marshal(b, m, deterministic)
+}
+func (dst *ExpensiveRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExpensiveRequest.Merge(dst, src)
+}
+func (m *ExpensiveRequest) XXX_Size() int {
+	return xxx_messageInfo_ExpensiveRequest.Size(m)
+}
+func (m *ExpensiveRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExpensiveRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExpensiveRequest proto.InternalMessageInfo
+
+func (m *ExpensiveRequest) GetConnectionString() string {
+	if m != nil {
+		return m.ConnectionString
+	}
+	return ""
+}
+
+func (m *ExpensiveRequest) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+func (m *ExpensiveRequest) GetPassword() string {
+	if m != nil {
+		return m.Password
+	}
+	return ""
+}
+
+// The response message containing the result of the expensive operation.
+type ExpensiveResponse struct {
+	Status               string   `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExpensiveResponse) Reset()         { *m = ExpensiveResponse{} }
+func (m *ExpensiveResponse) String() string { return proto.CompactTextString(m) }
+func (*ExpensiveResponse) ProtoMessage()    {}
+func (*ExpensiveResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_greeting_a6c6e73fbada950f, []int{3}
+}
+func (m *ExpensiveResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExpensiveResponse.Unmarshal(m, b)
+}
+func (m *ExpensiveResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExpensiveResponse.Marshal(b, m, deterministic)
+}
+func (dst *ExpensiveResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExpensiveResponse.Merge(dst, src)
+}
+func (m *ExpensiveResponse) XXX_Size() int {
+	return xxx_messageInfo_ExpensiveResponse.Size(m)
+}
+func (m *ExpensiveResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExpensiveResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExpensiveResponse proto.InternalMessageInfo
+
+func (m *ExpensiveResponse) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+type GetGreetingRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetGreetingRequest) Reset()         { *m = GetGreetingRequest{} }
+func (m *GetGreetingRequest) String() string { return proto.CompactTextString(m) }
+func (*GetGreetingRequest) ProtoMessage()    {}
+func (*GetGreetingRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_greeting_a6c6e73fbada950f, []int{4}
+}
+func (m *GetGreetingRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetGreetingRequest.Unmarshal(m, b)
+}
+func (m *GetGreetingRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetGreetingRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetGreetingRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetGreetingRequest.Merge(dst, src)
+}
+func (m *GetGreetingRequest) XXX_Size() int {
+	return xxx_messageInfo_GetGreetingRequest.Size(m)
+}
+func (m *GetGreetingRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetGreetingRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetGreetingRequest proto.InternalMessageInfo
+
+func (m *GetGreetingRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+// A greeting served by the service.
+type StoredGreeting struct {
+	Id       string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name     string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Greeting string `protobuf:"bytes,3,opt,name=greeting,proto3" json:"greeting,omitempty"`
+	Locale   string `protobuf:"bytes,4,opt,name=locale,proto3" json:"locale,omitempty"`
+	Caller   string `protobuf:"bytes,5,opt,name=caller,proto3" json:"caller,omitempty"`
+	// RFC 3339
+	CreatedAt            string   `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StoredGreeting) Reset()         { *m = StoredGreeting{} }
+func (m *StoredGreeting) String() string { return proto.CompactTextString(m) }
+func (*StoredGreeting) ProtoMessage()    {}
+func (*StoredGreeting) Descriptor() ([]byte, []int) {
+	return fileDescriptor_greeting_a6c6e73fbada950f, []int{5}
+}
+func (m *StoredGreeting) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StoredGreeting.Unmarshal(m, b)
+}
+func (m *StoredGreeting) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StoredGreeting.Marshal(b, m, deterministic)
+}
+func (dst *StoredGreeting) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StoredGreeting.Merge(dst, src)
+}
+func (m *StoredGreeting) XXX_Size() int {
+	return xxx_messageInfo_StoredGreeting.Size(m)
+}
+func (m *StoredGreeting) XXX_DiscardUnknown() {
+	xxx_messageInfo_StoredGreeting.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StoredGreeting proto.InternalMessageInfo
+
+func (m *StoredGreeting) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *StoredGreeting) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *StoredGreeting) GetGreeting() string {
+	if m != nil {
+		return m.Greeting
+	}
+	return ""
+}
+
+func (m *StoredGreeting) GetLocale() string {
+	if m != nil {
+		return m.Locale
+	}
+	return ""
+}
+
+func (m *StoredGreeting) GetCaller() string {
+	if m != nil {
+		return m.Caller
+	}
+	return ""
+}
+
+func (m *StoredGreeting) GetCreatedAt() string {
+	if m != nil {
+		return m.CreatedAt
+	}
+	return ""
+}
+
+type ListGreetingsRequest struct {
+	// Only greetings created at or after this RFC 3339 time are listed.
+	Since                string   `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`
+	PageToken            string   `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	PageSize             int32    `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListGreetingsRequest) Reset()         { *m = ListGreetingsRequest{} }
+func (m *ListGreetingsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListGreetingsRequest) ProtoMessage()    {}
+func (*ListGreetingsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_greeting_a6c6e73fbada950f, []int{6}
+}
+func (m *ListGreetingsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListGreetingsRequest.Unmarshal(m, b)
+}
+func (m *ListGreetingsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListGreetingsRequest.Marshal(b, m, deterministic)
+}
+func (dst *ListGreetingsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListGreetingsRequest.Merge(dst, src)
+}
+func (m *ListGreetingsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListGreetingsRequest.Size(m)
+}
+func (m *ListGreetingsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListGreetingsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListGreetingsRequest proto.InternalMessageInfo
+
+func (m *ListGreetingsRequest) GetSince() string {
+	if m != nil {
+		return m.Since
+	}
+	return ""
+}
+
+func (m *ListGreetingsRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+func (m *ListGreetingsRequest) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+type ListGreetingsResponse struct {
+	Greetings []*StoredGreeting `protobuf:"bytes,1,rep,name=greetings,proto3" json:"greetings,omitempty"`
+	// Pass as page_token to get the next page; empty on the last page.
+	NextPageToken        string   `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListGreetingsResponse) Reset()         { *m = ListGreetingsResponse{} }
+func (m *ListGreetingsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListGreetingsResponse) ProtoMessage()    {}
+func (*ListGreetingsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_greeting_a6c6e73fbada950f, []int{7}
+}
+func (m *ListGreetingsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListGreetingsResponse.Unmarshal(m, b)
+}
+func (m *ListGreetingsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListGreetingsResponse.Marshal(b, m, deterministic)
+}
+func (dst *ListGreetingsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListGreetingsResponse.Merge(dst, src)
+}
+func (m *ListGreetingsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListGreetingsResponse.Size(m)
+}
+func (m *ListGreetingsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListGreetingsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListGreetingsResponse proto.InternalMessageInfo
+
+func (m *ListGreetingsResponse) GetGreetings() []*StoredGreeting {
+	if m != nil {
+		return m.Greetings
+	}
+	return nil
+}
+
+func (m *ListGreetingsResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
+// A background run of the expensive operation, modelled on
+// google.longrunning.Operation.
+type Operation struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Whether the operation has finished; error is then set if it failed.
+	Done  bool           `protobuf:"varint,2,opt,name=done,proto3" json:"done,omitempty"`
+	State OperationState `protobuf:"varint,3,opt,name=state,proto3,enum=svc.v2.OperationState" json:"state,omitempty"`
+	// Not set: the result of the expensive operation may carry the credentials it
+	// was started with.
+	Response *ExpensiveResponse `protobuf:"bytes,4,opt,name=response,proto3" json:"response,omitempty"`
+	Error    string             `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	// RFC 3339
+	CreatedAt            string   `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt            string   `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Operation) Reset()         { *m = Operation{} }
+func (m *Operation) String() string { return proto.CompactTextString(m) }
+func (*Operation) ProtoMessage()    {}
+func (*Operation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_greeting_a6c6e73fbada950f, []int{8}
+}
+func (m *Operation) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Operation.Unmarshal(m, b)
+}
+func (m *Operation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Operation.Marshal(b, m, deterministic)
+}
+func (dst *Operation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Operation.Merge(dst, src)
+}
+func (m *Operation) XXX_Size() int {
+	return xxx_messageInfo_Operation.Size(m)
+}
+func (m *Operation) XXX_DiscardUnknown() {
+	xxx_messageInfo_Operation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Operation proto.InternalMessageInfo
+
+func (m *Operation) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Operation) GetDone() bool {
+	if m != nil {
+		return m.Done
+	}
+	return false
+}
+
+func (m *Operation) GetState() OperationState {
+	if m != nil {
+		return m.State
+	}
+	return OperationState_OPERATION_STATE_UNSPECIFIED
+}
+
+func (m *Operation) GetResponse() *ExpensiveResponse {
+	if m != nil {
+		return m.Response
+	}
+	return nil
+}
+
+func (m *Operation) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *Operation) GetCreatedAt() string {
+	if m != nil {
+		return m.CreatedAt
+	}
+	return ""
+}
+
+func (m *Operation) GetUpdatedAt() string {
+	if m != nil {
+		return m.UpdatedAt
+	}
+	return ""
+}
+
+type GetOperationRequest struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetOperationRequest) Reset()         { *m = GetOperationRequest{} }
+func (m *GetOperationRequest) String() string { return proto.CompactTextString(m) }
+func (*GetOperationRequest) ProtoMessage()    {}
+func (*GetOperationRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_greeting_a6c6e73fbada950f, []int{9}
+}
+func (m *GetOperationRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetOperationRequest.Unmarshal(m, b)
+}
+func (m *GetOperationRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetOperationRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetOperationRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetOperationRequest.Merge(dst, src)
+}
+func (m *GetOperationRequest) XXX_Size() int {
+	return xxx_messageInfo_GetOperationRequest.Size(m)
+}
+func (m *GetOperationRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetOperationRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetOperationRequest proto.InternalMessageInfo
+
+func (m *GetOperationRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type CancelOperationRequest struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CancelOperationRequest) Reset()         { *m = CancelOperationRequest{} }
+func (m *CancelOperationRequest) String() string { return proto.CompactTextString(m) }
+func (*CancelOperationRequest) ProtoMessage()    {}
+func (*CancelOperationRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_greeting_a6c6e73fbada950f, []int{10}
+}
+func (m *CancelOperationRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelOperationRequest.Unmarshal(m, b)
+}
+func (m *CancelOperationRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelOperationRequest.Marshal(b, m, deterministic)
+}
+func (dst *CancelOperationRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelOperationRequest.Merge(dst, src)
+}
+func (m *CancelOperationRequest) XXX_Size() int {
+	return xxx_messageInfo_CancelOperationRequest.Size(m)
+}
+func (m *CancelOperationRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelOperationRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelOperationRequest proto.InternalMessageInfo
+
+func (m *CancelOperationRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*GreetRequest)(nil), "svc.v2.GreetRequest")
+	proto.RegisterType((*GreetResponse)(nil), "svc.v2.GreetResponse")
+	proto.RegisterType((*ExpensiveRequest)(nil), "svc.v2.ExpensiveRequest")
+	proto.RegisterType((*ExpensiveResponse)(nil), "svc.v2.ExpensiveResponse")
+	proto.RegisterType((*GetGreetingRequest)(nil), "svc.v2.GetGreetingRequest")
+	proto.RegisterType((*StoredGreeting)(nil), "svc.v2.StoredGreeting")
+	proto.RegisterType((*ListGreetingsRequest)(nil), "svc.v2.ListGreetingsRequest")
+	proto.RegisterType((*ListGreetingsResponse)(nil), "svc.v2.ListGreetingsResponse")
+	proto.RegisterType((*Operation)(nil), "svc.v2.Operation")
+	proto.RegisterType((*GetOperationRequest)(nil), "svc.v2.GetOperationRequest")
+	proto.RegisterType((*CancelOperationRequest)(nil), "svc.v2.CancelOperationRequest")
+	proto.RegisterEnum("svc.v2.Formality", Formality_name, Formality_value)
+	proto.RegisterEnum("svc.v2.OperationState", OperationState_name, OperationState_value)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// GreetingServiceClient is the client API for GreetingService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type GreetingServiceClient interface {
+	// Sends a greeting
+	Greet(ctx context.Context, in *GreetRequest, opts ...grpc.CallOption) (*GreetResponse, error)
+	// Runs the expensive operation
+	Expensive(ctx context.Context, in *ExpensiveRequest, opts ...grpc.CallOption) (*ExpensiveResponse, error)
+	// Starts the expensive operation in the background. The returned operation is
+	// polled with GetOperation until it is done.
+	StartExpensive(ctx context.Context, in *ExpensiveRequest, opts ...grpc.CallOption) (*Operation, error)
+	// Returns the state of a background operation
+	GetOperation(ctx context.Context, in *GetOperationRequest, opts ...grpc.CallOption) (*Operation, error)
+	// Cancels a background operation that is not done yet
+	CancelOperation(ctx context.Context, in *CancelOperationRequest, opts ...grpc.CallOption) (*Operation, error)
+	// Returns a greeting from the history
+	GetGreeting(ctx context.Context, in *GetGreetingRequest, opts ...grpc.CallOption) (*StoredGreeting, error)
+	// Lists the greeting history, oldest first
+	ListGreetings(ctx context.Context, in *ListGreetingsRequest, opts ...grpc.CallOption) (*ListGreetingsResponse, error)
+}
+
+type greetingServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewGreetingServiceClient(cc *grpc.ClientConn) GreetingServiceClient {
+	return &greetingServiceClient{cc}
+}
+
+func (c *greetingServiceClient) Greet(ctx context.Context, in *GreetRequest, opts ...grpc.CallOption) (*GreetResponse, error) {
+	out := new(GreetResponse)
+	err := c.cc.Invoke(ctx, "/svc.v2.GreetingService/Greet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *greetingServiceClient) Expensive(ctx context.Context, in *ExpensiveRequest, opts ...grpc.CallOption) (*ExpensiveResponse, error) {
+	out := new(ExpensiveResponse)
+	err := c.cc.Invoke(ctx, "/svc.v2.GreetingService/Expensive", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *greetingServiceClient) StartExpensive(ctx context.Context, in *ExpensiveRequest, opts ...grpc.CallOption) (*Operation, error) {
+	out := new(Operation)
+	err := c.cc.Invoke(ctx, "/svc.v2.GreetingService/StartExpensive", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *greetingServiceClient) GetOperation(ctx context.Context, in *GetOperationRequest, opts ...grpc.CallOption) (*Operation, error) {
+	out := new(Operation)
+	err := c.cc.Invoke(ctx, "/svc.v2.GreetingService/GetOperation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *greetingServiceClient) CancelOperation(ctx context.Context, in *CancelOperationRequest, opts ...grpc.CallOption) (*Operation, error) {
+	out := new(Operation)
+	err := c.cc.Invoke(ctx, "/svc.v2.GreetingService/CancelOperation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *greetingServiceClient) GetGreeting(ctx context.Context, in *GetGreetingRequest, opts ...grpc.CallOption) (*StoredGreeting, error) {
+	out := new(StoredGreeting)
+	err := c.cc.Invoke(ctx, "/svc.v2.GreetingService/GetGreeting", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *greetingServiceClient) ListGreetings(ctx context.Context, in *ListGreetingsRequest, opts ...grpc.CallOption) (*ListGreetingsResponse, error) {
+	out := new(ListGreetingsResponse)
+	err := c.cc.Invoke(ctx, "/svc.v2.GreetingService/ListGreetings", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GreetingServiceServer is the server API for GreetingService service.
+type GreetingServiceServer interface {
+	// Sends a greeting
+	Greet(context.Context, *GreetRequest) (*GreetResponse, error)
+	// Runs the expensive operation
+	Expensive(context.Context, *ExpensiveRequest) (*ExpensiveResponse, error)
+	// Starts the expensive operation in the background. The returned operation is
+	// polled with GetOperation until it is done.
+	StartExpensive(context.Context, *ExpensiveRequest) (*Operation, error)
+	// Returns the state of a background operation
+	GetOperation(context.Context, *GetOperationRequest) (*Operation, error)
+	// Cancels a background operation that is not done yet
+	CancelOperation(context.Context, *CancelOperationRequest) (*Operation, error)
+	// Returns a greeting from the history
+	GetGreeting(context.Context, *GetGreetingRequest) (*StoredGreeting, error)
+	// Lists the greeting history, oldest first
+	ListGreetings(context.Context, *ListGreetingsRequest) (*ListGreetingsResponse, error)
+}
+
+func RegisterGreetingServiceServer(s *grpc.Server, srv GreetingServiceServer) {
+	s.RegisterService(&_GreetingService_serviceDesc, srv)
+}
+
+func _GreetingService_Greet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GreetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GreetingServiceServer).Greet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/svc.v2.GreetingService/Greet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GreetingServiceServer).Greet(ctx, req.(*GreetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GreetingService_Expensive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExpensiveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GreetingServiceServer).Expensive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/svc.v2.GreetingService/Expensive",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GreetingServiceServer).Expensive(ctx, req.(*ExpensiveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GreetingService_StartExpensive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExpensiveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GreetingServiceServer).StartExpensive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/svc.v2.GreetingService/StartExpensive",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GreetingServiceServer).StartExpensive(ctx, req.(*ExpensiveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GreetingService_GetOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GreetingServiceServer).GetOperation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/svc.v2.GreetingService/GetOperation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GreetingServiceServer).GetOperation(ctx, req.(*GetOperationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GreetingService_CancelOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GreetingServiceServer).CancelOperation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/svc.v2.GreetingService/CancelOperation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GreetingServiceServer).CancelOperation(ctx, req.(*CancelOperationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GreetingService_GetGreeting_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGreetingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GreetingServiceServer).GetGreeting(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/svc.v2.GreetingService/GetGreeting",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GreetingServiceServer).GetGreeting(ctx, req.(*GetGreetingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GreetingService_ListGreetings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGreetingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GreetingServiceServer).ListGreetings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/svc.v2.GreetingService/ListGreetings",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GreetingServiceServer).ListGreetings(ctx, req.(*ListGreetingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _GreetingService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "svc.v2.GreetingService",
+	HandlerType: (*GreetingServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Greet",
+			Handler:    _GreetingService_Greet_Handler,
+		},
+		{
+			MethodName: "Expensive",
+			Handler:    _GreetingService_Expensive_Handler,
+		},
+		{
+			MethodName: "StartExpensive",
+			Handler:    _GreetingService_StartExpensive_Handler,
+		},
+		{
+			MethodName: "GetOperation",
+			Handler:    _GreetingService_GetOperation_Handler,
+		},
+		{
+			MethodName: "CancelOperation",
+			Handler:    _GreetingService_CancelOperation_Handler,
+		},
+		{
+			MethodName: "GetGreeting",
+			Handler:    _GreetingService_GetGreeting_Handler,
+		},
+		{
+			MethodName: "ListGreetings",
+			Handler:    _GreetingService_ListGreetings_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "svc/v2/greeting.proto",
+}
+
+func init() { proto.RegisterFile("svc/v2/greeting.proto", fileDescriptor_greeting_a6c6e73fbada950f) }
+
+var fileDescriptor_greeting_a6c6e73fbada950f = []byte{
+	// 928 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x56, 0xcd, 0x6e, 0xe3, 0x54,
+	0x14, 0xc6, 0x69, 0x92, 0xa9, 0x4f, 0x9b, 0x36, 0xb9, 0x24, 0x99, 0x34, 0x9d, 0x42, 0xb1, 0x10,
+	0x0a, 0x99, 0x51, 0x2c, 0x02, 0x6c, 0x60, 0x43, 0x48, 0xdc, 0x28, 0x52, 0x27, 0xad, 0x9c, 0x74,
+	0xc1, 0x08, 0x14, 0xb9, 0xce, 0x21, 0x35, 0x93, 0xfa, 0x06, 0xdf, 0x9b, 0x74, 0x98, 0xd1, 0x6c,
+	0x78, 0x05, 0xd6, 0x88, 0x3d, 0xaf, 0xc3, 0x2b, 0xb0, 0xe4, 0x21, 0xd0, 0xbd, 0xf6, 0x75, 0x9d,
+	0xbf, 0xce, 0xec, 0xfc, 0x9d, 0x73, 0xfc, 0x9d, 0x9f, 0xef, 0xf8, 0xc8, 0x50, 0x62, 0x0b, 0xd7,
+	0x5c, 0x34, 0xcd, 0x49, 0x80, 0xc8, 0x3d, 0x7f, 0xd2, 0x98, 0x05, 0x94, 0x53, 0x92, 0x65, 0x0b,
+	0xb7, 0xb1, 0x68, 0x56, 0x9f, 0x4c, 0x28, 0x9d, 0x4c, 0xd1, 0x74, 0x66, 0x9e, 0xe9, 0xf8, 0x3e,
+	0xe5, 0x0e, 0xf7, 0xa8, 0xcf, 0xc2, 0x28, 0xe3, 0x4f, 0x0d, 0xf6, 0xbb, 0xe2, 0x45, 0x1b, 0x7f,
+	0x9d, 0x23, 0xe3, 0x84, 0x40, 0xda, 0x77, 0x6e, 0xb1, 0xa2, 0x9d, 0x6a, 0x35, 0xdd, 0x96, 0xcf,
+	0xa4, 0x0c, 0xd9, 0x29, 0x75, 0x9d, 0x29, 0x56, 0x52, 0xd2, 0x1a, 0x21, 0x62, 0x82, 0xfe, 0x33,
+	0x0d, 0x6e, 0x9d, 0xa9, 0xc7, 0x7f, 0xab, 0xec, 0x9c, 0x6a, 0xb5, 0x83, 0x66, 0xa1, 0x11, 0xa6,
+	0x6d, 0x9c, 0x29, 0x87, 0x7d, 0x1f, 0x43, 0x8a, 0x90, 0x71, 0xe9, 0xdc, 0xe7, 0x95, 0xf4, 0xa9,
+	0x56, 0xcb, 0xd8, 0x21, 0x20, 0x55, 0xd8, 0xe5, 0x78, 0x3b, 0x9b, 0x3a, 0x1c, 0x2b, 0x19, 0x99,
+	0x20, 0xc6, 0x46, 0x1b, 0x72, 0x51, 0x79, 0x6c, 0x46, 0x7d, 0x86, 0x22, 0x58, 0x35, 0x1a, 0xd5,
+	0x18, 0xe3, 0x6d, 0x75, 0x1a, 0x77, 0x90, 0xb7, 0x5e, 0xcd, 0xd0, 0x67, 0xde, 0x02, 0x55, 0x9f,
+	0x4f, 0xa1, 0xe0, 0x52, 0xdf, 0x47, 0x57, 0x4c, 0x63, 0xc4, 0x78, 0x70, 0x4f, 0x98, 0xbf, 0x77,
+	0x0c, 0xa4, 0x5d, 0x24, 0x9d, 0x33, 0x0c, 0xe4, 0x60, 0x42, 0xea, 0x18, 0x0b, 0xdf, 0xcc, 0x61,
+	0xec, 0x8e, 0x06, 0x63, 0x39, 0x03, 0xdd, 0x8e, 0xb1, 0xf1, 0x14, 0x0a, 0x89, 0xc4, 0x51, 0x07,
+	0x65, 0xc8, 0x32, 0xee, 0xf0, 0x39, 0x8b, 0xd2, 0x45, 0xc8, 0xf8, 0x14, 0x48, 0x17, 0x79, 0x37,
+	0x6a, 0x46, 0xd5, 0x79, 0x00, 0x29, 0x6f, 0x1c, 0x45, 0xa6, 0xbc, 0xb1, 0xf1, 0x97, 0x06, 0x07,
+	0x03, 0x4e, 0x03, 0x1c, 0xab, 0xc8, 0xd5, 0x90, 0x58, 0xc2, 0x54, 0x42, 0xc2, 0xe4, 0xd8, 0x76,
+	0xb6, 0x8e, 0x2d, 0xbd, 0x24, 0x6f, 0x19, 0xb2, 0xae, 0x33, 0x9d, 0x62, 0x10, 0xa9, 0x12, 0x21,
+	0x72, 0x02, 0xe0, 0x06, 0xe8, 0x70, 0x1c, 0x8f, 0x1c, 0x5e, 0xc9, 0x4a, 0x9f, 0x1e, 0x59, 0x5a,
+	0xdc, 0xb8, 0x81, 0xe2, 0xb9, 0xc7, 0xe2, 0x46, 0x98, 0xea, 0xa4, 0x08, 0x19, 0xe6, 0xf9, 0xae,
+	0x5a, 0xad, 0x10, 0x08, 0xb2, 0x99, 0x33, 0xc1, 0x11, 0xa7, 0x2f, 0xd1, 0x8f, 0x4a, 0xd6, 0x85,
+	0x65, 0x28, 0x0c, 0xe4, 0x18, 0x24, 0x18, 0x31, 0xef, 0x35, 0xca, 0xc2, 0x33, 0x62, 0xbc, 0x13,
+	0x1c, 0x78, 0xaf, 0xd1, 0x98, 0x43, 0x69, 0x25, 0x53, 0x34, 0xe2, 0xaf, 0x40, 0x57, 0xdd, 0x89,
+	0x29, 0xef, 0xd4, 0xf6, 0x9a, 0x65, 0xb5, 0x98, 0xcb, 0xc3, 0xb3, 0xef, 0x03, 0xc9, 0x67, 0x70,
+	0xe8, 0xe3, 0x2b, 0x3e, 0x5a, 0xab, 0x27, 0x27, 0xcc, 0x97, 0xaa, 0x26, 0xe3, 0x3f, 0x0d, 0xf4,
+	0x8b, 0x19, 0x06, 0xf2, 0x43, 0xda, 0xf8, 0xc1, 0x10, 0x48, 0x8f, 0xa9, 0x1f, 0x2a, 0xb0, 0x6b,
+	0xcb, 0x67, 0xf2, 0x0c, 0x32, 0x42, 0x68, 0x8c, 0x3e, 0x94, 0xb8, 0x9e, 0x98, 0x69, 0x20, 0xbc,
+	0x76, 0x18, 0x44, 0xbe, 0x86, 0xdd, 0x20, 0xea, 0x46, 0xaa, 0xb2, 0xd7, 0x3c, 0x52, 0x2f, 0xac,
+	0x6d, 0x94, 0x1d, 0x87, 0x8a, 0x19, 0x63, 0x10, 0x50, 0xa5, 0x58, 0x08, 0xde, 0x21, 0x98, 0x70,
+	0xcf, 0x67, 0x63, 0xe5, 0x7e, 0x14, 0xba, 0x23, 0x4b, 0x8b, 0x1b, 0x9f, 0xc3, 0x87, 0x5d, 0xe4,
+	0x71, 0x99, 0x0f, 0x1c, 0x0a, 0xe3, 0x19, 0x94, 0xdb, 0x8e, 0xef, 0xe2, 0xf4, 0x7d, 0xa2, 0xeb,
+	0xdf, 0x81, 0x1e, 0x5f, 0x09, 0x72, 0x04, 0xa5, 0xb3, 0x0b, 0xfb, 0x79, 0xeb, 0xbc, 0x37, 0xfc,
+	0x61, 0x74, 0xd5, 0x1f, 0x5c, 0x5a, 0xed, 0xde, 0x59, 0xcf, 0xea, 0xe4, 0x3f, 0x20, 0xfb, 0xb0,
+	0xdb, 0xeb, 0x87, 0xce, 0xbc, 0x46, 0x00, 0xb2, 0xd1, 0x73, 0xaa, 0x3e, 0x87, 0x83, 0xe5, 0xf1,
+	0x91, 0x8f, 0xe1, 0xf8, 0xe2, 0xd2, 0xb2, 0x5b, 0xc3, 0xde, 0x45, 0x7f, 0x34, 0x18, 0xb6, 0x86,
+	0xd6, 0x0a, 0xd9, 0x1e, 0x3c, 0xba, 0xb4, 0xfa, 0x9d, 0x5e, 0xbf, 0x9b, 0xd7, 0x04, 0xb0, 0xaf,
+	0xfa, 0x7d, 0x01, 0x52, 0x24, 0x07, 0xfa, 0xe0, 0xaa, 0xdd, 0xb6, 0xac, 0x8e, 0xd5, 0xc9, 0xef,
+	0xc8, 0x3c, 0xad, 0xde, 0xb9, 0xd5, 0xc9, 0xa7, 0x85, 0xab, 0xdd, 0xea, 0xb7, 0xad, 0x73, 0x01,
+	0x33, 0xcd, 0xbf, 0x33, 0x70, 0xa8, 0x16, 0x68, 0x80, 0xc1, 0xc2, 0x73, 0x91, 0x3c, 0x87, 0x8c,
+	0x34, 0x91, 0xa2, 0xd2, 0x29, 0x79, 0x56, 0xab, 0xa5, 0x15, 0x6b, 0xa8, 0x97, 0xf1, 0xf8, 0xf7,
+	0x7f, 0xfe, 0xfd, 0x23, 0x55, 0x30, 0xf6, 0x93, 0x07, 0xfc, 0x1b, 0xad, 0x4e, 0x5e, 0x80, 0x1e,
+	0xeb, 0x4c, 0x2a, 0x1b, 0xa4, 0x0f, 0x69, 0xb7, 0x2f, 0x85, 0x51, 0x91, 0xd4, 0xc4, 0xc8, 0x09,
+	0x6a, 0x54, 0x6e, 0xc1, 0xfd, 0xa3, 0xb8, 0x20, 0x4e, 0xc0, 0xdf, 0x27, 0x41, 0x61, 0x6d, 0x4d,
+	0x8d, 0x13, 0x49, 0xfc, 0xd8, 0x20, 0x4b, 0xc4, 0xe6, 0x2f, 0xf4, 0x9a, 0x09, 0x76, 0x07, 0xf6,
+	0x93, 0xeb, 0x42, 0x8e, 0xe3, 0xce, 0xd7, 0x97, 0x68, 0x13, 0xfd, 0x27, 0x92, 0xfe, 0x98, 0x1c,
+	0xad, 0xd3, 0x9b, 0x6f, 0xc4, 0xde, 0xbc, 0x25, 0x13, 0x38, 0x5c, 0x59, 0x33, 0xf2, 0x91, 0x22,
+	0xda, 0xbc, 0x7f, 0x0f, 0x24, 0xaa, 0x3f, 0x90, 0xe8, 0x27, 0xd8, 0x4b, 0x9c, 0x64, 0x52, 0x4d,
+	0xb4, 0xb2, 0x72, 0xa7, 0xab, 0x5b, 0xee, 0x8b, 0x51, 0x95, 0x59, 0x8a, 0x84, 0x24, 0x15, 0x66,
+	0xe6, 0x1b, 0x6f, 0xfc, 0x96, 0xb8, 0x90, 0x5b, 0xba, 0x5f, 0xe4, 0x89, 0x22, 0xd9, 0x74, 0x40,
+	0xab, 0x27, 0x5b, 0xbc, 0x91, 0xe0, 0x25, 0x99, 0xe9, 0x90, 0xe4, 0x96, 0x32, 0x7d, 0x5f, 0x7f,
+	0x51, 0x9b, 0x78, 0xfc, 0x66, 0x7e, 0xdd, 0x70, 0xe9, 0xad, 0xc9, 0x5f, 0x22, 0xba, 0x37, 0x5f,
+	0x98, 0x13, 0x7a, 0x87, 0xd7, 0xe2, 0xc7, 0x21, 0xfc, 0x79, 0xf8, 0x96, 0x2d, 0xdc, 0x45, 0xf3,
+	0x3a, 0x2b, 0x7f, 0x0a, 0xbe, 0xfc, 0x7f, 0x00, 0x08, 0x5f, 0xcd, 0x1c, 0x53, 0x08, 0x00, 0x00,
+}
@@ -0,0 +1,160 @@
+package svcv2
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tkeech1/gowebsvc/jobs"
+	"github.com/tkeech1/gowebsvc/middleware"
+	"github.com/tkeech1/gowebsvc/store"
+	service "github.com/tkeech1/gowebsvc/svc"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the v2 GreetingService on top of a Greeter. The locale and
+// formality of a request, and the accept-language metadata, are passed to the Greeter
+// in the context. The history is read from Greetings, which is nil when greetings are
+// not recorded; a caller only reads their own greetings. Background operations run on
+// Jobs, which is nil when they are disabled; a caller only sees and cancels their own
+// operations.
+type Server struct {
+	Next      service.Greeter
+	Greetings store.Repository
+	Jobs      *jobs.Pool
+}
+
+func (s Server) Greet(ctx context.Context, in *GreetRequest) (*GreetResponse, error) {
+	o := service.GreetOptionsFromContext(ctx)
+	o.Locale = in.GetLocale()
+	o.Formality = FormalityToService(in.GetFormality())
+	o.Count = int(in.GetCount())
+	o.Template = in.GetTemplate()
+	ctx = service.IncomingAcceptLanguageContext(service.NewGreetOptionsContext(ctx, o))
+	greeting, err := s.Next.Greet(ctx, in.GetName())
+	if err != nil {
+		return nil, status.Error(service.Code(err), err.Error())
+	}
+	return &GreetResponse{Greeting: greeting, Locale: service.ResolveLocale(ctx)}, nil
+}
+
+func (s Server) Expensive(ctx context.Context, in *ExpensiveRequest) (*ExpensiveResponse, error) {
+	v, err := s.Next.Expensive(ctx, in.GetConnectionString(), in.GetUsername(), in.GetPassword())
+	if err != nil {
+		return nil, status.Error(service.Code(err), err.Error())
+	}
+	return &ExpensiveResponse{Status: v}, nil
+}
+
+func (s Server) StartExpensive(ctx context.Context, in *ExpensiveRequest) (*Operation, error) {
+	if s.Jobs == nil {
+		return nil, status.Error(service.Code(service.ErrJobsDisabled), service.ErrJobsDisabled.Error())
+	}
+	j, err := s.Jobs.Submit(ctx, owner(ctx), func(ctx context.Context) (string, error) {
+		return s.Next.Expensive(ctx, in.GetConnectionString(), in.GetUsername(), in.GetPassword())
+	})
+	if err != nil {
+		return nil, status.Error(service.Code(err), err.Error())
+	}
+	return operation(j), nil
+}
+
+func (s Server) GetOperation(ctx context.Context, in *GetOperationRequest) (*Operation, error) {
+	if s.Jobs == nil {
+		return nil, status.Error(service.Code(service.ErrJobsDisabled), service.ErrJobsDisabled.Error())
+	}
+	j, err := s.Jobs.Get(owner(ctx), in.GetName())
+	if err != nil {
+		return nil, status.Error(service.Code(err), err.Error())
+	}
+	return operation(j), nil
+}
+
+func (s Server) CancelOperation(ctx context.Context, in *CancelOperationRequest) (*Operation, error) {
+	if s.Jobs == nil {
+		return nil, status.Error(service.Code(service.ErrJobsDisabled), service.ErrJobsDisabled.Error())
+	}
+	j, err := s.Jobs.Cancel(owner(ctx), in.GetName())
+	if err != nil {
+		return nil, status.Error(service.Code(err), err.Error())
+	}
+	return operation(j), nil
+}
+
+// owner returns the caller the jobs and greetings of a call belong to.
+func owner(ctx context.Context) string {
+	caller, _ := middleware.CallerFromContext(ctx)
+	return caller.ID
+}
+
+// operation returns j as an Operation. Like the REST job, it leaves out the response.
+func operation(j jobs.Job) *Operation {
+	w := service.NewExpensiveJob(j)
+	return &Operation{
+		Name:      w.ID,
+		Done:      j.State.Done(),
+		State:     OperationState(OperationState_value[strings.ToUpper(w.State)]),
+		Error:     w.Err,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+func (s Server) GetGreeting(ctx context.Context, in *GetGreetingRequest) (*StoredGreeting, error) {
+	if s.Greetings == nil {
+		return nil, status.Error(service.Code(service.ErrHistoryDisabled), service.ErrHistoryDisabled.Error())
+	}
+	g, err := s.Greetings.Get(ctx, in.GetId())
+	if err == nil && g.Caller != owner(ctx) {
+		err = store.ErrNotFound
+	}
+	if err != nil {
+		return nil, status.Error(service.Code(err), err.Error())
+	}
+	return storedGreeting(g), nil
+}
+
+func (s Server) ListGreetings(ctx context.Context, in *ListGreetingsRequest) (*ListGreetingsResponse, error) {
+	if s.Greetings == nil {
+		return nil, status.Error(service.Code(service.ErrHistoryDisabled), service.ErrHistoryDisabled.Error())
+	}
+	since, err := service.ParseSince(in.GetSince())
+	if err != nil {
+		return nil, status.Error(service.Code(err), err.Error())
+	}
+	page, err := s.Greetings.List(ctx, store.Filter{Since: since, Caller: owner(ctx)}, in.GetPageToken(), int(in.GetPageSize()))
+	if err != nil {
+		return nil, status.Error(service.Code(err), err.Error())
+	}
+	response := &ListGreetingsResponse{NextPageToken: page.NextPageToken}
+	for _, g := range page.Greetings {
+		response.Greetings = append(response.Greetings, storedGreeting(g))
+	}
+	return response, nil
+}
+
+func storedGreeting(g store.Greeting) *StoredGreeting {
+	w := service.NewStoredGreeting(g)
+	return &StoredGreeting{Id: w.ID, Name: w.Name, Greeting: w.Greeting, Locale: w.Locale, Caller: w.Caller, CreatedAt: w.CreatedAt}
+}
+
+// FormalityToService converts a proto formality to the service's.
+func FormalityToService(f Formality) service.Formality {
+	switch f {
+	case Formality_INFORMAL:
+		return service.Informal
+	case Formality_FORMAL:
+		return service.Formal
+	}
+	return service.FormalityUnspecified
+}
+
+// FormalityFromService converts a service formality to the proto's.
+func FormalityFromService(f service.Formality) Formality {
+	switch f {
+	case service.Informal:
+		return Formality_INFORMAL
+	case service.Formal:
+		return Formality_FORMAL
+	}
+	return Formality_FORMALITY_UNSPECIFIED
+}
@@ -0,0 +1,42 @@
+package svc
+
+import (
+	"time"
+
+	"github.com/tkeech1/gowebsvc/webhook"
+)
+
+// NewWebhook returns the wire format of a webhook subscription, without its secret.
+func NewWebhook(s webhook.Subscription) Webhook {
+	events := s.Events
+	if events == nil {
+		events = []string{}
+	}
+	return Webhook{
+		ID:        s.ID,
+		URL:       s.URL,
+		Events:    events,
+		CreatedAt: s.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// NewWebhookDelivery returns the wire format of a webhook delivery.
+func NewWebhookDelivery(d webhook.Delivery) WebhookDelivery {
+	delivery := WebhookDelivery{
+		ID:        d.ID,
+		WebhookID: d.SubscriptionID,
+		URL:       d.URL,
+		EventID:   d.EventID,
+		EventType: d.EventType,
+		State:     string(d.State),
+		Attempts:  []WebhookAttempt{},
+	}
+	for _, a := range d.Attempts {
+		delivery.Attempts = append(delivery.Attempts, WebhookAttempt{
+			At:         a.At.UTC().Format(time.RFC3339Nano),
+			StatusCode: a.StatusCode,
+			Err:        a.Err,
+		})
+	}
+	return delivery
+}
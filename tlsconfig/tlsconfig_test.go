@@ -0,0 +1,242 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCert creates a certificate signed by parent (self-signed when parent is nil)
+// and writes it to dir as <name>.pem and <name>-key.pem.
+func writeCert(t *testing.T, dir, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".pem"), certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name+"-key.pem"), keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+func newPKI(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "tlsconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, caKey := writeCert(t, dir, "ca", true, nil, nil)
+	writeCert(t, dir, "server", false, ca, caKey)
+	writeCert(t, dir, "client", false, ca, caKey)
+	return dir
+}
+
+func Test_MutualTLS(t *testing.T) {
+	dir := newPKI(t)
+	defer os.RemoveAll(dir)
+
+	serverCfg, _, err := Server(Options{
+		CertFile: filepath.Join(dir, "server.pem"),
+		KeyFile:  filepath.Join(dir, "server-key.pem"),
+		CAFile:   filepath.Join(dir, "ca.pem"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	ts.TLS = serverCfg
+	ts.StartTLS()
+	defer ts.Close()
+
+	tests := map[string]struct {
+		options Options
+		success bool
+	}{
+		"client_cert": {
+			options: Options{
+				CertFile:   filepath.Join(dir, "client.pem"),
+				KeyFile:    filepath.Join(dir, "client-key.pem"),
+				CAFile:     filepath.Join(dir, "ca.pem"),
+				ServerName: "localhost",
+			},
+			success: true,
+		},
+		"no_client_cert": {
+			options: Options{CAFile: filepath.Join(dir, "ca.pem"), ServerName: "localhost"},
+			success: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		clientCfg, _, err := Client(test.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientCfg}}
+		res, err := client.Get(ts.URL)
+		if !test.success {
+			assert.NotNil(t, err)
+			continue
+		}
+		if assert.Nil(t, err) {
+			body, _ := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			assert.Equal(t, "client", string(body))
+		}
+	}
+}
+
+func Test_Options(t *testing.T) {
+	_, err := base(Options{MinVersion: "1.3", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}})
+	assert.Nil(t, err)
+	_, err = base(Options{MinVersion: "2.0"})
+	assert.NotNil(t, err)
+	_, err = base(Options{CipherSuites: []string{"TLS_NOPE"}})
+	assert.NotNil(t, err)
+	_, _, err = Server(Options{})
+	assert.NotNil(t, err)
+}
+
+func Test_Reload(t *testing.T) {
+	dir := newPKI(t)
+	defer os.RemoveAll(dir)
+
+	r, err := NewReloader(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, _ := r.GetCertificate(nil)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.Watch(10*time.Millisecond, log.New(ioutil.Discard, "", 0), stop)
+
+	// replace the server certificate and push its modification time forward
+	writeCert(t, dir, "server", false, nil, nil)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "server.pem"), future, future)
+
+	assert.Eventually(t, func() bool {
+		after, _ := r.GetCertificate(nil)
+		return after != before
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_ReloadClientCA(t *testing.T) {
+	dir := newPKI(t)
+	defer os.RemoveAll(dir)
+	// a client whose certificate is signed by a CA the server does not trust yet
+	ca2, ca2Key := writeCert(t, dir, "ca2", true, nil, nil)
+	writeCert(t, dir, "client2", false, ca2, ca2Key)
+	serverCA := filepath.Join(dir, "server-ca.pem")
+	copyFile(t, filepath.Join(dir, "ca.pem"), serverCA)
+
+	serverCfg, r, err := Server(Options{
+		CertFile: filepath.Join(dir, "server.pem"),
+		KeyFile:  filepath.Join(dir, "server-key.pem"),
+		CAFile:   serverCA,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.Watch(10*time.Millisecond, log.New(ioutil.Discard, "", 0), stop)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	ts.TLS = serverCfg
+	ts.StartTLS()
+	defer ts.Close()
+
+	clientCfg, _, err := Client(Options{
+		CertFile:   filepath.Join(dir, "client2.pem"),
+		KeyFile:    filepath.Join(dir, "client2-key.pem"),
+		CAFile:     filepath.Join(dir, "ca.pem"),
+		ServerName: "localhost",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func() (string, error) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientCfg, DisableKeepAlives: true}}
+		res, err := client.Get(ts.URL)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		return string(body), err
+	}
+
+	_, err = get()
+	assert.NotNil(t, err)
+
+	// rotate the CA trusted by the server
+	copyFile(t, filepath.Join(dir, "ca2.pem"), serverCA)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(serverCA, future, future)
+
+	assert.Eventually(t, func() bool {
+		body, err := get()
+		return err == nil && body == "client2"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func copyFile(t *testing.T, from, to string) {
+	b, err := ioutil.ReadFile(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(to, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set on every delivery.
+const (
+	DeliveryHeader  = "X-Webhook-Delivery"
+	EventHeader     = "X-Webhook-Event"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign sets the timestamp and signature headers of a delivery of body. The signature
+// is "sha256=" followed by the hex HMAC-SHA256, keyed with secret, of the Unix
+// timestamp, a dot and the body.
+func Sign(h http.Header, secret string, t time.Time, body []byte) {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	h.Set(TimestampHeader, timestamp)
+	h.Set(SignatureHeader, "sha256="+signature(secret, timestamp, body))
+}
+
+// Verify checks the signature of a delivery received by a webhook receiver, rejecting
+// deliveries signed more than tolerance away from now to prevent replays.
+func Verify(h http.Header, secret string, body []byte, tolerance time.Duration) error {
+	timestamp := h.Get(TimestampHeader)
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if d := time.Since(time.Unix(sec, 0)); d > tolerance || d < -tolerance {
+		return ErrInvalidSignature
+	}
+	sig := strings.TrimPrefix(h.Get(SignatureHeader), "sha256=")
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
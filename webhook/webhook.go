@@ -0,0 +1,383 @@
+// Package webhook delivers events to callback URLs registered by clients. Subscriptions
+// belong to the caller registering them, who only receives the events of their own
+// calls. Payloads are signed with the subscription's secret, failed deliveries are
+// retried with backoff, and deliveries that never succeed are kept as dead letters.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tkeech1/gowebsvc/retry"
+)
+
+// Event types delivered to subscriptions.
+const (
+	GreetingCreated       = "greeting.created"
+	ExpensiveJobCompleted = "expensive_job.completed"
+)
+
+// EventTypes lists the event types a subscription may ask for.
+var EventTypes = []string{GreetingCreated, ExpensiveJobCompleted}
+
+var (
+	ErrNotFound     = errors.New("webhook not found")
+	ErrInvalidURL   = errors.New("webhook url must be an absolute http or https URL")
+	ErrPrivateURL   = errors.New("webhook url must not point to a private, loopback or link-local address")
+	ErrUnknownEvent = errors.New("unknown webhook event")
+)
+
+// Subscription is a callback URL registered by Owner for some event types; no types
+// means all of them.
+type Subscription struct {
+	ID        string
+	Owner     string
+	URL       string
+	Secret    string
+	Events    []string
+	CreatedAt time.Time
+}
+
+func (s Subscription) wants(eventType string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is the JSON payload posted to subscriptions.
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type DeliveryState string
+
+const (
+	Pending   DeliveryState = "pending"
+	Delivered DeliveryState = "delivered"
+	Dead      DeliveryState = "dead"
+)
+
+// Attempt is one try at posting an event. StatusCode is 0 when no response arrived.
+type Attempt struct {
+	At         time.Time
+	StatusCode int
+	Err        string
+}
+
+// Delivery is the delivery of an event to a subscription of Owner.
+type Delivery struct {
+	ID             string
+	SubscriptionID string
+	Owner          string
+	URL            string
+	EventID        string
+	EventType      string
+	State          DeliveryState
+	Attempts       []Attempt
+}
+
+// DefaultPolicy tries a delivery five times over about half a minute.
+var DefaultPolicy = retry.Policy{
+	MaxAttempts:    5,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+	Multiplier:     3,
+	Jitter:         0.2,
+}
+
+// DefaultRetain is the number of finished deliveries a Dispatcher keeps by default.
+const DefaultRetain = 1000
+
+// Dispatcher keeps the subscriptions and delivers published events to them in the
+// background. Deliveries are tried up to Policy.MaxAttempts times; a delivery is dead
+// once all attempts failed or the receiver rejected it with a 4xx status other than
+// 408 and 429. The last Retain finished deliveries are kept for inspection.
+//
+// URLs naming a private, loopback or link-local address are rejected unless
+// AllowPrivate is set. Host names are only resolved when delivering, so Client should
+// refuse to dial such addresses too; NewClient returns one that does.
+type Dispatcher struct {
+	Client       *http.Client
+	Policy       retry.Policy
+	Retain       int
+	Logger       *log.Logger
+	AllowPrivate bool
+
+	now    func() time.Time
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu            sync.Mutex
+	subscriptions map[string]Subscription
+	deliveries    []*Delivery
+	closed        bool
+}
+
+func NewDispatcher(client *http.Client, policy retry.Policy, logger *log.Logger) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		Client:        client,
+		Policy:        policy,
+		Retain:        DefaultRetain,
+		Logger:        logger,
+		now:           time.Now,
+		ctx:           ctx,
+		cancel:        cancel,
+		subscriptions: map[string]Subscription{},
+	}
+}
+
+// Subscribe registers rawurl for the events of owner's calls of the given types. A
+// random secret is generated when secret is empty.
+func (d *Dispatcher) Subscribe(owner, rawurl, secret string, events []string) (Subscription, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Subscription{}, ErrInvalidURL
+	}
+	if !d.AllowPrivate && privateHost(u.Hostname()) {
+		return Subscription{}, ErrPrivateURL
+	}
+	for _, e := range events {
+		if !knownEvent(e) {
+			return Subscription{}, ErrUnknownEvent
+		}
+	}
+	id, err := newID()
+	if err != nil {
+		return Subscription{}, err
+	}
+	if secret == "" {
+		if secret, err = newID(); err != nil {
+			return Subscription{}, err
+		}
+	}
+	s := Subscription{ID: id, Owner: owner, URL: rawurl, Secret: secret, Events: events, CreatedAt: d.now()}
+	d.mu.Lock()
+	d.subscriptions[id] = s
+	d.mu.Unlock()
+	return s, nil
+}
+
+// Unsubscribe removes owner's subscription with id. Deliveries already started are
+// still retried.
+func (d *Dispatcher) Unsubscribe(owner, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if s, ok := d.subscriptions[id]; !ok || s.Owner != owner {
+		return ErrNotFound
+	}
+	delete(d.subscriptions, id)
+	return nil
+}
+
+// Subscriptions returns owner's subscriptions, oldest first.
+func (d *Dispatcher) Subscriptions(owner string) []Subscription {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var subscriptions []Subscription
+	for _, s := range d.subscriptions {
+		if s.Owner == owner {
+			subscriptions = append(subscriptions, s)
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+	return subscriptions
+}
+
+// Publish delivers an event of eventType carrying data, caused by a call of owner, to
+// every subscription of owner wanting it. It does not wait for the deliveries.
+func (d *Dispatcher) Publish(owner, eventType string, data interface{}) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+	event := Event{ID: id, Type: eventType, CreatedAt: d.now().UTC(), Data: data}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return nil
+	}
+	for _, s := range d.subscriptions {
+		if s.Owner != owner || !s.wants(eventType) {
+			continue
+		}
+		deliveryID, err := newID()
+		if err != nil {
+			return err
+		}
+		delivery := &Delivery{ID: deliveryID, SubscriptionID: s.ID, Owner: owner, URL: s.URL, EventID: id, EventType: eventType, State: Pending}
+		d.deliveries = append(d.deliveries, delivery)
+		d.wg.Add(1)
+		go d.deliver(delivery, s.Secret, body)
+	}
+	return nil
+}
+
+// Deliveries returns the deliveries to owner's subscription with id, or to all of
+// owner's subscriptions when id is empty, oldest first.
+func (d *Dispatcher) Deliveries(owner, id string) []Delivery {
+	return d.filter(func(delivery *Delivery) bool {
+		return delivery.Owner == owner && (id == "" || delivery.SubscriptionID == id)
+	})
+}
+
+// DeadLetters returns the deliveries to owner's subscriptions that failed for good,
+// oldest first.
+func (d *Dispatcher) DeadLetters(owner string) []Delivery {
+	return d.filter(func(delivery *Delivery) bool {
+		return delivery.Owner == owner && delivery.State == Dead
+	})
+}
+
+func (d *Dispatcher) filter(keep func(*Delivery) bool) []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var deliveries []Delivery
+	for _, delivery := range d.deliveries {
+		if keep(delivery) {
+			c := *delivery
+			c.Attempts = append([]Attempt(nil), delivery.Attempts...)
+			deliveries = append(deliveries, c)
+		}
+	}
+	return deliveries
+}
+
+// Close stops retrying and waits for the attempts in flight.
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+	d.cancel()
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) deliver(delivery *Delivery, secret string, body []byte) {
+	defer d.wg.Done()
+	for attempt := 1; ; attempt++ {
+		status, err := d.post(delivery, secret, body)
+		a := Attempt{At: d.now().UTC(), StatusCode: status}
+		if err != nil {
+			a.Err = err.Error()
+		}
+		ok := err == nil && status >= 200 && status < 300
+		retryable := err != nil || status >= 500 || status == http.StatusRequestTimeout || status == http.StatusTooManyRequests
+
+		d.mu.Lock()
+		delivery.Attempts = append(delivery.Attempts, a)
+		switch {
+		case ok:
+			delivery.State = Delivered
+		case !retryable || attempt >= d.Policy.MaxAttempts:
+			delivery.State = Dead
+		}
+		state := delivery.State
+		if state != Pending {
+			d.trim()
+		}
+		d.mu.Unlock()
+
+		if state == Dead {
+			d.Logger.Printf("webhook delivery %s of event %s to %s failed after %d attempts", delivery.ID, delivery.EventID, delivery.URL, attempt)
+		}
+		if state != Pending {
+			return
+		}
+		select {
+		case <-time.After(d.Policy.Backoff(attempt)):
+		case <-d.ctx.Done():
+			d.mu.Lock()
+			delivery.State = Dead
+			d.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) post(delivery *Delivery, secret string, body []byte) (int, error) {
+	req, err := http.NewRequest("POST", delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(d.ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(EventHeader, delivery.EventType)
+	Sign(req.Header, secret, d.now(), body)
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// trim forgets the oldest finished deliveries beyond Retain. d.mu must be held.
+func (d *Dispatcher) trim() {
+	finished := 0
+	for _, delivery := range d.deliveries {
+		if delivery.State != Pending {
+			finished++
+		}
+	}
+	kept := d.deliveries[:0]
+	for _, delivery := range d.deliveries {
+		if delivery.State != Pending && finished > d.Retain {
+			finished--
+			continue
+		}
+		kept = append(kept, delivery)
+	}
+	for i := len(kept); i < len(d.deliveries); i++ {
+		d.deliveries[i] = nil
+	}
+	d.deliveries = kept
+}
+
+func knownEvent(eventType string) bool {
+	for _, e := range EventTypes {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
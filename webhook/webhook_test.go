@@ -0,0 +1,283 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tkeech1/gowebsvc/retry"
+)
+
+var testPolicy = retry.Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Multiplier: 1}
+
+// receiver records the deliveries it receives and answers them with the next of
+// statuses, repeating the last one.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	headers  []http.Header
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	status := rc.statuses[0]
+	if len(rc.statuses) > 1 {
+		rc.statuses = rc.statuses[1:]
+	}
+	rc.headers = append(rc.headers, r.Header)
+	rc.bodies = append(rc.bodies, body)
+	w.WriteHeader(status)
+}
+
+// wait returns the deliveries once none of them is pending.
+func wait(t *testing.T, d *Dispatcher) []Delivery {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		deliveries := d.Deliveries("alice", "")
+		pending := false
+		for _, delivery := range deliveries {
+			pending = pending || delivery.State == Pending
+		}
+		if !pending {
+			return deliveries
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("deliveries did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// newTestDispatcher returns a dispatcher delivering to the loopback receivers of the
+// tests.
+func newTestDispatcher() *Dispatcher {
+	d := NewDispatcher(http.DefaultClient, testPolicy, log.New(ioutil.Discard, "", 0))
+	d.AllowPrivate = true
+	return d
+}
+
+func Test_Deliver(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusOK}}
+	ts := httptest.NewServer(rc)
+	defer ts.Close()
+	d := newTestDispatcher()
+	defer d.Close()
+
+	sub, err := d.Subscribe("alice", ts.URL, "s3cret", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, d.Publish("alice", GreetingCreated, map[string]string{"greeting": "hello"}))
+
+	deliveries := wait(t, d)
+	if !assert.Len(t, deliveries, 1) {
+		return
+	}
+	assert.Equal(t, Delivered, deliveries[0].State)
+	assert.Equal(t, sub.ID, deliveries[0].SubscriptionID)
+	assert.Equal(t, GreetingCreated, deliveries[0].EventType)
+	if assert.Len(t, deliveries[0].Attempts, 1) {
+		assert.Equal(t, http.StatusOK, deliveries[0].Attempts[0].StatusCode)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	h, body := rc.headers[0], rc.bodies[0]
+	assert.Nil(t, Verify(h, "s3cret", body, time.Minute))
+	assert.Equal(t, ErrInvalidSignature, Verify(h, "other", body, time.Minute))
+	assert.Equal(t, ErrInvalidSignature, Verify(h, "s3cret", append(body, ' '), time.Minute))
+	assert.Equal(t, deliveries[0].ID, h.Get(DeliveryHeader))
+	assert.Equal(t, GreetingCreated, h.Get(EventHeader))
+
+	var event struct {
+		ID   string            `json:"id"`
+		Type string            `json:"type"`
+		Data map[string]string `json:"data"`
+	}
+	assert.Nil(t, json.Unmarshal(body, &event))
+	assert.Equal(t, deliveries[0].EventID, event.ID)
+	assert.Equal(t, GreetingCreated, event.Type)
+	assert.Equal(t, "hello", event.Data["greeting"])
+}
+
+func Test_DeliverRetries(t *testing.T) {
+	tests := map[string]struct {
+		statuses         []int
+		expectedState    DeliveryState
+		expectedAttempts int
+	}{
+		"recovers": {
+			statuses:         []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusNoContent},
+			expectedState:    Delivered,
+			expectedAttempts: 3,
+		},
+		"exhausted": {
+			statuses:         []int{http.StatusServiceUnavailable},
+			expectedState:    Dead,
+			expectedAttempts: 3,
+		},
+		"rejected": {
+			statuses:         []int{http.StatusGone},
+			expectedState:    Dead,
+			expectedAttempts: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		ts := httptest.NewServer(&receiver{statuses: test.statuses})
+		d := newTestDispatcher()
+		_, err := d.Subscribe("alice", ts.URL, "", nil)
+		assert.Nil(t, err)
+		assert.Nil(t, d.Publish("alice", ExpensiveJobCompleted, "done"))
+
+		deliveries := wait(t, d)
+		if assert.Len(t, deliveries, 1) {
+			assert.Equal(t, test.expectedState, deliveries[0].State)
+			assert.Len(t, deliveries[0].Attempts, test.expectedAttempts)
+		}
+		assert.Equal(t, test.expectedState == Dead, len(d.DeadLetters("alice")) == 1)
+		d.Close()
+		ts.Close()
+	}
+
+	// unreachable receivers are retried too
+	d := newTestDispatcher()
+	defer d.Close()
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+	_, err := d.Subscribe("alice", ts.URL, "", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, d.Publish("alice", GreetingCreated, "hello"))
+	dead := wait(t, d)
+	if assert.Len(t, dead, 1) {
+		assert.Equal(t, Dead, dead[0].State)
+		assert.Len(t, dead[0].Attempts, 3)
+		assert.Equal(t, 0, dead[0].Attempts[0].StatusCode)
+		assert.NotEmpty(t, dead[0].Attempts[0].Err)
+	}
+}
+
+func Test_Subscribe(t *testing.T) {
+	d := newTestDispatcher()
+	defer d.Close()
+
+	tests := map[string]struct {
+		url           string
+		events        []string
+		expectedError error
+	}{
+		"valid":          {url: "https://example.com/hook", events: []string{GreetingCreated}},
+		"relative_url":   {url: "/hook", expectedError: ErrInvalidURL},
+		"other_scheme":   {url: "ftp://example.com/hook", expectedError: ErrInvalidURL},
+		"unknown_event":  {url: "https://example.com/hook", events: []string{"greeting.deleted"}, expectedError: ErrUnknownEvent},
+		"invalid_syntax": {url: "http://[::1", expectedError: ErrInvalidURL},
+	}
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		sub, err := d.Subscribe("alice", test.url, "", test.events)
+		assert.Equal(t, test.expectedError, err)
+		if err == nil {
+			assert.NotEmpty(t, sub.Secret)
+		}
+	}
+
+	subs := d.Subscriptions("alice")
+	if assert.Len(t, subs, 1) {
+		assert.Equal(t, ErrNotFound, d.Unsubscribe("alice", "missing"))
+		assert.Nil(t, d.Unsubscribe("alice", subs[0].ID))
+	}
+	assert.Empty(t, d.Subscriptions("alice"))
+}
+
+func Test_PublishFilters(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusOK}}
+	ts := httptest.NewServer(rc)
+	defer ts.Close()
+	d := newTestDispatcher()
+	defer d.Close()
+	d.Retain = 2
+
+	greetings, err := d.Subscribe("alice", ts.URL+"/greetings", "", []string{GreetingCreated})
+	assert.Nil(t, err)
+	all, err := d.Subscribe("alice", ts.URL+"/all", "", nil)
+	assert.Nil(t, err)
+
+	assert.Nil(t, d.Publish("alice", ExpensiveJobCompleted, "done"))
+	wait(t, d)
+	assert.Len(t, d.Deliveries("alice", greetings.ID), 0)
+	assert.Len(t, d.Deliveries("alice", all.ID), 1)
+
+	assert.Nil(t, d.Publish("alice", GreetingCreated, "hello"))
+	wait(t, d)
+	assert.Nil(t, d.Publish("alice", GreetingCreated, "hello"))
+	// only the last Retain finished deliveries are kept
+	assert.Len(t, wait(t, d), 2)
+}
+
+func Test_Owners(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusOK}}
+	ts := httptest.NewServer(rc)
+	defer ts.Close()
+	d := newTestDispatcher()
+	defer d.Close()
+
+	alice, err := d.Subscribe("alice", ts.URL+"/alice", "", nil)
+	assert.Nil(t, err)
+	_, err = d.Subscribe("bob", ts.URL+"/bob", "", nil)
+	assert.Nil(t, err)
+
+	// subscribers only get the events of their own calls
+	assert.Nil(t, d.Publish("alice", GreetingCreated, "hello"))
+	wait(t, d)
+	deliveries := d.Deliveries("alice", "")
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, alice.ID, deliveries[0].SubscriptionID)
+	}
+	assert.Empty(t, d.Deliveries("bob", ""))
+	assert.Empty(t, d.Deliveries("bob", alice.ID))
+
+	// and only see and remove their own subscriptions
+	if subs := d.Subscriptions("bob"); assert.Len(t, subs, 1) {
+		assert.Equal(t, ts.URL+"/bob", subs[0].URL)
+	}
+	assert.Equal(t, ErrNotFound, d.Unsubscribe("bob", alice.ID))
+	assert.Len(t, d.Subscriptions("alice"), 1)
+}
+
+func Test_PrivateTargets(t *testing.T) {
+	d := NewDispatcher(http.DefaultClient, testPolicy, log.New(ioutil.Discard, "", 0))
+	defer d.Close()
+
+	tests := map[string]struct {
+		url           string
+		expectedError error
+	}{
+		"public":     {url: "https://93.184.216.34/hook"},
+		"host_name":  {url: "https://example.com/hook"},
+		"loopback":   {url: "http://127.0.0.1:8080/hook", expectedError: ErrPrivateURL},
+		"localhost":  {url: "http://localhost/hook", expectedError: ErrPrivateURL},
+		"private":    {url: "http://10.1.2.3/hook", expectedError: ErrPrivateURL},
+		"link_local": {url: "http://169.254.169.254/latest/meta-data", expectedError: ErrPrivateURL},
+		"ipv6":       {url: "http://[::1]/hook", expectedError: ErrPrivateURL},
+		"ula":        {url: "http://[fd00::1]/hook", expectedError: ErrPrivateURL},
+	}
+	for name, test := range tests {
+		t.Logf("Running test case: %s", name)
+		_, err := d.Subscribe("alice", test.url, "", nil)
+		assert.Equal(t, test.expectedError, err)
+	}
+
+	// whatever a host name resolves to, the client refuses to dial such addresses
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	_, err := NewClient(time.Second).Get(ts.URL)
+	assert.NotNil(t, err)
+}